@@ -0,0 +1,225 @@
+// assetc preprocesses a resources folder for shipping. Images get their
+// mip chains made ahead of time as KTX files, folders of PBR maps get their
+// metallic, roughness and AO packed into one ORM texture and OBJ models
+// become mesh files the mesh package maps straight into buffers. Every
+// file is copied as well, so the MTLs' images are still there and the
+// output loads with model.Load the same as the input. A manifest lists
+// what was written with content hashes.
+//
+// It's pure Go and needs no GPU, and the same resources always make the
+// same bytes so it can run in CI and be checked with diff.
+//
+//	go run ./cmd/assetc -in resources -out build/resources
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A job writes outputs from sources, both slash separated paths relative
+// to the input and output folders
+type job struct {
+	sources []string
+	outputs []string
+	run     func(in, out string) error
+}
+
+func main() {
+	in := flag.String("in", "resources", "folder to read")
+	out := flag.String("out", "build/resources", "folder to write")
+	workers := flag.Int("j", runtime.NumCPU(), "jobs to run at once")
+	flag.Parse()
+
+	if err := build(*in, *out, *workers); err != nil {
+		fmt.Fprintln(os.Stderr, "assetc:", err)
+		os.Exit(1)
+	}
+}
+
+func build(in, out string, workers int) error {
+	files, err := listFiles(in)
+	if err != nil {
+		return err
+	}
+	jobs, err := planJobs(files)
+	if err != nil {
+		return err
+	}
+
+	// Jobs write different files so they can run in any order, the
+	// manifest is sorted afterwards
+	var mu sync.Mutex
+	var assets []asset
+	var firstErr error
+	queue := make(chan job)
+	var wg sync.WaitGroup
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				err := j.run(in, out)
+				if err == nil {
+					var made []asset
+					made, err = describe(in, out, j)
+					mu.Lock()
+					assets = append(assets, made...)
+					mu.Unlock()
+				}
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%s: %v",
+							strings.Join(j.sources, ", "), err)
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return writeManifest(filepath.Join(out, MANIFEST), assets)
+}
+
+// listFiles is every file under root as a slash separated relative path,
+// in order. Hidden files are skipped.
+func listFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(root, func(name string, info os.FileInfo,
+		err error) error {
+
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && name != root {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// planJobs picks what to do with each file, and which folders get an ORM
+// texture
+func planJobs(files []string) ([]job, error) {
+	var jobs []job
+	channels := map[string]map[string]string{}
+	for _, file := range files {
+		file := file
+		jobs = append(jobs, job{sources: []string{file},
+			outputs: []string{file},
+			run: func(in, out string) error {
+				return copyFile(in, out, file)
+			}})
+
+		switch strings.ToLower(path.Ext(file)) {
+		case ".png", ".jpg", ".jpeg":
+			jobs = append(jobs, job{sources: []string{file},
+				outputs: []string{withExt(file, ".ktx")},
+				run: func(in, out string) error {
+					return mipmapTexture(in, out, file)
+				}})
+
+			dir, name := path.Dir(file), baseName(file)
+			if ormChannel(name) >= 0 {
+				if channels[dir] == nil {
+					channels[dir] = map[string]string{}
+				}
+				channels[dir][name] = file
+			}
+		case ".obj":
+			jobs = append(jobs, job{sources: []string{file},
+				outputs: []string{withExt(file, ".mesh")},
+				run: func(in, out string) error {
+					return convertOBJ(in, out, file)
+				}})
+		}
+	}
+
+	var dirs []string
+	for dir := range channels {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		maps := channels[dir]
+		// Packing a single map saves nothing
+		if len(maps) < 2 {
+			continue
+		}
+		var sources []string
+		for _, file := range maps {
+			sources = append(sources, file)
+		}
+		sort.Strings(sources)
+		orm := path.Join(dir, ORM_NAME)
+		jobs = append(jobs, job{sources: sources, outputs: []string{orm},
+			run: func(in, out string) error {
+				return packORM(in, out, orm, maps)
+			}})
+	}
+
+	// Say wall.png and wall.jpg would both be wall.ktx
+	made := map[string]string{MANIFEST: "the manifest"}
+	for _, j := range jobs {
+		for _, output := range j.outputs {
+			if other, ok := made[output]; ok {
+				return nil, fmt.Errorf("%s and %s would both write %s",
+					other, j.sources[0], output)
+			}
+			made[output] = j.sources[0]
+		}
+	}
+	return jobs, nil
+}
+
+// withExt changes the extension of a slash separated path
+func withExt(file, ext string) string {
+	return strings.TrimSuffix(file, path.Ext(file)) + ext
+}
+
+// baseName is the lower case file name without its extension
+func baseName(file string) string {
+	return strings.ToLower(strings.TrimSuffix(path.Base(file),
+		path.Ext(file)))
+}
+
+// create makes a file in the output folder and the folders it's in
+func create(out, file string) (*os.File, error) {
+	name := filepath.Join(out, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(name)
+}
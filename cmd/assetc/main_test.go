@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/meshdata"
+)
+
+const boxOBJ = `mtllib box.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+o box
+usemtl crate
+f 1/1 2/2 3/3 4/4
+`
+
+const boxMTL = `newmtl crate
+Kd 1 1 1
+map_Kd crate.png
+map_Bump -bm 1.0 normal.png
+`
+
+// writeFixture makes a small resources folder with a model, a PBR folder
+// and files that are only copied
+func writeFixture(t *testing.T, root string) {
+	t.Helper()
+	write := func(file string, data []byte) {
+		name := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePNG := func(file string, img image.Image) {
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err != nil {
+			t.Fatal(err)
+		}
+		write(file, b.Bytes())
+	}
+
+	crate := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for i := range crate.Pix {
+		crate.Pix[i] = uint8(i * 7)
+	}
+	normal := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := 0; i < 4; i++ {
+		normal.SetNRGBA(i%2, i/2, color.NRGBA{128, 128, 255, 255})
+	}
+	gray := func(w, h int, v uint8) *image.Gray {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for i := range img.Pix {
+			img.Pix[i] = v + uint8(i)
+		}
+		return img
+	}
+
+	write("models/box.obj", []byte(boxOBJ))
+	write("models/box.mtl", []byte(boxMTL))
+	writePNG("models/crate.png", crate)
+	writePNG("models/normal.png", normal)
+	writePNG("pbr/metallic.png", gray(4, 4, 10))
+	writePNG("pbr/roughness.png", gray(2, 2, 100))
+	write("shaders/notes.txt", []byte("copied as is\n"))
+	write(".hidden/skipped.txt", []byte("not copied\n"))
+}
+
+func readTree(t *testing.T, root string) map[string][]byte {
+	t.Helper()
+	files, err := listFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := map[string][]byte{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(root,
+			filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree[file] = data
+	}
+	return tree
+}
+
+func TestBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "assetc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	in := filepath.Join(dir, "in")
+	writeFixture(t, in)
+
+	// Built twice with a different number of workers
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	if err := build(in, first, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := build(in, second, 4); err != nil {
+		t.Fatal(err)
+	}
+	tree, again := readTree(t, first), readTree(t, second)
+	for file, data := range tree {
+		if other, ok := again[file]; !ok {
+			t.Errorf("%s is only in the first build", file)
+		} else if !bytes.Equal(data, other) {
+			t.Errorf("%s is different between builds", file)
+		}
+	}
+	for file := range again {
+		if _, ok := tree[file]; !ok {
+			t.Errorf("%s is only in the second build", file)
+		}
+	}
+
+	want := []string{
+		"manifest.json",
+		"models/box.mesh", "models/box.mtl", "models/box.obj",
+		"models/crate.ktx", "models/crate.png",
+		"models/normal.ktx", "models/normal.png",
+		"pbr/metallic.ktx", "pbr/metallic.png", "pbr/orm.ktx",
+		"pbr/roughness.ktx", "pbr/roughness.png",
+		"shaders/notes.txt",
+	}
+	got := mustList(t, first)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("built %v, want %v", got, want)
+	}
+
+	checkManifest(t, first, tree)
+	checkLoadable(t, tree)
+}
+
+func mustList(t *testing.T, root string) []string {
+	t.Helper()
+	files, err := listFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// checkManifest checks every output is listed once with its hash and the
+// sources it came from
+func checkManifest(t *testing.T, out string, tree map[string][]byte) {
+	t.Helper()
+	var m manifest
+	if err := json.Unmarshal(tree[MANIFEST], &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != MANIFEST_VERSION {
+		t.Errorf("manifest version %d", m.Version)
+	}
+	if len(m.Assets) != len(tree)-1 {
+		t.Errorf("manifest has %d assets for %d files", len(m.Assets),
+			len(tree)-1)
+	}
+	for _, a := range m.Assets {
+		hash, size, err := hashFile(filepath.Join(out,
+			filepath.FromSlash(a.Path)))
+		if err != nil {
+			t.Errorf("%s: %v", a.Path, err)
+			continue
+		}
+		if a.SHA256 != hash || a.Size != size {
+			t.Errorf("%s: manifest has %s of %d bytes, file is %s of %d",
+				a.Path, a.SHA256, a.Size, hash, size)
+		}
+		if len(a.Sources) == 0 {
+			t.Errorf("%s has no sources", a.Path)
+		}
+	}
+	if orm := findAsset(m.Assets, "pbr/orm.ktx"); orm == nil ||
+		len(orm.Sources) != 2 {
+		t.Errorf("orm.ktx isn't made from both maps: %+v", orm)
+	}
+}
+
+func findAsset(assets []asset, file string) *asset {
+	for i := range assets {
+		if assets[i].Path == file {
+			return &assets[i]
+		}
+	}
+	return nil
+}
+
+// checkLoadable checks the MTL's images are there, the KTXs have a full
+// mip chain and the mesh file has the OBJ's quad
+func checkLoadable(t *testing.T, tree map[string][]byte) {
+	t.Helper()
+	for _, line := range strings.Split(string(tree["models/box.mtl"]),
+		"\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "map_") {
+			continue
+		}
+		file := path.Join("models", fields[len(fields)-1])
+		if _, ok := tree[file]; !ok {
+			t.Errorf("box.mtl uses %s which wasn't written", file)
+		}
+	}
+
+	for _, test := range []struct {
+		file          string
+		width, height uint32
+		levels        uint32
+	}{
+		{"models/crate.ktx", 8, 4, 4},
+		{"pbr/orm.ktx", 4, 4, 3},
+	} {
+		data := tree[test.file]
+		if !bytes.HasPrefix(data, ktxIdentifier) || len(data) < 64 {
+			t.Errorf("%s isn't a KTX", test.file)
+			continue
+		}
+		field := func(i int) uint32 {
+			return binary.LittleEndian.Uint32(data[16+i*4:])
+		}
+		if field(5) != test.width || field(6) != test.height ||
+			field(10) != test.levels {
+			t.Errorf("%s is %dx%d with %d levels, want %dx%d with %d",
+				test.file, field(5), field(6), field(10), test.width,
+				test.height, test.levels)
+		}
+	}
+
+	f, err := meshdata.Decode(tree["models/box.mesh"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Meshes) != 1 {
+		t.Fatalf("box.mesh has %d meshes", len(f.Meshes))
+	}
+	m := f.Meshes[0]
+	if m.Name != "box" || m.Material != "crate" || m.VertexCount != 4 ||
+		m.IndexCount() != 6 {
+		t.Errorf("box.mesh has %q with %q, %d vertices and %d indices",
+			m.Name, m.Material, m.VertexCount, m.IndexCount())
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// MANIFEST is written at the top of the output folder
+const MANIFEST = "manifest.json"
+
+const MANIFEST_VERSION = 1
+
+type manifest struct {
+	Version int     `json:"version"`
+	Assets  []asset `json:"assets"`
+}
+
+// asset is a file that was written and the files it was made from, so
+// anything that changed can be found by comparing manifests
+type asset struct {
+	Path    string   `json:"path"`
+	Size    int64    `json:"size"`
+	SHA256  string   `json:"sha256"`
+	Sources []source `json:"sources"`
+}
+
+type source struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// describe hashes what a job wrote and what it read
+func describe(in, out string, j job) ([]asset, error) {
+	var sources []source
+	for _, file := range j.sources {
+		hash, _, err := hashFile(filepath.Join(in, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source{Path: file, SHA256: hash})
+	}
+
+	var assets []asset
+	for _, file := range j.outputs {
+		hash, size, err := hashFile(filepath.Join(out,
+			filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset{Path: file, Size: size, SHA256: hash,
+			Sources: sources})
+	}
+	return assets, nil
+}
+
+func hashFile(name string) (string, int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+func writeManifest(name string, assets []asset) error {
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].Path < assets[j].Path
+	})
+	data, err := json.MarshalIndent(manifest{Version: MANIFEST_VERSION,
+		Assets: assets}, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, append(data, '\n'), 0644)
+}
+
+func copyFile(in, out, file string) error {
+	r, err := os.Open(filepath.Join(in, filepath.FromSlash(file)))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := create(out, file)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/meshdata"
+)
+
+// convertOBJ writes an OBJ's meshes as a mesh file, with normals and
+// tangents made the same as model.LoadOBJ makes them. The MTL is copied
+// separately, meshes only keep the names of their materials.
+func convertOBJ(in, out, file string) error {
+	r, err := os.Open(filepath.Join(in, filepath.FromSlash(file)))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	groups, err := meshdata.ReadOBJ(bufio.NewReader(r), nil)
+	if err != nil {
+		return err
+	}
+	var meshes []meshdata.Mesh
+	for _, g := range groups {
+		g.ComputeNormals()
+		meshdata.ComputeTangents(g.Vertices, g.Indices)
+		meshes = append(meshes, meshdata.Pack(g.Object, g.Material,
+			g.Vertices, g.Indices))
+	}
+
+	f, err := create(out, withExt(file, ".mesh"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	if err := meshdata.Write(w, meshes); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
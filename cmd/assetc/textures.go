@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"path/filepath"
+
+	"github.com/disintegration/imaging"
+)
+
+// ORM_NAME is the texture made in folders of PBR maps, ambient occlusion
+// in red, roughness in green and metallic in blue like glTF
+const ORM_NAME = "orm.ktx"
+
+// The maps packed into an ORM texture, by file name, and the value each
+// channel has when its map is missing
+var ormMaps = []string{"ao", "roughness", "metallic"}
+var ormDefaults = []uint8{255, 255, 0}
+
+func ormChannel(name string) int {
+	for i, m := range ormMaps {
+		if m == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// GL's numbers for the formats written
+const (
+	glUnsignedByte = 0x1401
+	glRGB          = 0x1907
+	glRGBA         = 0x1908
+	glRGB8         = 0x8051
+	glRGBA8        = 0x8058
+)
+
+var ktxIdentifier = []byte("\xabKTX 11\xbb\r\n\x1a\n")
+
+// mipmapTexture writes an image as a KTX with every level down to 1x1.
+// Rows are kept top first like the image, the same as loading it without
+// Flip. Opaque images drop their alpha.
+func mipmapTexture(in, out, file string) error {
+	img, err := imaging.Open(filepath.Join(in, filepath.FromSlash(file)))
+	if err != nil {
+		return err
+	}
+	nrgba := imaging.Clone(img)
+	return writeKTX(out, withExt(file, ".ktx"), mipChain(nrgba),
+		!nrgba.Opaque())
+}
+
+// packORM writes the maps of a folder into the channels of one texture,
+// the size of the biggest of them
+func packORM(in, out, file string, maps map[string]string) error {
+	var channels [3]*image.NRGBA
+	var bounds image.Rectangle
+	for name, source := range maps {
+		img, err := imaging.Open(filepath.Join(in,
+			filepath.FromSlash(source)))
+		if err != nil {
+			return err
+		}
+		channels[ormChannel(name)] = imaging.Clone(img)
+		if b := img.Bounds(); b.Dx()*b.Dy() > bounds.Dx()*bounds.Dy() {
+			bounds = image.Rect(0, 0, b.Dx(), b.Dy())
+		}
+	}
+	for i, c := range channels {
+		if c != nil && c.Bounds().Size() != bounds.Size() {
+			channels[i] = imaging.Resize(c, bounds.Dx(), bounds.Dy(),
+				imaging.Linear)
+		}
+	}
+
+	orm := image.NewNRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			texel := color.NRGBA{A: 255}
+			values := []*uint8{&texel.R, &texel.G, &texel.B}
+			for i, c := range channels {
+				*values[i] = ormDefaults[i]
+				if c != nil {
+					// Maps are gray so any channel will do
+					*values[i] = c.Pix[c.PixOffset(x, y)]
+				}
+			}
+			orm.SetNRGBA(x, y, texel)
+		}
+	}
+	return writeKTX(out, file, mipChain(orm), false)
+}
+
+// mipChain halves an image with a box filter until it's 1x1
+func mipChain(img *image.NRGBA) []*image.NRGBA {
+	levels := []*image.NRGBA{img}
+	for {
+		w, h := img.Bounds().Dx(), img.Bounds().Dy()
+		if w == 1 && h == 1 {
+			return levels
+		}
+		img = imaging.Resize(img, max(1, w/2), max(1, h/2), imaging.Box)
+		levels = append(levels, img)
+	}
+}
+
+// writeKTX writes levels as an uncompressed 8 bit KTX, RGBA with alpha or
+// RGB without
+func writeKTX(out, file string, levels []*image.NRGBA, alpha bool) error {
+	f, err := create(out, file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	channels, format, internalFormat := 3, uint32(glRGB), uint32(glRGB8)
+	if alpha {
+		channels, format, internalFormat = 4, glRGBA, glRGBA8
+	}
+	size := levels[0].Bounds().Size()
+	w.Write(ktxIdentifier)
+	for _, field := range []uint32{
+		0x04030201,
+		glUnsignedByte, 1, format, internalFormat, format,
+		uint32(size.X), uint32(size.Y), 0,
+		// Array elements, faces, mip levels and key value bytes
+		0, 1, uint32(len(levels)), 0,
+	} {
+		binary.Write(w, binary.LittleEndian, field)
+	}
+
+	for _, level := range levels {
+		width, height := level.Bounds().Dx(), level.Bounds().Dy()
+		// Rows are padded to 4 bytes
+		row := (width*channels + 3) &^ 3
+		binary.Write(w, binary.LittleEndian, uint32(row*height))
+
+		pix := make([]byte, row)
+		for y := 0; y < height; y++ {
+			src := level.Pix[y*level.Stride:]
+			for x := 0; x < width; x++ {
+				copy(pix[x*channels:], src[x*4:x*4+channels])
+			}
+			w.Write(pix)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
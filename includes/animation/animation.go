@@ -0,0 +1,155 @@
+// Skeletal animation that doesn't need a GL context. Clips are sampled on
+// the CPU into a Pose, and a pose becomes the bone matrix palette the
+// vertex shader skins with.
+
+package animation
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type VectorKey struct {
+	Time  float32
+	Value mgl32.Vec3
+}
+
+type QuatKey struct {
+	Time  float32
+	Value mgl32.Quat
+}
+
+// Channel animates one node of the skeleton. Keys are in time order and
+// times are in seconds.
+type Channel struct {
+	Node      string
+	Positions []VectorKey
+	Rotations []QuatKey
+	Scales    []VectorKey
+}
+
+// Clip is a single animation like a walk cycle
+type Clip struct {
+	Name     string
+	Duration float32
+	Channels []Channel
+
+	// Channel index by node name
+	byNode map[string]int
+}
+
+func NewClip(name string, duration float32, channels []Channel) *Clip {
+	c := &Clip{Name: name, Duration: duration, Channels: channels,
+		byNode: map[string]int{}}
+	for i, channel := range channels {
+		c.byNode[channel.Node] = i
+	}
+	return c
+}
+
+// Channel gets the channel for a node or nil if the clip doesn't move it
+func (c *Clip) Channel(node string) *Channel {
+	i, ok := c.byNode[node]
+	if !ok {
+		return nil
+	}
+	return &c.Channels[i]
+}
+
+// Transform is a joint's local translation, rotation and scale
+type Transform struct {
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+}
+
+// Decompose splits a matrix with no shear into a Transform
+func Decompose(m mgl32.Mat4) Transform {
+	t := Transform{Translation: m.Col(3).Vec3(), Scale: mgl32.Vec3{
+		m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}}
+
+	rotation := m
+	for i := 0; i < 3; i++ {
+		if t.Scale[i] != 0 {
+			rotation.SetCol(i, m.Col(i).Mul(1.0/t.Scale[i]))
+		}
+	}
+	t.Rotation = mgl32.Mat4ToQuat(rotation).Normalize()
+	return t
+}
+
+func (t Transform) Mat4() mgl32.Mat4 {
+	return mgl32.Translate3D(t.Translation[0], t.Translation[1],
+		t.Translation[2]).Mul4(t.Rotation.Mat4()).Mul4(
+		mgl32.Scale3D(t.Scale[0], t.Scale[1], t.Scale[2]))
+}
+
+// Lerp interpolates towards u by w, taking the short way around for the
+// rotation
+func (t Transform) Lerp(u Transform, w float32) Transform {
+	rotation := u.Rotation
+	if t.Rotation.Dot(rotation) < 0 {
+		rotation = rotation.Scale(-1.0)
+	}
+	return Transform{
+		Translation: lerp(t.Translation, u.Translation, w),
+		Rotation:    mgl32.QuatNlerp(t.Rotation, rotation, w),
+		Scale:       lerp(t.Scale, u.Scale, w)}
+}
+
+// Sample gets the channel's transform at time t, clamped to the first and
+// last keys. Parts of the channel with no keys come from bind.
+func (c *Channel) Sample(t float32, bind Transform) Transform {
+	out := bind
+	if len(c.Positions) > 0 {
+		out.Translation = sampleVector(c.Positions, t)
+	}
+	if len(c.Rotations) > 0 {
+		out.Rotation = sampleQuat(c.Rotations, t)
+	}
+	if len(c.Scales) > 0 {
+		out.Scale = sampleVector(c.Scales, t)
+	}
+	return out
+}
+
+// segment finds the keys either side of t and how far t is between them
+func segment(n int, time func(int) float32, t float32) (int, int, float32) {
+	if t <= time(0) {
+		return 0, 0, 0.0
+	}
+	if t >= time(n-1) {
+		return n - 1, n - 1, 0.0
+	}
+	i := sort.Search(n, func(i int) bool {
+		return time(i) > t
+	}) - 1
+	span := time(i+1) - time(i)
+	if span <= 0 {
+		return i, i, 0.0
+	}
+	return i, i + 1, (t - time(i)) / span
+}
+
+func sampleVector(keys []VectorKey, t float32) mgl32.Vec3 {
+	i, j, u := segment(len(keys), func(i int) float32 {
+		return keys[i].Time
+	}, t)
+	return lerp(keys[i].Value, keys[j].Value, u)
+}
+
+func sampleQuat(keys []QuatKey, t float32) mgl32.Quat {
+	i, j, u := segment(len(keys), func(i int) float32 {
+		return keys[i].Time
+	}, t)
+	q1, q2 := keys[i].Value, keys[j].Value
+	if q1.Dot(q2) < 0 {
+		q2 = q2.Scale(-1.0)
+	}
+	return mgl32.QuatSlerp(q1, q2, u).Normalize()
+}
+
+func lerp(a, b mgl32.Vec3, w float32) mgl32.Vec3 {
+	return a.Add(b.Sub(a).Mul(w))
+}
@@ -0,0 +1,229 @@
+package animation
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const epsilon = 1e-4
+
+func quatAbout(degrees float32, axis mgl32.Vec3) mgl32.Quat {
+	return mgl32.QuatRotate(mgl32.DegToRad(degrees), axis)
+}
+
+// sameRotation allows for q and -q being the same rotation
+func sameRotation(a, b mgl32.Quat) bool {
+	return a.ApproxEqualThreshold(b, epsilon) ||
+		a.ApproxEqualThreshold(b.Scale(-1.0), epsilon)
+}
+
+func TestChannelSample(t *testing.T) {
+	channel := Channel{
+		Node: "arm",
+		Positions: []VectorKey{
+			{0.0, mgl32.Vec3{0, 0, 0}},
+			{1.0, mgl32.Vec3{2, 0, 0}},
+			{3.0, mgl32.Vec3{2, 4, 0}},
+		},
+		Rotations: []QuatKey{
+			{0.0, mgl32.QuatIdent()},
+			{2.0, quatAbout(90, mgl32.Vec3{0, 1, 0})},
+		},
+	}
+	bind := Transform{Translation: mgl32.Vec3{9, 9, 9},
+		Rotation: quatAbout(30, mgl32.Vec3{1, 0, 0}),
+		Scale:    mgl32.Vec3{2, 2, 2}}
+
+	tests := []struct {
+		name     string
+		t        float32
+		position mgl32.Vec3
+		rotation mgl32.Quat
+	}{
+		{"before the first key", -1.0, mgl32.Vec3{0, 0, 0},
+			mgl32.QuatIdent()},
+		{"at the first key", 0.0, mgl32.Vec3{0, 0, 0}, mgl32.QuatIdent()},
+		{"between keys", 0.5, mgl32.Vec3{1, 0, 0},
+			quatAbout(22.5, mgl32.Vec3{0, 1, 0})},
+		{"at a middle key", 1.0, mgl32.Vec3{2, 0, 0},
+			quatAbout(45, mgl32.Vec3{0, 1, 0})},
+		{"between later keys", 2.0, mgl32.Vec3{2, 2, 0},
+			quatAbout(90, mgl32.Vec3{0, 1, 0})},
+		{"at the last key", 3.0, mgl32.Vec3{2, 4, 0},
+			quatAbout(90, mgl32.Vec3{0, 1, 0})},
+		{"past the last key", 10.0, mgl32.Vec3{2, 4, 0},
+			quatAbout(90, mgl32.Vec3{0, 1, 0})},
+	}
+	for _, test := range tests {
+		got := channel.Sample(test.t, bind)
+		if !got.Translation.ApproxEqualThreshold(test.position, epsilon) {
+			t.Errorf("%s: position %v, want %v", test.name,
+				got.Translation, test.position)
+		}
+		if !sameRotation(got.Rotation, test.rotation) {
+			t.Errorf("%s: rotation %v, want %v", test.name, got.Rotation,
+				test.rotation)
+		}
+		// No scale keys so it comes from the bind pose
+		if got.Scale != bind.Scale {
+			t.Errorf("%s: scale %v, want the bind scale %v", test.name,
+				got.Scale, bind.Scale)
+		}
+	}
+}
+
+func TestSampleShortestRotation(t *testing.T) {
+	// The same rotations with opposite signs shouldn't spin the long way
+	channel := Channel{Rotations: []QuatKey{
+		{0.0, quatAbout(10, mgl32.Vec3{0, 0, 1})},
+		{1.0, quatAbout(30, mgl32.Vec3{0, 0, 1}).Scale(-1.0)},
+	}}
+	got := channel.Sample(0.5, Transform{}).Rotation
+	if want := quatAbout(20, mgl32.Vec3{0, 0, 1}); !sameRotation(got,
+		want) {
+		t.Errorf("rotation %v, want %v", got, want)
+	}
+}
+
+func TestBlend(t *testing.T) {
+	a := Pose{{Translation: mgl32.Vec3{0, 0, 0},
+		Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}}
+	b := Pose{{Translation: mgl32.Vec3{4, -2, 0},
+		Rotation: quatAbout(90, mgl32.Vec3{1, 0, 0}),
+		Scale:    mgl32.Vec3{3, 3, 3}}}
+
+	tests := []struct {
+		w    float32
+		want Transform
+	}{
+		{0.0, a[0]},
+		{0.5, Transform{Translation: mgl32.Vec3{2, -1, 0},
+			Rotation: quatAbout(45, mgl32.Vec3{1, 0, 0}),
+			Scale:    mgl32.Vec3{2, 2, 2}}},
+		{1.0, b[0]},
+	}
+	for _, test := range tests {
+		out := make(Pose, 1)
+		Blend(a, b, test.w, out)
+		got := out[0]
+		if !got.Translation.ApproxEqualThreshold(test.want.Translation,
+			epsilon) || !got.Scale.ApproxEqualThreshold(test.want.Scale,
+			epsilon) || !sameRotation(got.Rotation, test.want.Rotation) {
+			t.Errorf("w %f: blended to %+v, want %+v", test.w, got,
+				test.want)
+		}
+	}
+
+	// Blending into one of the inputs
+	out := append(Pose(nil), a...)
+	Blend(out, b, 1.0, out)
+	if !out[0].Translation.ApproxEqualThreshold(b[0].Translation,
+		epsilon) {
+		t.Errorf("blending in place gave %+v, want %+v", out[0], b[0])
+	}
+}
+
+// chain is a scene root with two bones above it, an upper arm one unit up
+// and a forearm two units above that
+func chain() *Skeleton {
+	return MakeSkeleton([]Joint{
+		{Name: "root", Parent: -1, Transform: mgl32.Ident4(), Bone: -1,
+			Offset: mgl32.Ident4()},
+		{Name: "upper", Parent: 0, Transform: mgl32.Translate3D(0, 1, 0),
+			Bone: 0, Offset: mgl32.Translate3D(0, -1, 0)},
+		{Name: "fore", Parent: 1, Transform: mgl32.Translate3D(0, 2, 0),
+			Bone: 1, Offset: mgl32.Translate3D(0, -3, 0)},
+	})
+}
+
+func skin(palette []mgl32.Mat4, bone int, v mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.TransformCoordinate(v, palette[bone])
+}
+
+func TestPalette(t *testing.T) {
+	s := chain()
+	if s.Bones != 2 {
+		t.Fatalf("skeleton has %d bones, want 2", s.Bones)
+	}
+	palette := make([]mgl32.Mat4, s.Bones)
+
+	// The bind pose leaves every vertex where it is
+	s.Palette(s.BindPose(), palette)
+	for i, m := range palette {
+		if !m.ApproxEqualThreshold(mgl32.Ident4(), epsilon) {
+			t.Errorf("bind pose palette[%d] is %v, want identity", i, m)
+		}
+	}
+
+	// Bend both joints 90 degrees about Z
+	bend := quatAbout(90, mgl32.Vec3{0, 0, 1})
+	pose := s.BindPose()
+	pose[1].Rotation = bend
+	pose[2].Rotation = bend
+	s.Palette(pose, palette)
+
+	tests := []struct {
+		bone     int
+		bind     mgl32.Vec3
+		expected mgl32.Vec3
+	}{
+		// The shoulder doesn't move and the elbow swings round it
+		{0, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 1, 0}},
+		{0, mgl32.Vec3{0, 2, 0}, mgl32.Vec3{-1, 1, 0}},
+		// The elbow follows the upper arm and the hand bends again
+		{1, mgl32.Vec3{0, 3, 0}, mgl32.Vec3{-2, 1, 0}},
+		{1, mgl32.Vec3{0, 4, 0}, mgl32.Vec3{-2, 0, 0}},
+	}
+	for _, test := range tests {
+		got := skin(palette, test.bone, test.bind)
+		if !got.ApproxEqualThreshold(test.expected, epsilon) {
+			t.Errorf("bone %d moved %v to %v, want %v", test.bone, test.bind,
+				got, test.expected)
+		}
+	}
+
+	if allocs := testing.AllocsPerRun(10, func() {
+		s.Palette(pose, palette)
+	}); allocs != 0 {
+		t.Errorf("Palette allocated %.0f times", allocs)
+	}
+}
+
+func TestSkeletonSample(t *testing.T) {
+	s := chain()
+	clip := NewClip("wave", 1.0, []Channel{{Node: "fore",
+		Rotations: []QuatKey{{0.0, mgl32.QuatIdent()},
+			{1.0, quatAbout(90, mgl32.Vec3{0, 0, 1})}}}})
+
+	pose := make(Pose, len(s.Joints))
+	s.Sample(clip, 1.0, pose)
+	bind := s.BindPose()
+	// Joints without a channel stay in the bind pose
+	for _, i := range []int{0, 1} {
+		if pose[i] != bind[i] {
+			t.Errorf("joint %d moved to %+v", i, pose[i])
+		}
+	}
+	palette := make([]mgl32.Mat4, s.Bones)
+	s.Palette(pose, palette)
+	got := skin(palette, 1, mgl32.Vec3{0, 4, 0})
+	if want := (mgl32.Vec3{-1, 3, 0}); !got.ApproxEqualThreshold(want,
+		epsilon) {
+		t.Errorf("hand moved to %v, want %v", got, want)
+	}
+}
+
+func TestMaxBones(t *testing.T) {
+	joints := make([]Joint, MAX_BONES+1)
+	for i := range joints {
+		joints[i] = Joint{Parent: i - 1, Transform: mgl32.Ident4(),
+			Bone: i, Offset: mgl32.Ident4()}
+	}
+	if _, err := NewSkeleton(joints[:MAX_BONES]); err != nil {
+		t.Errorf("%d bones: %v", MAX_BONES, err)
+	}
+	if _, err := NewSkeleton(joints); err == nil {
+		t.Errorf("%d bones didn't error", len(joints))
+	}
+}
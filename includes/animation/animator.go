@@ -0,0 +1,127 @@
+package animation
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
+)
+
+// Animator plays clips on a skeleton. CrossFade blends from the playing
+// clip into another over a number of seconds.
+type Animator struct {
+	Skeleton *Skeleton
+	Clip     *Clip
+	Time     float32
+	Speed    float32
+	Loop     bool
+
+	// Clip being faded to, Weight goes from 0 to 1 over FadeDuration
+	Next         *Clip
+	NextTime     float32
+	Weight       float32
+	FadeDuration float32
+
+	// Palette is updated by Update and Sample
+	Palette []mgl32.Mat4
+
+	pose, nextPose Pose
+}
+
+func NewAnimator(skeleton *Skeleton) *Animator {
+	return &Animator{Skeleton: skeleton, Speed: 1.0, Loop: true,
+		Palette:  identities(skeleton.Bones),
+		pose:     skeleton.BindPose(),
+		nextPose: skeleton.BindPose()}
+}
+
+// Play switches straight to a clip from the start
+func (a *Animator) Play(c *Clip) {
+	a.Clip, a.Time = c, 0.0
+	a.Next = nil
+}
+
+// CrossFade starts c from the beginning and fades it in over duration
+// seconds
+func (a *Animator) CrossFade(c *Clip, duration float32) {
+	if a.Clip == nil || duration <= 0 {
+		a.Play(c)
+		return
+	}
+	a.Next, a.NextTime = c, 0.0
+	a.Weight, a.FadeDuration = 0.0, duration
+}
+
+// Update moves the animation forward by deltaTime seconds and rebuilds the
+// palette
+func (a *Animator) Update(deltaTime float32) {
+	if a.Clip == nil {
+		return
+	}
+	step := deltaTime * a.Speed
+	a.Time = a.advance(a.Clip, a.Time, step)
+
+	if a.Next != nil {
+		a.NextTime = a.advance(a.Next, a.NextTime, step)
+		a.Weight += deltaTime / a.FadeDuration
+		if a.Weight >= 1.0 {
+			a.Clip, a.Time = a.Next, a.NextTime
+			a.Next = nil
+		}
+	}
+
+	if a.Next != nil {
+		a.SampleBlend(a.Clip, a.Time, a.Next, a.NextTime, a.Weight)
+	} else {
+		a.Sample(a.Clip, a.Time)
+	}
+}
+
+// Sample sets the palette to a clip at time t
+func (a *Animator) Sample(c *Clip, t float32) {
+	a.Skeleton.Sample(c, t, a.pose)
+	a.Skeleton.Palette(a.pose, a.Palette)
+}
+
+// SampleBlend sets the palette to clip c1 at t1 mixed with c2 at t2, w of
+// 0 is all c1 and 1 is all c2
+func (a *Animator) SampleBlend(c1 *Clip, t1 float32, c2 *Clip, t2 float32,
+	w float32) {
+
+	a.Skeleton.Sample(c1, t1, a.pose)
+	a.Skeleton.Sample(c2, t2, a.nextPose)
+	Blend(a.pose, a.nextPose, w, a.pose)
+	a.Skeleton.Palette(a.pose, a.Palette)
+}
+
+// Upload sets a mat4 array uniform to the palette
+func (a *Animator) Upload(s shader.Shader, name string) {
+	s.SetMat4Array(name, a.Palette)
+}
+
+func (a *Animator) advance(c *Clip, t, step float32) float32 {
+	t += step
+	if c.Duration <= 0 {
+		return 0.0
+	}
+	if a.Loop {
+		t = float32(math.Mod(float64(t), float64(c.Duration)))
+		if t < 0 {
+			t += c.Duration
+		}
+	} else if t > c.Duration {
+		t = c.Duration
+	} else if t < 0 {
+		t = 0.0
+	}
+	return t
+}
+
+func identities(n int) []mgl32.Mat4 {
+	palette := make([]mgl32.Mat4, n)
+	for i := range palette {
+		palette[i] = mgl32.Ident4()
+	}
+	return palette
+}
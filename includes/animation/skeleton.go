@@ -0,0 +1,116 @@
+package animation
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The most bones a palette can hold, this has to match the size of the
+// bone matrix array in the vertex shader
+const MAX_BONES = 100
+
+// Joint is a node of the skeleton. Bone is the joint's index in the bone
+// matrix palette, or -1 for nodes that only move other joints. Offset takes
+// a vertex from model space into the bone's space in the bind pose.
+type Joint struct {
+	Name      string
+	Parent    int
+	Transform mgl32.Mat4
+	Bone      int
+	Offset    mgl32.Mat4
+}
+
+// Skeleton is a node hierarchy flattened so parents come before their
+// children
+type Skeleton struct {
+	Joints []Joint
+	// Undoes the root node's transform
+	GlobalInverse mgl32.Mat4
+	// Bones is how many entries the palette has
+	Bones int
+
+	bind Pose
+	// Scratch space for Palette so it doesn't allocate every frame
+	globals []mgl32.Mat4
+}
+
+// NewSkeleton errors if the joints use more than MAX_BONES bones, as
+// uploading the palette would write past the end of the shader's array
+func NewSkeleton(joints []Joint) (*Skeleton, error) {
+	s := &Skeleton{Joints: joints, GlobalInverse: mgl32.Ident4()}
+	s.bind = make(Pose, len(joints))
+	for i, joint := range joints {
+		s.bind[i] = Decompose(joint.Transform)
+		if joint.Bone+1 > s.Bones {
+			s.Bones = joint.Bone + 1
+		}
+	}
+	if s.Bones > MAX_BONES {
+		return nil, fmt.Errorf("animation: skeleton has %d bones, "+
+			"at most %d fit in the palette", s.Bones, MAX_BONES)
+	}
+	if len(joints) > 0 {
+		s.GlobalInverse = joints[0].Transform.Inv()
+	}
+	s.globals = make([]mgl32.Mat4, len(joints))
+	return s, nil
+}
+
+// MakeSkeleton is NewSkeleton that panics on errors
+func MakeSkeleton(joints []Joint) *Skeleton {
+	s, err := NewSkeleton(joints)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Pose is a local transform for each joint of a skeleton
+type Pose []Transform
+
+// BindPose gets a copy of the pose the skeleton was modelled in
+func (s *Skeleton) BindPose() Pose {
+	return append(Pose(nil), s.bind...)
+}
+
+// Sample fills pose with the clip at time t. Joints the clip doesn't
+// animate stay in the bind pose.
+func (s *Skeleton) Sample(c *Clip, t float32, pose Pose) {
+	for i, joint := range s.Joints {
+		if channel := c.Channel(joint.Name); channel != nil {
+			pose[i] = channel.Sample(t, s.bind[i])
+		} else {
+			pose[i] = s.bind[i]
+		}
+	}
+}
+
+// Blend mixes two poses into out, w of 0 is all a and 1 is all b. out can
+// be a or b.
+func Blend(a, b Pose, w float32, out Pose) {
+	for i := range out {
+		out[i] = a[i].Lerp(b[i], w)
+	}
+}
+
+// Palette turns a pose into the matrices the vertex shader skins with
+func (s *Skeleton) Palette(pose Pose, palette []mgl32.Mat4) {
+	if len(s.globals) != len(s.Joints) {
+		s.globals = make([]mgl32.Mat4, len(s.Joints))
+	}
+	globals := s.globals
+	for i, joint := range s.Joints {
+		local := pose[i].Mat4()
+		if joint.Parent >= 0 {
+			globals[i] = globals[joint.Parent].Mul4(local)
+		} else {
+			globals[i] = local
+		}
+
+		if joint.Bone >= 0 && joint.Bone < len(palette) {
+			palette[joint.Bone] = s.GlobalInverse.Mul4(globals[i]).Mul4(
+				joint.Offset)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Arcball rotates the view around Target as if dragging a ball that fills
+// the window. Unlike Orbit there is no fixed up so the model can be turned
+// any way.
+type Arcball struct {
+	Target      mgl32.Vec3
+	Distance    float32
+	Orientation mgl32.Quat
+
+	MinDistance float32
+	MaxDistance float32
+	DollySpeed  float32
+	Zoom        float32
+
+	Projection Projection
+
+	dragging        bool
+	dragStart       mgl32.Vec3
+	dragOrientation mgl32.Quat
+}
+
+func NewArcball(target mgl32.Vec3, distance float32) Arcball {
+	return Arcball{Target: target, Distance: distance,
+		Orientation: mgl32.QuatIdent(), MinDistance: 0.1,
+		MaxDistance: 1000.0, DollySpeed: DOLLY_SPEED, Zoom: ZOOM,
+		Projection: DefaultProjection()}
+}
+
+func (a *Arcball) GetViewMatrix() mgl32.Mat4 {
+	return mgl32.Translate3D(0.0, 0.0, -a.Distance).
+		Mul4(a.Orientation.Mat4()).
+		Mul4(mgl32.Translate3D(-a.Target[0], -a.Target[1], -a.Target[2]))
+}
+
+func (a *Arcball) GetProjectionMatrix() mgl32.Mat4 {
+	return a.Projection.Matrix(a.Zoom)
+}
+
+func (a *Arcball) GetFrustum() Frustum {
+	return NewFrustum(a.GetViewMatrix(), a.Projection, a.Zoom)
+}
+
+func (a *Arcball) GetPosition() mgl32.Vec3 {
+	back := a.Orientation.Inverse().Rotate(mgl32.Vec3{0.0, 0.0, a.Distance})
+	return a.Target.Add(back)
+}
+
+// BeginDrag starts a rotation at the cursor position x, y given in pixels
+// of a window width by height
+func (a *Arcball) BeginDrag(x, y, width, height float32) {
+	a.dragging = true
+	a.dragStart = arcballVector(x, y, width, height)
+	a.dragOrientation = a.Orientation
+}
+
+// Drag rotates by the arc from where the drag began to x, y
+func (a *Arcball) Drag(x, y, width, height float32) {
+	if !a.dragging {
+		a.BeginDrag(x, y, width, height)
+		return
+	}
+
+	current := arcballVector(x, y, width, height)
+	axis := a.dragStart.Cross(current)
+	if axis.Len() < 1e-6 {
+		return
+	}
+	dot := float64(mgl32.Clamp(a.dragStart.Dot(current), -1.0, 1.0))
+	rotation := mgl32.QuatRotate(float32(math.Acos(dot)), axis.Normalize())
+
+	a.Orientation = rotation.Mul(a.dragOrientation).Normalize()
+}
+
+func (a *Arcball) EndDrag() {
+	a.dragging = false
+}
+
+// ProcessMouseScroll dollies towards or away from the target
+func (a *Arcball) ProcessMouseScroll(yOffset float32) {
+	a.Distance *= float32(math.Pow(float64(1.0-a.DollySpeed),
+		float64(yOffset)))
+
+	if a.Distance < a.MinDistance {
+		a.Distance = a.MinDistance
+	}
+	if a.Distance > a.MaxDistance {
+		a.Distance = a.MaxDistance
+	}
+}
+
+// arcballVector maps a window position onto the unit sphere, points
+// outside of it land on the sphere's silhouette
+func arcballVector(x, y, width, height float32) mgl32.Vec3 {
+	p := mgl32.Vec3{
+		2.0*x/width - 1.0,
+		1.0 - 2.0*y/height, // Window y goes down
+		0.0}
+
+	lengthSquared := p[0]*p[0] + p[1]*p[1]
+	if lengthSquared <= 1.0 {
+		p[2] = float32(math.Sqrt(float64(1.0 - lengthSquared)))
+		return p
+	}
+	return p.Normalize()
+}
@@ -13,6 +13,10 @@ const FORWARD uint32 = 0
 const BACKWARD uint32 = 1
 const LEFT uint32 = 2
 const RIGHT uint32 = 3
+const UP uint32 = 4
+const DOWN uint32 = 5
+const ROLL_LEFT uint32 = 6
+const ROLL_RIGHT uint32 = 7
 
 // Default camera values
 const YAW float32 = -90.0
@@ -21,6 +25,16 @@ const SPEED float32 = 2.5
 const SENSITIVITY float32 = 0.1
 const ZOOM float32 = 45.0
 
+// Controller is anything that can be used to view a scene. Camera is the
+// FPS style camera from the tutorial, Orbit, Arcball and FreeFly are
+// meant for inspecting models.
+type Controller interface {
+	GetViewMatrix() mgl32.Mat4
+	GetProjectionMatrix() mgl32.Mat4
+	GetPosition() mgl32.Vec3
+	GetFrustum() Frustum
+}
+
 type Camera struct {
 	Position mgl32.Vec3
 	Front    mgl32.Vec3
@@ -36,6 +50,21 @@ type Camera struct {
 	MovementSpeed    float32
 	MouseSensitivity float32
 	Zoom             float32
+
+	// Damping smooths out movement and LookDamping mouse look. Both are
+	// rates per second, higher is snappier and 0 turns smoothing off.
+	// When either is set Update has to be called every frame.
+	Damping     float32
+	LookDamping float32
+
+	Projection Projection
+
+	// Smoothing state
+	velocity      mgl32.Vec3
+	moveInput     mgl32.Vec3
+	hasLookTarget bool
+	targetYaw     float32
+	targetPitch   float32
 }
 
 // Construct camera with vectors
@@ -44,7 +73,7 @@ func NewCameraV(position mgl32.Vec3, up mgl32.Vec3, yaw float32,
 
 	c := Camera{Position: position, WorldUp: up, Yaw: yaw,
 		Pitch: pitch, MovementSpeed: movementSpeed, Zoom: zoom,
-		MouseSensitivity: mouseSen}
+		MouseSensitivity: mouseSen, Projection: DefaultProjection()}
 	c.updateCameraVectors()
 
 	return c
@@ -58,7 +87,8 @@ func NewCamera(posX, posY, posZ, upX, upY, upZ, yaw, pitch,
 
 	c := Camera{Position: position, WorldUp: up, Yaw: yaw,
 		Pitch: pitch, MovementSpeed: movementSpeed, Zoom: zoom,
-		MouseSensitivity: mouseSen, Front: mgl32.Vec3{0.0, 0.0, -1.0}}
+		MouseSensitivity: mouseSen, Front: mgl32.Vec3{0.0, 0.0, -1.0},
+		Projection: DefaultProjection()}
 	c.updateCameraVectors()
 
 	return c
@@ -68,51 +98,139 @@ func (c *Camera) GetViewMatrix() mgl32.Mat4 {
 	return mgl32.LookAtV(c.Position, c.Position.Add(c.Front), c.Up)
 }
 
+// GetProjectionMatrix uses Zoom as the vertical field of view
+func (c *Camera) GetProjectionMatrix() mgl32.Mat4 {
+	return c.Projection.Matrix(c.Zoom)
+}
+
+func (c *Camera) GetFrustum() Frustum {
+	return NewFrustum(c.GetViewMatrix(), c.Projection, c.Zoom)
+}
+
+func (c *Camera) GetPosition() mgl32.Vec3 {
+	return c.Position
+}
+
 func (c *Camera) ProcessKeyboard(direction uint32, deltaTime float32) {
-	velocity := c.MovementSpeed * deltaTime
+	var move mgl32.Vec3
 	if direction == FORWARD {
-		c.Position = c.Position.Add(c.Front.Mul(velocity))
+		move = c.Front
 	}
 	if direction == BACKWARD {
-		c.Position = c.Position.Sub(c.Front.Mul(velocity))
+		move = c.Front.Mul(-1.0)
 	}
 	if direction == LEFT {
-		c.Position = c.Position.Sub(c.Right.Mul(velocity))
+		move = c.Right.Mul(-1.0)
 	}
 	if direction == RIGHT {
-		c.Position = c.Position.Add(c.Right.Mul(velocity))
+		move = c.Right
+	}
+	if direction == UP {
+		move = c.WorldUp
+	}
+	if direction == DOWN {
+		move = c.WorldUp.Mul(-1.0)
 	}
+
+	// Leave it to Update to accelerate towards
+	if c.Damping > 0 {
+		c.moveInput = c.moveInput.Add(move)
+		return
+	}
+
+	velocity := c.MovementSpeed * deltaTime
+	c.Position = c.Position.Add(move.Mul(velocity))
 }
 
 func (c *Camera) ProcessMouseMovement(xOffset, yOffset float32, constrainPitch bool) {
 	xOffset *= c.MouseSensitivity
 	yOffset *= c.MouseSensitivity
 
+	if c.LookDamping > 0 {
+		if !c.hasLookTarget {
+			c.targetYaw, c.targetPitch = c.Yaw, c.Pitch
+			c.hasLookTarget = true
+		}
+		c.targetYaw += xOffset
+		c.targetPitch = clampPitch(c.targetPitch+yOffset, constrainPitch)
+		return
+	}
+
 	c.Yaw += xOffset
-	c.Pitch += yOffset
+	c.Pitch = clampPitch(c.Pitch+yOffset, constrainPitch)
 
+	c.updateCameraVectors()
+}
+
+func clampPitch(pitch float32, constrainPitch bool) float32 {
 	if constrainPitch {
-		if c.Pitch > 89.0 {
-			c.Pitch = 89.0
+		if pitch > 89.0 {
+			pitch = 89.0
 		}
-		if c.Pitch < -89.0 {
-			c.Pitch = -89.0
+		if pitch < -89.0 {
+			pitch = -89.0
 		}
 	}
+	return pitch
+}
 
+// Update eases the camera towards the movement and look input since the
+// last call. It does nothing unless Damping or LookDamping is set.
+func (c *Camera) Update(deltaTime float32) {
+	if c.Damping > 0 {
+		target := mgl32.Vec3{}
+		if c.moveInput.Len() > 0 {
+			target = c.moveInput.Normalize().Mul(c.MovementSpeed)
+		}
+		t := dampFactor(c.Damping, deltaTime)
+		c.velocity = c.velocity.Add(target.Sub(c.velocity).Mul(t))
+		c.Position = c.Position.Add(c.velocity.Mul(deltaTime))
+		c.moveInput = mgl32.Vec3{}
+	}
+
+	if c.LookDamping > 0 && c.hasLookTarget {
+		t := dampFactor(c.LookDamping, deltaTime)
+		c.Yaw += (c.targetYaw - c.Yaw) * t
+		c.Pitch += (c.targetPitch - c.Pitch) * t
+		c.updateCameraVectors()
+	}
+}
+
+// dampFactor is how far to move towards a target this frame for
+// exponential smoothing that doesn't depend on the frame rate
+func dampFactor(rate, deltaTime float32) float32 {
+	return 1.0 - float32(math.Exp(float64(-rate*deltaTime)))
+}
+
+// SetOrientation points the camera the same way as the rotation q, roll is
+// lost since the camera always keeps WorldUp as up
+func (c *Camera) SetOrientation(q mgl32.Quat) {
+	front := q.Rotate(mgl32.Vec3{0.0, 0.0, -1.0})
+	c.Yaw = mgl32.RadToDeg(float32(math.Atan2(float64(front[2]),
+		float64(front[0]))))
+	c.Pitch = mgl32.RadToDeg(float32(math.Asin(float64(
+		mgl32.Clamp(front[1], -1.0, 1.0)))))
+	c.targetYaw, c.targetPitch = c.Yaw, c.Pitch
 	c.updateCameraVectors()
 }
 
 func (c *Camera) ProcessMouseScroll(yOffset float32) {
-	if c.Zoom >= 1.0 && c.Zoom <= 45.0 {
-		c.Zoom -= yOffset
+	c.Zoom = scrollZoom(c.Zoom, yOffset)
+}
+
+// scrollZoom narrows the field of view zoom by yOffset keeping it in
+// [1, 45] degrees
+func scrollZoom(zoom, yOffset float32) float32 {
+	if zoom >= 1.0 && zoom <= 45.0 {
+		zoom -= yOffset
 	}
-	if c.Zoom <= 1.0 {
-		c.Zoom = 1.0
+	if zoom <= 1.0 {
+		zoom = 1.0
 	}
-	if c.Zoom >= 45.0 {
-		c.Zoom = 45.0
+	if zoom >= 45.0 {
+		zoom = 45.0
 	}
+	return zoom
 }
 
 func (c *Camera) updateCameraVectors() {
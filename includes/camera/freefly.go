@@ -0,0 +1,99 @@
+package camera
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Default free fly values
+const ROLL_SPEED float32 = 90.0
+
+// FreeFly is a quaternion camera with no world up, so it can pitch over
+// the top and roll like a spaceship. Turning and moving is always relative
+// to the camera's own axes.
+type FreeFly struct {
+	Position    mgl32.Vec3
+	Orientation mgl32.Quat
+
+	MovementSpeed    float32
+	MouseSensitivity float32
+	// Degrees per second
+	RollSpeed float32
+	Zoom      float32
+
+	Projection Projection
+}
+
+func NewFreeFly(position mgl32.Vec3) FreeFly {
+	return FreeFly{Position: position, Orientation: mgl32.QuatIdent(),
+		MovementSpeed: SPEED, MouseSensitivity: SENSITIVITY,
+		RollSpeed: ROLL_SPEED, Zoom: ZOOM, Projection: DefaultProjection()}
+}
+
+func (f *FreeFly) Front() mgl32.Vec3 {
+	return f.Orientation.Rotate(mgl32.Vec3{0.0, 0.0, -1.0})
+}
+
+func (f *FreeFly) Up() mgl32.Vec3 {
+	return f.Orientation.Rotate(mgl32.Vec3{0.0, 1.0, 0.0})
+}
+
+func (f *FreeFly) Right() mgl32.Vec3 {
+	return f.Orientation.Rotate(mgl32.Vec3{1.0, 0.0, 0.0})
+}
+
+func (f *FreeFly) GetViewMatrix() mgl32.Mat4 {
+	return f.Orientation.Inverse().Mat4().Mul4(mgl32.Translate3D(
+		-f.Position[0], -f.Position[1], -f.Position[2]))
+}
+
+func (f *FreeFly) GetProjectionMatrix() mgl32.Mat4 {
+	return f.Projection.Matrix(f.Zoom)
+}
+
+func (f *FreeFly) GetFrustum() Frustum {
+	return NewFrustum(f.GetViewMatrix(), f.Projection, f.Zoom)
+}
+
+func (f *FreeFly) GetPosition() mgl32.Vec3 {
+	return f.Position
+}
+
+// ProcessKeyboard also takes UP, DOWN, ROLL_LEFT and ROLL_RIGHT
+func (f *FreeFly) ProcessKeyboard(direction uint32, deltaTime float32) {
+	velocity := f.MovementSpeed * deltaTime
+	switch direction {
+	case FORWARD:
+		f.Position = f.Position.Add(f.Front().Mul(velocity))
+	case BACKWARD:
+		f.Position = f.Position.Sub(f.Front().Mul(velocity))
+	case LEFT:
+		f.Position = f.Position.Sub(f.Right().Mul(velocity))
+	case RIGHT:
+		f.Position = f.Position.Add(f.Right().Mul(velocity))
+	case UP:
+		f.Position = f.Position.Add(f.Up().Mul(velocity))
+	case DOWN:
+		f.Position = f.Position.Sub(f.Up().Mul(velocity))
+	case ROLL_LEFT:
+		f.rotate(f.RollSpeed*deltaTime, mgl32.Vec3{0.0, 0.0, 1.0})
+	case ROLL_RIGHT:
+		f.rotate(-f.RollSpeed*deltaTime, mgl32.Vec3{0.0, 0.0, 1.0})
+	}
+}
+
+// ProcessMouseMovement yaws around the camera's up and pitches around its
+// right. There is no pitch limit.
+func (f *FreeFly) ProcessMouseMovement(xOffset, yOffset float32) {
+	f.rotate(-xOffset*f.MouseSensitivity, mgl32.Vec3{0.0, 1.0, 0.0})
+	f.rotate(yOffset*f.MouseSensitivity, mgl32.Vec3{1.0, 0.0, 0.0})
+}
+
+func (f *FreeFly) ProcessMouseScroll(yOffset float32) {
+	f.Zoom = scrollZoom(f.Zoom, yOffset)
+}
+
+// rotate turns by degrees around an axis in camera space
+func (f *FreeFly) rotate(degrees float32, axis mgl32.Vec3) {
+	q := mgl32.QuatRotate(mgl32.DegToRad(degrees), axis)
+	f.Orientation = f.Orientation.Mul(q).Normalize()
+}
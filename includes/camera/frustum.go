@@ -0,0 +1,135 @@
+package camera
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Indices into Frustum.Planes
+const LEFT_PLANE = 0
+const RIGHT_PLANE = 1
+const BOTTOM_PLANE = 2
+const TOP_PLANE = 3
+const NEAR_PLANE = 4
+const FAR_PLANE = 5
+
+// Plane is the set of points p where Normal.Dot(p) + D == 0. Normal is
+// unit length (or zero for a plane at infinity).
+type Plane struct {
+	Normal mgl32.Vec3
+	D      float32
+}
+
+// Distance is the signed distance from the plane, positive on the side the
+// normal faces
+func (p Plane) Distance(point mgl32.Vec3) float32 {
+	return p.Normal.Dot(point) + p.D
+}
+
+// Frustum is the volume a camera can see in world space. The planes face
+// inwards. Corners are the near plane's bottom left, bottom right, top
+// right and top left followed by the same on the far plane.
+type Frustum struct {
+	Planes  [6]Plane
+	Corners [8]mgl32.Vec3
+}
+
+// NewFrustum builds the frustum of a view matrix and projection with a
+// vertical field of view of fovy degrees
+func NewFrustum(view mgl32.Mat4, p Projection, fovy float32) Frustum {
+	var f Frustum
+
+	// Planes from the rows of the view projection matrix (Gribb/Hartmann)
+	m := p.Matrix(fovy).Mul4(view)
+	row := func(i int) mgl32.Vec4 {
+		return mgl32.Vec4{m.At(i, 0), m.At(i, 1), m.At(i, 2), m.At(i, 3)}
+	}
+	r0, r1, r2, r3 := row(0), row(1), row(2), row(3)
+
+	f.Planes[LEFT_PLANE] = newPlane(r3.Add(r0))
+	f.Planes[RIGHT_PLANE] = newPlane(r3.Sub(r0))
+	f.Planes[BOTTOM_PLANE] = newPlane(r3.Add(r1))
+	f.Planes[TOP_PLANE] = newPlane(r3.Sub(r1))
+	if p.ReverseZ {
+		// Depth goes from 1 at the near plane to 0 at the far plane
+		f.Planes[NEAR_PLANE] = newPlane(r3.Sub(r2))
+		f.Planes[FAR_PLANE] = newPlane(r2)
+	} else {
+		f.Planes[NEAR_PLANE] = newPlane(r3.Add(r2))
+		f.Planes[FAR_PLANE] = newPlane(r3.Sub(r2))
+	}
+
+	// Corners by unprojecting the clip space cube, an infinite far plane
+	// has no corners so use Far instead
+	finite := p
+	finite.InfiniteFar = false
+	inverse := finite.Matrix(fovy).Mul4(view).Inv()
+	nearZ, farZ := finite.depthRange()
+	square := [4][2]float32{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+	for i, z := range []float32{nearZ, farZ} {
+		for j, xy := range square {
+			v := inverse.Mul4x1(mgl32.Vec4{xy[0], xy[1], z, 1.0})
+			f.Corners[i*4+j] = v.Vec3().Mul(1.0 / v[3])
+		}
+	}
+
+	return f
+}
+
+func newPlane(v mgl32.Vec4) Plane {
+	p := Plane{Normal: v.Vec3(), D: v[3]}
+	// The far plane of an infinite projection is all D and no normal
+	if length := p.Normal.Len(); length > 1e-6 {
+		p.Normal = p.Normal.Mul(1.0 / length)
+		p.D /= length
+	}
+	return p
+}
+
+func (f Frustum) ContainsPoint(point mgl32.Vec3) bool {
+	for _, p := range f.Planes {
+		if p.Distance(point) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsSphere is conservative, a sphere near a corner but outside of
+// the frustum can still count as intersecting
+func (f Frustum) IntersectsSphere(center mgl32.Vec3, radius float32) bool {
+	for _, p := range f.Planes {
+		if p.Distance(center) < -radius {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsAABB tests an axis aligned box, conservative like
+// IntersectsSphere
+func (f Frustum) IntersectsAABB(min, max mgl32.Vec3) bool {
+	for _, p := range f.Planes {
+		// The corner furthest along the normal
+		var positive mgl32.Vec3
+		for i := 0; i < 3; i++ {
+			if p.Normal[i] >= 0 {
+				positive[i] = max[i]
+			} else {
+				positive[i] = min[i]
+			}
+		}
+		if p.Distance(positive) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Center is the average of the corners, handy for fitting shadow cascades
+func (f Frustum) Center() mgl32.Vec3 {
+	var center mgl32.Vec3
+	for _, c := range f.Corners {
+		center = center.Add(c)
+	}
+	return center.Mul(1.0 / 8.0)
+}
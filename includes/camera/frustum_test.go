@@ -0,0 +1,156 @@
+package camera
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// A 90 degree square frustum looking down -Z, so at a depth of d it spans
+// -d to d across and up
+var testProjections = []struct {
+	name string
+	p    Projection
+}{
+	{"standard", Projection{Aspect: 1.0, Near: 1.0, Far: 10.0}},
+	{"reverse z", Projection{Aspect: 1.0, Near: 1.0, Far: 10.0,
+		ReverseZ: true}},
+	{"infinite far", Projection{Aspect: 1.0, Near: 1.0, Far: 10.0,
+		InfiniteFar: true}},
+	{"reverse z infinite far", Projection{Aspect: 1.0, Near: 1.0,
+		Far: 10.0, ReverseZ: true, InfiniteFar: true}},
+}
+
+// Cameras at eye looking down -Z, the test points are moved by eye
+var testViews = []mgl32.Vec3{
+	{0, 0, 0},
+	{3, -2, 7},
+}
+
+func testFrustums(t *testing.T, f func(name string, fr Frustum,
+	p Projection, eye mgl32.Vec3)) {
+
+	for _, proj := range testProjections {
+		for _, eye := range testViews {
+			view := mgl32.LookAtV(eye, eye.Add(mgl32.Vec3{0, 0, -1}),
+				mgl32.Vec3{0, 1, 0})
+			f(proj.name, NewFrustum(view, proj.p, 90.0), proj.p, eye)
+		}
+	}
+}
+
+func TestFrustumPlanes(t *testing.T) {
+	tests := []struct {
+		plane           int
+		inside, outside mgl32.Vec3
+	}{
+		{LEFT_PLANE, mgl32.Vec3{-4.99, 0, -5}, mgl32.Vec3{-5.01, 0, -5}},
+		{RIGHT_PLANE, mgl32.Vec3{4.99, 0, -5}, mgl32.Vec3{5.01, 0, -5}},
+		{BOTTOM_PLANE, mgl32.Vec3{0, -4.99, -5}, mgl32.Vec3{0, -5.01, -5}},
+		{TOP_PLANE, mgl32.Vec3{0, 4.99, -5}, mgl32.Vec3{0, 5.01, -5}},
+		{NEAR_PLANE, mgl32.Vec3{0, 0, -1.01}, mgl32.Vec3{0, 0, -0.99}},
+		{FAR_PLANE, mgl32.Vec3{0, 0, -9.99}, mgl32.Vec3{0, 0, -10.01}},
+	}
+	testFrustums(t, func(name string, fr Frustum, p Projection,
+		eye mgl32.Vec3) {
+
+		for _, test := range tests {
+			inside, outside := test.inside.Add(eye), test.outside.Add(eye)
+			if !fr.ContainsPoint(inside) {
+				t.Errorf("%s %v: plane %d, %v should be inside", name, eye,
+					test.plane, test.inside)
+			}
+			if d := fr.Planes[test.plane].Distance(inside); d < 0 {
+				t.Errorf("%s %v: plane %d, distance to %v is %f", name, eye,
+					test.plane, test.inside, d)
+			}
+
+			if test.plane == FAR_PLANE && p.InfiniteFar {
+				if !fr.ContainsPoint(outside) ||
+					!fr.ContainsPoint(mgl32.Vec3{0, 0, -1e6}.Add(eye)) {
+					t.Errorf("%s %v: far points should be inside", name,
+						eye)
+				}
+				continue
+			}
+			if fr.ContainsPoint(outside) {
+				t.Errorf("%s %v: plane %d, %v should be outside", name, eye,
+					test.plane, test.outside)
+			}
+			if d := fr.Planes[test.plane].Distance(outside); d >= 0 {
+				t.Errorf("%s %v: plane %d, distance to %v is %f", name, eye,
+					test.plane, test.outside, d)
+			}
+		}
+	})
+}
+
+func TestFrustumBoxes(t *testing.T) {
+	half := mgl32.Vec3{0.1, 0.1, 0.1}
+	tests := []struct {
+		name   string
+		center mgl32.Vec3
+		want   bool
+		// Boxes past the far plane are still in an infinite frustum
+		infinite bool
+	}{
+		{"inside", mgl32.Vec3{0, 0, -5}, true, true},
+		{"across left", mgl32.Vec3{-5.05, 0, -5}, true, true},
+		{"outside left", mgl32.Vec3{-5.3, 0, -5}, false, false},
+		{"across right", mgl32.Vec3{5.05, 0, -5}, true, true},
+		{"outside right", mgl32.Vec3{5.3, 0, -5}, false, false},
+		{"across bottom", mgl32.Vec3{0, -5.05, -5}, true, true},
+		{"outside bottom", mgl32.Vec3{0, -5.3, -5}, false, false},
+		{"across top", mgl32.Vec3{0, 5.05, -5}, true, true},
+		{"outside top", mgl32.Vec3{0, 5.3, -5}, false, false},
+		{"across near", mgl32.Vec3{0, 0, -0.95}, true, true},
+		{"outside near", mgl32.Vec3{0, 0, -0.8}, false, false},
+		{"behind", mgl32.Vec3{0, 0, 5}, false, false},
+		{"across far", mgl32.Vec3{0, 0, -9.95}, true, true},
+		{"outside far", mgl32.Vec3{0, 0, -10.2}, false, true},
+		{"across corner", mgl32.Vec3{-5, -5, -5}, true, true},
+		{"outside corner", mgl32.Vec3{-5.3, 5.3, -5}, false, false},
+	}
+	testFrustums(t, func(name string, fr Frustum, p Projection,
+		eye mgl32.Vec3) {
+
+		for _, test := range tests {
+			want := test.want
+			if p.InfiniteFar {
+				want = test.infinite
+			}
+			center := test.center.Add(eye)
+			if got := fr.IntersectsAABB(center.Sub(half),
+				center.Add(half)); got != want {
+				t.Errorf("%s %v: box %s intersects is %t", name, eye,
+					test.name, got)
+			}
+			if got := fr.IntersectsSphere(center, 0.1); got != want {
+				t.Errorf("%s %v: sphere %s intersects is %t", name, eye,
+					test.name, got)
+			}
+		}
+	})
+}
+
+func TestFrustumCorners(t *testing.T) {
+	want := [8]mgl32.Vec3{
+		{-1, -1, -1}, {1, -1, -1}, {1, 1, -1}, {-1, 1, -1},
+		{-10, -10, -10}, {10, -10, -10}, {10, 10, -10}, {-10, 10, -10},
+	}
+	testFrustums(t, func(name string, fr Frustum, p Projection,
+		eye mgl32.Vec3) {
+
+		for i, c := range fr.Corners {
+			if !c.ApproxEqualThreshold(want[i].Add(eye), 1e-3) {
+				t.Errorf("%s %v: corner %d is %v, want %v", name, eye, i,
+					c, want[i].Add(eye))
+			}
+		}
+		center := mgl32.Vec3{0, 0, -5.5}.Add(eye)
+		if !fr.Center().ApproxEqualThreshold(center, 1e-3) {
+			t.Errorf("%s %v: center is %v, want %v", name, eye,
+				fr.Center(), center)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Default orbit values
+const ORBIT_SENSITIVITY float32 = 0.25
+const PAN_SPEED float32 = 0.001
+const DOLLY_SPEED float32 = 0.1
+
+// Orbit is a turntable camera that circles around Target, always keeping
+// WorldUp as up. Yaw and Pitch are in degrees.
+type Orbit struct {
+	Target   mgl32.Vec3
+	Distance float32
+	WorldUp  mgl32.Vec3
+
+	Yaw   float32
+	Pitch float32
+
+	// Distance limits for dollying in and out
+	MinDistance float32
+	MaxDistance float32
+
+	MouseSensitivity float32
+	PanSpeed         float32
+	DollySpeed       float32
+	Zoom             float32
+
+	Projection Projection
+}
+
+func NewOrbit(target mgl32.Vec3, distance, yaw, pitch float32) Orbit {
+	return Orbit{Target: target, Distance: distance,
+		WorldUp: mgl32.Vec3{0.0, 1.0, 0.0}, Yaw: yaw, Pitch: pitch,
+		MinDistance: 0.1, MaxDistance: 1000.0,
+		MouseSensitivity: ORBIT_SENSITIVITY, PanSpeed: PAN_SPEED,
+		DollySpeed: DOLLY_SPEED, Zoom: ZOOM,
+		Projection: DefaultProjection()}
+}
+
+func (o *Orbit) GetPosition() mgl32.Vec3 {
+	yaw := float64(mgl32.DegToRad(o.Yaw))
+	pitch := float64(mgl32.DegToRad(o.Pitch))
+
+	offset := mgl32.Vec3{
+		float32(math.Cos(yaw) * math.Cos(pitch)),
+		float32(math.Sin(pitch)),
+		float32(math.Sin(yaw) * math.Cos(pitch))}
+
+	return o.Target.Add(offset.Mul(o.Distance))
+}
+
+func (o *Orbit) GetViewMatrix() mgl32.Mat4 {
+	return mgl32.LookAtV(o.GetPosition(), o.Target, o.WorldUp)
+}
+
+func (o *Orbit) GetProjectionMatrix() mgl32.Mat4 {
+	return o.Projection.Matrix(o.Zoom)
+}
+
+func (o *Orbit) GetFrustum() Frustum {
+	return NewFrustum(o.GetViewMatrix(), o.Projection, o.Zoom)
+}
+
+// ProcessMouseMovement spins the camera around the target
+func (o *Orbit) ProcessMouseMovement(xOffset, yOffset float32) {
+	o.Yaw += xOffset * o.MouseSensitivity
+	o.Pitch -= yOffset * o.MouseSensitivity
+
+	// Going over the poles would flip the view
+	if o.Pitch > 89.0 {
+		o.Pitch = 89.0
+	}
+	if o.Pitch < -89.0 {
+		o.Pitch = -89.0
+	}
+}
+
+// Pan moves the target (and so the camera) across the view plane. Panning
+// is scaled by distance so the target follows the mouse.
+func (o *Orbit) Pan(xOffset, yOffset float32) {
+	front := o.Target.Sub(o.GetPosition()).Normalize()
+	right := front.Cross(o.WorldUp).Normalize()
+	up := right.Cross(front).Normalize()
+
+	scale := o.PanSpeed * o.Distance
+	o.Target = o.Target.Sub(right.Mul(xOffset * scale))
+	o.Target = o.Target.Sub(up.Mul(yOffset * scale))
+}
+
+// ProcessMouseScroll dollies towards or away from the target
+func (o *Orbit) ProcessMouseScroll(yOffset float32) {
+	o.Distance *= float32(math.Pow(float64(1.0-o.DollySpeed),
+		float64(yOffset)))
+
+	if o.Distance < o.MinDistance {
+		o.Distance = o.MinDistance
+	}
+	if o.Distance > o.MaxDistance {
+		o.Distance = o.MaxDistance
+	}
+}
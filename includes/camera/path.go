@@ -0,0 +1,179 @@
+package camera
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Keyframe is where the camera is and which way it faces Time seconds into
+// a path. Orientation rotates the camera's -Z forward into world space.
+type Keyframe struct {
+	Time        float32    `json:"time"`
+	Position    mgl32.Vec3 `json:"position"`
+	Orientation mgl32.Quat `json:"orientation"`
+}
+
+// NewKeyframe captures the current view of any controller
+func NewKeyframe(time float32, c Controller) Keyframe {
+	// The view matrix rotates world into camera space, so invert it
+	orientation := mgl32.Mat4ToQuat(c.GetViewMatrix()).Inverse().Normalize()
+	return Keyframe{Time: time, Position: c.GetPosition(),
+		Orientation: orientation}
+}
+
+// Path is a camera fly through. Positions follow a Catmull-Rom spline
+// through the keyframes and orientations are slerped between them.
+type Path struct {
+	Keyframes []Keyframe `json:"keyframes"`
+	// Loop wraps time around. For a seamless loop the last keyframe should
+	// be the same as the first.
+	Loop bool `json:"loop"`
+}
+
+// Add inserts a keyframe keeping the path in time order
+func (p *Path) Add(k Keyframe) {
+	i := sort.Search(len(p.Keyframes), func(i int) bool {
+		return p.Keyframes[i].Time > k.Time
+	})
+	p.Keyframes = append(p.Keyframes, Keyframe{})
+	copy(p.Keyframes[i+1:], p.Keyframes[i:])
+	p.Keyframes[i] = k
+}
+
+// Duration is the time of the last keyframe
+func (p *Path) Duration() float32 {
+	if len(p.Keyframes) == 0 {
+		return 0
+	}
+	return p.Keyframes[len(p.Keyframes)-1].Time
+}
+
+// Sample gives the position and orientation at time t. Times outside of
+// the path are clamped to its ends, or wrapped when looping.
+func (p *Path) Sample(t float32) (mgl32.Vec3, mgl32.Quat) {
+	n := len(p.Keyframes)
+	if n == 0 {
+		return mgl32.Vec3{}, mgl32.QuatIdent()
+	}
+	first, last := p.Keyframes[0], p.Keyframes[n-1]
+	if n == 1 {
+		return first.Position, first.Orientation
+	}
+
+	if p.Loop && last.Time > first.Time {
+		span := last.Time - first.Time
+		t = first.Time + mod(t-first.Time, span)
+	}
+	if t <= first.Time {
+		return first.Position, first.Orientation
+	}
+	if t >= last.Time {
+		return last.Position, last.Orientation
+	}
+
+	// Segment from keyframe i to i+1 containing t
+	i := sort.Search(n, func(i int) bool {
+		return p.Keyframes[i].Time > t
+	}) - 1
+	k1, k2 := p.Keyframes[i], p.Keyframes[i+1]
+	u := float32(0.0)
+	if k2.Time > k1.Time {
+		u = (t - k1.Time) / (k2.Time - k1.Time)
+	}
+
+	p0 := p.neighbour(i - 1).Position
+	p3 := p.neighbour(i + 2).Position
+	position := catmullRom(p0, k1.Position, k2.Position, p3, u)
+
+	// Take the short way around
+	q2 := k2.Orientation
+	if k1.Orientation.Dot(q2) < 0 {
+		q2 = q2.Scale(-1.0)
+	}
+	orientation := mgl32.QuatSlerp(k1.Orientation, q2, u).Normalize()
+
+	return position, orientation
+}
+
+// ViewMatrix is the view matrix at time t
+func (p *Path) ViewMatrix(t float32) mgl32.Mat4 {
+	position, orientation := p.Sample(t)
+	return orientation.Inverse().Mat4().Mul4(mgl32.Translate3D(
+		-position[0], -position[1], -position[2]))
+}
+
+// neighbour gets the keyframe at i for the ends of the spline, clamping or
+// wrapping past the ends of the path
+func (p *Path) neighbour(i int) Keyframe {
+	n := len(p.Keyframes)
+	if p.Loop {
+		// The last keyframe is the same place as the first when looping
+		return p.Keyframes[((i%(n-1))+(n-1))%(n-1)]
+	}
+	if i < 0 {
+		return p.Keyframes[0]
+	}
+	if i >= n {
+		return p.Keyframes[n-1]
+	}
+	return p.Keyframes[i]
+}
+
+func catmullRom(p0, p1, p2, p3 mgl32.Vec3, t float32) mgl32.Vec3 {
+	t2 := t * t
+	t3 := t2 * t
+
+	a := p1.Mul(2.0)
+	b := p2.Sub(p0).Mul(t)
+	c := p0.Mul(2.0).Sub(p1.Mul(5.0)).Add(p2.Mul(4.0)).Sub(p3).Mul(t2)
+	d := p1.Mul(3.0).Sub(p0).Sub(p2.Mul(3.0)).Add(p3).Mul(t3)
+
+	return a.Add(b).Add(c).Add(d).Mul(0.5)
+}
+
+func mod(a, b float32) float32 {
+	r := a - b*float32(int(a/b))
+	if r < 0 {
+		r += b
+	}
+	return r
+}
+
+// Apply moves a camera to where the path is at time t
+func (p *Path) Apply(t float32, c *Camera) {
+	position, orientation := p.Sample(t)
+	c.Position = position
+	c.SetOrientation(orientation)
+}
+
+// ApplyFreeFly is Apply for a FreeFly camera, which keeps the roll
+func (p *Path) ApplyFreeFly(t float32, f *FreeFly) {
+	f.Position, f.Orientation = p.Sample(t)
+}
+
+func LoadPath(path string) (*Path, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Path
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(p.Keyframes, func(i, j int) bool {
+		return p.Keyframes[i].Time < p.Keyframes[j].Time
+	})
+	return &p, nil
+}
+
+func (p *Path) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,102 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Default projection values, the same as the chapters use
+const ASPECT float32 = 800.0 / 600.0
+const NEAR float32 = 0.1
+const FAR float32 = 100.0
+
+// Projection is the lens of a camera. The field of view comes from the
+// camera's Zoom so scrolling keeps working.
+type Projection struct {
+	Aspect float32
+	Near   float32
+	Far    float32
+
+	// Orthographic projections show OrthoHeight world units from the
+	// bottom to the top of the view and ignore the field of view
+	Orthographic bool
+	OrthoHeight  float32
+
+	// ReverseZ maps the near plane to a depth of 1 and the far plane to 0.
+	// It is meant for a [0, 1] clip space depth, so set
+	// gl.ClipControl(gl.LOWER_LEFT, gl.ZERO_TO_ONE) (OpenGL 4.5), clear
+	// depth to 0 and use gl.DepthFunc(gl.GREATER).
+	ReverseZ bool
+
+	// InfiniteFar ignores Far and puts the far plane at infinity. Far is
+	// still used for the corners of the frustum.
+	InfiniteFar bool
+}
+
+func DefaultProjection() Projection {
+	return Projection{Aspect: ASPECT, Near: NEAR, Far: FAR, OrthoHeight: 10.0}
+}
+
+// SetAspect sets the aspect ratio from a framebuffer size, ignoring zero
+// sizes from minimised windows
+func (p *Projection) SetAspect(width, height int) {
+	if width > 0 && height > 0 {
+		p.Aspect = float32(width) / float32(height)
+	}
+}
+
+// Matrix gives the projection matrix. For perspective projections fovy is
+// the vertical field of view in degrees.
+func (p Projection) Matrix(fovy float32) mgl32.Mat4 {
+	if p.Orthographic {
+		return p.ortho()
+	}
+	return p.perspective(fovy)
+}
+
+func (p Projection) perspective(fovy float32) mgl32.Mat4 {
+	if !p.ReverseZ && !p.InfiniteFar {
+		return mgl32.Perspective(mgl32.DegToRad(fovy), p.Aspect, p.Near, p.Far)
+	}
+
+	// Column major, m[col*4+row]
+	f := float32(1.0 / math.Tan(float64(mgl32.DegToRad(fovy))/2.0))
+	n := p.Near
+	m := mgl32.Mat4{}
+	m[0] = f / p.Aspect
+	m[5] = f
+	m[11] = -1.0
+
+	// Clip z = A * eye z + B
+	switch {
+	case p.ReverseZ && p.InfiniteFar:
+		m[10], m[14] = 0.0, n
+	case p.ReverseZ:
+		m[10], m[14] = n/(p.Far-n), n*p.Far/(p.Far-n)
+	default: // Infinite far with a [-1, 1] depth
+		m[10], m[14] = -1.0, -2.0*n
+	}
+	return m
+}
+
+func (p Projection) ortho() mgl32.Mat4 {
+	top := p.OrthoHeight / 2.0
+	right := top * p.Aspect
+	if !p.ReverseZ {
+		return mgl32.Ortho(-right, right, -top, top, p.Near, p.Far)
+	}
+
+	m := mgl32.Ortho(-right, right, -top, top, p.Near, p.Far)
+	m[10] = 1.0 / (p.Far - p.Near)
+	m[14] = p.Far / (p.Far - p.Near)
+	return m
+}
+
+// depthRange is the clip space depth at the near and far planes
+func (p Projection) depthRange() (float32, float32) {
+	if p.ReverseZ {
+		return 1.0, 0.0
+	}
+	return -1.0, 1.0
+}
@@ -0,0 +1,85 @@
+package cubemap
+
+import (
+	"fmt"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/texture"
+)
+
+// Where each face sits in a cross, in face sized cells, in FACES order
+var horizontalCross = [6][2]int{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}}
+var verticalCross = [6][2]int{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {1, 3}}
+
+// LoadCross makes a cubemap from a single image laid out as a cross,
+// either 4 faces wide and 3 high
+//
+//	   +Y
+//	-X +Z +X -Z
+//	   -Y
+//
+// or 3 wide and 4 high with -Z upside down below -Y.
+func LoadCross(path string, opts texture.Options) (Cubemap, error) {
+	img, err := texture.DecodeFile(path)
+	if err != nil {
+		return Cubemap{}, fmt.Errorf("cubemap: %s: %v", path, err)
+	}
+
+	faces, err := crossFaces(img)
+	if err != nil {
+		return Cubemap{}, fmt.Errorf("cubemap: %s: %v", path, err)
+	}
+
+	c, err := fromImages(faces, opts)
+	if err != nil {
+		return Cubemap{}, fmt.Errorf("cubemap: %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// crossFaces cuts the faces out of a cross in FACES order
+func crossFaces(img *texture.Image) ([6]*texture.Image, error) {
+	var faces [6]*texture.Image
+	var cells [6][2]int
+	var size int
+	switch {
+	case img.Width*3 == img.Height*4:
+		cells, size = horizontalCross, img.Width/4
+	case img.Width*4 == img.Height*3:
+		cells, size = verticalCross, img.Width/3
+	default:
+		return faces, fmt.Errorf("%dx%d isn't a 4x3 or 3x4 cross",
+			img.Width, img.Height)
+	}
+
+	for i, cell := range cells {
+		faces[i] = crop(img, cell[0]*size, cell[1]*size, size)
+	}
+	if size*3 == img.Width {
+		rotate180(faces[5])
+	}
+	return faces, nil
+}
+
+// crop copies out a square of an image
+func crop(img *texture.Image, x, y, size int) *texture.Image {
+	pixelSize := len(img.Pix) / (img.Width * img.Height)
+	face := &texture.Image{Width: size, Height: size, Channels: img.Channels,
+		Type: img.Type, Pix: make([]byte, size*size*pixelSize)}
+	for row := 0; row < size; row++ {
+		start := ((y+row)*img.Width + x) * pixelSize
+		copy(face.Pix[row*size*pixelSize:],
+			img.Pix[start:start+size*pixelSize])
+	}
+	return face
+}
+
+func rotate180(img *texture.Image) {
+	pixelSize := len(img.Pix) / (img.Width * img.Height)
+	for i, j := 0, img.Width*img.Height-1; i < j; i, j = i+1, j-1 {
+		a := img.Pix[i*pixelSize : (i+1)*pixelSize]
+		b := img.Pix[j*pixelSize : (j+1)*pixelSize]
+		for k := range a {
+			a[k], b[k] = b[k], a[k]
+		}
+	}
+}
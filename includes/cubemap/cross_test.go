@@ -0,0 +1,72 @@
+package cubemap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/texture"
+)
+
+// testCross is a cross of 2x2 cells with 2 channels, each pixel is its
+// cell's number times 4 plus where it is in the cell then that plus 100
+func testCross(columns, rows int) *texture.Image {
+	img := &texture.Image{Width: columns * 2, Height: rows * 2, Channels: 2,
+		Type: gl.UNSIGNED_BYTE, Pix: make([]byte, columns*rows*4*2)}
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			cell := y/2*columns + x/2
+			v := byte(cell*4 + y%2*2 + x%2)
+			p := (y*img.Width + x) * 2
+			img.Pix[p], img.Pix[p+1] = v, v+100
+		}
+	}
+	return img
+}
+
+func TestCrossFaces(t *testing.T) {
+	tests := []struct {
+		name          string
+		columns, rows int
+		// The cell numbers of each face in FACES order
+		cells   [6]int
+		rotated bool
+	}{
+		{"horizontal", 4, 3, [6]int{6, 4, 1, 9, 5, 7}, false},
+		// -Z is upside down at the bottom
+		{"vertical", 3, 4, [6]int{5, 3, 1, 7, 4, 10}, true},
+	}
+	for _, test := range tests {
+		faces, err := crossFaces(testCross(test.columns, test.rows))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		for i, face := range faces {
+			if face.Width != 2 || face.Height != 2 || face.Channels != 2 ||
+				face.Type != gl.UNSIGNED_BYTE {
+				t.Errorf("%s: %s face is %dx%d with %d channels", test.name,
+					FACES[i], face.Width, face.Height, face.Channels)
+				continue
+			}
+			for k := 0; k < 4; k++ {
+				at := k
+				if i == 5 && test.rotated {
+					at = 3 - k
+				}
+				want := byte(test.cells[i]*4 + at)
+				got := face.Pix[k*2 : k*2+2]
+				if got[0] != want || got[1] != want+100 {
+					t.Errorf("%s: %s face pixel %d is %v, want %d and %d",
+						test.name, FACES[i], k, got, want, want+100)
+				}
+			}
+		}
+	}
+
+	_, err := crossFaces(testCross(4, 4))
+	if err == nil || !strings.Contains(err.Error(), "isn't a 4x3 or 3x4") {
+		t.Errorf("a square image gave %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package cubemap loads cubemaps from six faces, a cross image or an
+// equirectangular map and saves them back to disk
+package cubemap
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/texture"
+)
+
+// FACES are the names of the faces in GL's order, +X, -X, +Y, -Y, +Z and
+// -Z, as the skybox files are called
+var FACES = []string{"right", "left", "top", "bottom", "front", "back"}
+
+type Cubemap struct {
+	ID             uint32
+	Size           int
+	InternalFormat int32
+}
+
+// LoadFaces makes a cubemap from six images in FACES order. Unlike 2D
+// textures the faces aren't flipped and wrap modes default to
+// CLAMP_TO_EDGE. Flip in opts is ignored.
+func LoadFaces(paths []string, opts texture.Options) (Cubemap, error) {
+	if len(paths) != 6 {
+		return Cubemap{}, fmt.Errorf("cubemap: need 6 faces, got %d",
+			len(paths))
+	}
+
+	var faces [6]*texture.Image
+	for i, path := range paths {
+		img, err := texture.DecodeFile(path)
+		if err != nil {
+			return Cubemap{}, fmt.Errorf("cubemap: %s: %v", path, err)
+		}
+		faces[i] = img
+	}
+	c, err := fromImages(faces, opts)
+	if err != nil {
+		return Cubemap{}, fmt.Errorf("cubemap: %s: %v", paths[0], err)
+	}
+	return c, nil
+}
+
+// MakeFaces is LoadFaces that panics on errors
+func MakeFaces(paths []string, opts texture.Options) Cubemap {
+	c, err := LoadFaces(paths, opts)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// LoadDir loads the six faces in a directory, named after FACES with any
+// image extension, like resources/textures/skybox
+func LoadDir(dir string, opts texture.Options) (Cubemap, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return Cubemap{}, fmt.Errorf("cubemap: %v", err)
+	}
+
+	paths := make([]string, 6)
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		for i, face := range FACES {
+			if strings.EqualFold(name, face) {
+				paths[i] = filepath.Join(dir, f.Name())
+			}
+		}
+	}
+	for i, path := range paths {
+		if path == "" {
+			return Cubemap{}, fmt.Errorf("cubemap: %s has no %s face", dir,
+				FACES[i])
+		}
+	}
+	return LoadFaces(paths, opts)
+}
+
+// MakeDir is LoadDir that panics on errors
+func MakeDir(dir string, opts texture.Options) Cubemap {
+	c, err := LoadDir(dir, opts)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Empty makes a cubemap with no data to render into, faces of size by
+// size with mipmap storage if opts wants mipmaps
+func Empty(size int, internalFormat int32, opts texture.Options) Cubemap {
+	c := Cubemap{Size: size, InternalFormat: internalFormat}
+	gl.GenTextures(1, &c.ID)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, c.ID)
+	for i := uint32(0); i < 6; i++ {
+		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i, 0, internalFormat,
+			int32(size), int32(size), 0, gl.RGBA, gl.FLOAT, nil)
+	}
+	setParameters(opts)
+	if !opts.NoMipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	return c
+}
+
+func fromImages(faces [6]*texture.Image, opts texture.Options) (Cubemap,
+	error) {
+
+	// The faces are already the way round GL wants them
+	opts.Flip = false
+
+	size := faces[0].Width
+	for i, img := range faces {
+		if img.Width != size || img.Height != size {
+			return Cubemap{}, fmt.Errorf("%s face is %dx%d, not %dx%d",
+				FACES[i], img.Width, img.Height, size, size)
+		}
+		if img.Channels != faces[0].Channels || img.Type != faces[0].Type {
+			return Cubemap{}, fmt.Errorf("%s face is in a different format",
+				FACES[i])
+		}
+	}
+
+	c := Cubemap{Size: size}
+	gl.GenTextures(1, &c.ID)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, c.ID)
+	for i, img := range faces {
+		internalFormat, err := texture.Prepare(img, opts)
+		if err != nil {
+			c.Delete()
+			return Cubemap{}, err
+		}
+		c.InternalFormat = internalFormat
+		texture.TexImage(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), img,
+			internalFormat)
+	}
+	texture.Swizzle(gl.TEXTURE_CUBE_MAP, faces[0].Channels)
+
+	setParameters(opts)
+	if !opts.NoMipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	return c, nil
+}
+
+// setParameters sets the bound cubemap's parameters from opts
+func setParameters(opts texture.Options) {
+	wrapS := orDefault(opts.WrapS, gl.CLAMP_TO_EDGE)
+	minFilter := orDefault(opts.MinFilter, gl.LINEAR_MIPMAP_LINEAR)
+	if opts.NoMipmaps && opts.MinFilter == 0 {
+		minFilter = gl.LINEAR
+	}
+	texture.SetParameters(gl.TEXTURE_CUBE_MAP, wrapS,
+		orDefault(opts.WrapT, gl.CLAMP_TO_EDGE), minFilter,
+		orDefault(opts.MagFilter, gl.LINEAR), opts.Anisotropy)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, wrapS)
+}
+
+// Bind binds the cubemap to a texture unit
+func (c Cubemap) Bind(unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, c.ID)
+}
+
+func (c *Cubemap) Delete() {
+	gl.DeleteTextures(1, &c.ID)
+	c.ID = 0
+}
+
+// CaptureProjection and CaptureViews look out of the centre of a cube at
+// each face in turn, for rendering into a cubemap a face at a time
+func CaptureProjection() mgl32.Mat4 {
+	return mgl32.Perspective(mgl32.DegToRad(90.0), 1.0, 0.1, 10.0)
+}
+
+func CaptureViews() [6]mgl32.Mat4 {
+	return [6]mgl32.Mat4{
+		mgl32.LookAt(0.0, 0.0, 0.0, +1.0, +0.0, +0.0, +0.0, -1.0, +0.0),
+		mgl32.LookAt(0.0, 0.0, 0.0, -1.0, +0.0, +0.0, +0.0, -1.0, +0.0),
+		mgl32.LookAt(0.0, 0.0, 0.0, +0.0, +1.0, +0.0, +0.0, +0.0, +1.0),
+		mgl32.LookAt(0.0, 0.0, 0.0, +0.0, -1.0, +0.0, +0.0, +0.0, -1.0),
+		mgl32.LookAt(0.0, 0.0, 0.0, +0.0, +0.0, +1.0, +0.0, -1.0, +0.0),
+		mgl32.LookAt(0.0, 0.0, 0.0, +0.0, +0.0, -1.0, +0.0, -1.0, +0.0),
+	}
+}
+
+func orDefault(value, fallback int32) int32 {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
+
+// isFloat is true for the float colour formats
+func isFloat(internalFormat int32) bool {
+	switch internalFormat {
+	case gl.R16F, gl.RG16F, gl.RGB16F, gl.RGBA16F, gl.R32F, gl.RG32F,
+		gl.RGB32F, gl.RGBA32F, gl.R11F_G11F_B10F, gl.RGB9_E5:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package cubemap
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/texture"
+)
+
+const captureVertex = `#version 410 core
+layout (location = 0) in vec3 aPos;
+
+out vec3 WorldPos;
+
+uniform mat4 projection;
+uniform mat4 view;
+
+void main()
+{
+    WorldPos = aPos;
+    gl_Position =  projection * view * vec4(WorldPos, 1.0);
+}
+`
+
+const equirectangularFragment = `#version 410 core
+out vec4 FragColor;
+in vec3 WorldPos;
+
+uniform sampler2D equirectangularMap;
+
+const vec2 invAtan = vec2(0.1591, 0.3183);
+vec2 SampleSphericalMap(vec3 v)
+{
+    vec2 uv = vec2(atan(v.z, v.x), asin(v.y));
+    uv *= invAtan;
+    uv += 0.5;
+    return uv;
+}
+
+void main()
+{
+    vec2 uv = SampleSphericalMap(normalize(WorldPos));
+    vec3 color = texture(equirectangularMap, uv).rgb;
+
+    FragColor = vec4(color, 1.0);
+}
+`
+
+// LoadEquirectangular loads an equirectangular map, usually an .hdr like
+// newport_loft.hdr, and renders it into a cubemap with faces of size. It's
+// RGB16F unless opts sets an internal format.
+func LoadEquirectangular(path string, size int,
+	opts texture.Options) (Cubemap, error) {
+
+	// The map is sampled once so it needs no mipmaps, flipped like stb
+	// flips it for the IBL chapters
+	equirectangular, err := texture.Load(path, texture.Options{Flip: true,
+		NoMipmaps: true, WrapS: gl.CLAMP_TO_EDGE, WrapT: gl.CLAMP_TO_EDGE})
+	if err != nil {
+		return Cubemap{}, fmt.Errorf("cubemap: %v", err)
+	}
+	defer gl.DeleteTextures(1, &equirectangular.ID)
+
+	return FromEquirectangular(equirectangular.ID, size, opts)
+}
+
+// MakeEquirectangular is LoadEquirectangular that panics on errors
+func MakeEquirectangular(path string, size int,
+	opts texture.Options) Cubemap {
+
+	c, err := LoadEquirectangular(path, size, opts)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// FromEquirectangular renders an equirectangular 2D texture into a new
+// cubemap. The framebuffer, viewport and face culling are put back
+// afterwards.
+func FromEquirectangular(equirectangular uint32, size int,
+	opts texture.Options) (Cubemap, error) {
+
+	s, err := shader.NewShaderFromSource(captureVertex,
+		equirectangularFragment)
+	if err != nil {
+		return Cubemap{}, fmt.Errorf("cubemap: %v", err)
+	}
+	defer gl.DeleteProgram(s.ID)
+
+	internalFormat := opts.InternalFormat
+	if internalFormat == 0 {
+		internalFormat = gl.RGB16F
+	}
+	c := Empty(size, internalFormat, opts)
+
+	var previousFBO int32
+	var viewport [4]int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &previousFBO)
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	defer func() {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(previousFBO))
+		gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+	}()
+	// The cube is seen from inside
+	if gl.IsEnabled(gl.CULL_FACE) {
+		gl.Disable(gl.CULL_FACE)
+		defer gl.Enable(gl.CULL_FACE)
+	}
+
+	var captureFBO uint32
+	gl.GenFramebuffers(1, &captureFBO)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, captureFBO)
+	defer gl.DeleteFramebuffers(1, &captureFBO)
+
+	s.Use()
+	s.SetInt("equirectangularMap", 0)
+	s.SetMat4("projection", CaptureProjection())
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, equirectangular)
+
+	vao, vbo := cube()
+	defer func() {
+		gl.BindVertexArray(0)
+		gl.DeleteVertexArrays(1, &vao)
+		gl.DeleteBuffers(1, &vbo)
+	}()
+	gl.Viewport(0, 0, int32(size), int32(size))
+	for i, view := range CaptureViews() {
+		s.SetMat4("view", view)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
+			gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), c.ID, 0)
+		if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status !=
+			gl.FRAMEBUFFER_COMPLETE {
+
+			c.Delete()
+			return Cubemap{}, fmt.Errorf("cubemap: can't render to the "+
+				"cubemap, framebuffer status 0x%x", status)
+		}
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		gl.DrawArrays(gl.TRIANGLES, 0, 36)
+	}
+
+	if !opts.NoMipmaps {
+		gl.BindTexture(gl.TEXTURE_CUBE_MAP, c.ID)
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	return c, nil
+}
+
+// cube makes and binds a unit cube of positions at location 0
+func cube() (uint32, uint32) {
+	vertices := []float32{
+		// back
+		-1, -1, -1, 1, 1, -1, 1, -1, -1, 1, 1, -1, -1, -1, -1, -1, 1, -1,
+		// front
+		-1, -1, 1, 1, -1, 1, 1, 1, 1, 1, 1, 1, -1, 1, 1, -1, -1, 1,
+		// left
+		-1, 1, 1, -1, 1, -1, -1, -1, -1, -1, -1, -1, -1, -1, 1, -1, 1, 1,
+		// right
+		1, 1, 1, 1, -1, -1, 1, 1, -1, 1, -1, -1, 1, 1, 1, 1, -1, 1,
+		// bottom
+		-1, -1, -1, 1, -1, -1, 1, -1, 1, 1, -1, 1, -1, -1, 1, -1, -1, -1,
+		// top
+		-1, 1, -1, 1, 1, 1, 1, 1, -1, 1, 1, 1, -1, 1, -1, -1, 1, 1,
+	}
+
+	var vao, vbo uint32
+	gl.GenVertexArrays(1, &vao)
+	gl.GenBuffers(1, &vbo)
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices),
+		gl.STATIC_DRAW)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 3*4, gl.PtrOffset(0))
+	return vao, vbo
+}
@@ -0,0 +1,95 @@
+package cubemap
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/texture"
+)
+
+// Save writes the top mip level of each face to dir, named after FACES so
+// LoadDir can read them back. Float cubemaps are written as .hdr files and
+// everything else as 8 bit .png, gray cubemaps as gray.
+func (c Cubemap) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cubemap: %v", err)
+	}
+
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, c.ID)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	defer gl.PixelStorei(gl.PACK_ALIGNMENT, 4)
+
+	channels, glType, size := 4, uint32(gl.UNSIGNED_BYTE), 1
+	if isFloat(c.InternalFormat) {
+		channels, glType, size = 3, gl.FLOAT, 4
+	}
+	// Reading back RGBA ignores the swizzle, gray faces would come out red
+	if gray := grayChannels(c.InternalFormat); gray > 0 && isSwizzled() {
+		channels = gray
+	}
+	format := []uint32{gl.RED, gl.RG, gl.RGB, gl.RGBA}[channels-1]
+
+	for i, face := range FACES {
+		img := &texture.Image{Width: c.Size, Height: c.Size,
+			Channels: channels, Type: glType,
+			Pix: make([]byte, c.Size*c.Size*channels*size)}
+		gl.GetTexImage(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), 0, format,
+			glType, gl.Ptr(img.Pix))
+
+		if err := saveFace(dir, face, img); err != nil {
+			return fmt.Errorf("cubemap: %v", err)
+		}
+	}
+	return nil
+}
+
+// grayChannels is how many channels the one and two channel formats have,
+// 0 for the rest
+func grayChannels(internalFormat int32) int {
+	switch internalFormat {
+	case gl.R8, gl.R16, gl.R16F, gl.R32F:
+		return 1
+	case gl.RG8, gl.RG16, gl.RG16F, gl.RG32F:
+		return 2
+	}
+	return 0
+}
+
+// isSwizzled is whether the bound cubemap samples red as gray like
+// texture.Swizzle sets up, rather than being a red or red green target
+func isSwizzled() bool {
+	var green int32
+	gl.GetTexParameteriv(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_SWIZZLE_G, &green)
+	return green == gl.RED
+}
+
+func saveFace(dir, face string, img *texture.Image) error {
+	ext := ".png"
+	if img.Type == gl.FLOAT {
+		ext = ".hdr"
+	}
+	f, err := os.Create(filepath.Join(dir, face+ext))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if img.Type == gl.FLOAT {
+		err = texture.EncodeHDR(f, img)
+	} else {
+		var decoded image.Image
+		decoded, err = img.ToImage()
+		if err == nil {
+			err = png.Encode(f, decoded)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,82 @@
+package framebuffer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// IncompleteError is returned when GL won't draw to a framebuffer that
+// looked fine. Status is what CheckFramebufferStatus said.
+type IncompleteError struct {
+	Status      uint32
+	Description Description
+}
+
+var statusReasons = map[uint32]string{
+	gl.FRAMEBUFFER_UNDEFINED: "there's no default framebuffer",
+	gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT: "an attachment can't be drawn " +
+		"to, its format may not be renderable",
+	gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT: "there are no " +
+		"attachments",
+	gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER: "a draw buffer has no " +
+		"attachment",
+	gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER: "the read buffer has no " +
+		"attachment",
+	gl.FRAMEBUFFER_UNSUPPORTED: "the driver doesn't support this mix of " +
+		"formats",
+	gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE: "the attachments have " +
+		"different numbers of samples",
+	gl.FRAMEBUFFER_INCOMPLETE_LAYER_TARGETS: "some attachments are " +
+		"layered and some aren't",
+}
+
+func (e *IncompleteError) Error() string {
+	reason, ok := statusReasons[e.Status]
+	if !ok {
+		reason = fmt.Sprintf("status 0x%x", e.Status)
+	}
+	return fmt.Sprintf("framebuffer: %s is incomplete, %s", e.Description,
+		reason)
+}
+
+// String describes the attachments, like "800x600 4x RGBA16F,
+// DEPTH24_STENCIL8 renderbuffer"
+func (d Description) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%dx%d", d.Width, d.Height)
+	switch {
+	case d.Samples > 0:
+		fmt.Fprintf(&b, " %dx", d.Samples)
+	case d.Layers > 0:
+		fmt.Fprintf(&b, " %d layer", d.Layers)
+	case d.Cubemap:
+		b.WriteString(" cubemap")
+	}
+
+	attachments := d.Colors
+	if d.Depth != nil {
+		attachments = append(attachments[:len(attachments):len(attachments)],
+			*d.Depth)
+	}
+	for i, a := range attachments {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(" " + formatName(a.InternalFormat))
+		if a.Renderbuffer {
+			b.WriteString(" renderbuffer")
+		}
+	}
+	return b.String()
+}
+
+// check asks GL if the bound framebuffer is complete
+func check(desc Description) error {
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return &IncompleteError{Status: status, Description: desc}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package framebuffer
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+type formatInfo struct {
+	name string
+	// format and xtype are what TexImage is given along with no data,
+	// they only have to be allowed with the internal format
+	format uint32
+	xtype  uint32
+}
+
+// Formats attachments can have
+var formats = map[int32]formatInfo{
+	gl.RED:               {"RED", gl.RED, gl.UNSIGNED_BYTE},
+	gl.RG:                {"RG", gl.RG, gl.UNSIGNED_BYTE},
+	gl.RGB:               {"RGB", gl.RGB, gl.UNSIGNED_BYTE},
+	gl.RGBA:              {"RGBA", gl.RGBA, gl.UNSIGNED_BYTE},
+	gl.R8:                {"R8", gl.RED, gl.UNSIGNED_BYTE},
+	gl.RG8:               {"RG8", gl.RG, gl.UNSIGNED_BYTE},
+	gl.RGB8:              {"RGB8", gl.RGB, gl.UNSIGNED_BYTE},
+	gl.RGBA8:             {"RGBA8", gl.RGBA, gl.UNSIGNED_BYTE},
+	gl.SRGB8:             {"SRGB8", gl.RGB, gl.UNSIGNED_BYTE},
+	gl.SRGB8_ALPHA8:      {"SRGB8_ALPHA8", gl.RGBA, gl.UNSIGNED_BYTE},
+	gl.RGB10_A2:          {"RGB10_A2", gl.RGBA, gl.UNSIGNED_BYTE},
+	gl.R16F:              {"R16F", gl.RED, gl.FLOAT},
+	gl.RG16F:             {"RG16F", gl.RG, gl.FLOAT},
+	gl.RGB16F:            {"RGB16F", gl.RGB, gl.FLOAT},
+	gl.RGBA16F:           {"RGBA16F", gl.RGBA, gl.FLOAT},
+	gl.R32F:              {"R32F", gl.RED, gl.FLOAT},
+	gl.RG32F:             {"RG32F", gl.RG, gl.FLOAT},
+	gl.RGB32F:            {"RGB32F", gl.RGB, gl.FLOAT},
+	gl.RGBA32F:           {"RGBA32F", gl.RGBA, gl.FLOAT},
+	gl.R11F_G11F_B10F:    {"R11F_G11F_B10F", gl.RGB, gl.FLOAT},
+	gl.R32I:              {"R32I", gl.RED_INTEGER, gl.INT},
+	gl.R32UI:             {"R32UI", gl.RED_INTEGER, gl.UNSIGNED_INT},
+	gl.RGBA32UI:          {"RGBA32UI", gl.RGBA_INTEGER, gl.UNSIGNED_INT},
+	gl.DEPTH_COMPONENT:   {"DEPTH_COMPONENT", gl.DEPTH_COMPONENT, gl.FLOAT},
+	gl.DEPTH_COMPONENT16: {"DEPTH_COMPONENT16", gl.DEPTH_COMPONENT, gl.FLOAT},
+	gl.DEPTH_COMPONENT24: {"DEPTH_COMPONENT24", gl.DEPTH_COMPONENT, gl.FLOAT},
+	gl.DEPTH_COMPONENT32: {"DEPTH_COMPONENT32", gl.DEPTH_COMPONENT, gl.FLOAT},
+	gl.DEPTH_COMPONENT32F: {"DEPTH_COMPONENT32F", gl.DEPTH_COMPONENT,
+		gl.FLOAT},
+	gl.DEPTH_STENCIL: {"DEPTH_STENCIL", gl.DEPTH_STENCIL,
+		gl.UNSIGNED_INT_24_8},
+	gl.DEPTH24_STENCIL8: {"DEPTH24_STENCIL8", gl.DEPTH_STENCIL,
+		gl.UNSIGNED_INT_24_8},
+	gl.DEPTH32F_STENCIL8: {"DEPTH32F_STENCIL8", gl.DEPTH_STENCIL,
+		gl.FLOAT_32_UNSIGNED_INT_24_8_REV},
+}
+
+func transferFormat(internalFormat int32) (uint32, uint32, error) {
+	info, ok := formats[internalFormat]
+	if !ok {
+		return 0, 0, fmt.Errorf("format 0x%x can't be attached",
+			internalFormat)
+	}
+	return info.format, info.xtype, nil
+}
+
+func formatName(internalFormat int32) string {
+	if info, ok := formats[internalFormat]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("0x%x", internalFormat)
+}
+
+func isDepth(internalFormat int32) bool {
+	format, _, _ := transferFormat(internalFormat)
+	return format == gl.DEPTH_COMPONENT || format == gl.DEPTH_STENCIL
+}
+
+func hasStencil(internalFormat int32) bool {
+	format, _, _ := transferFormat(internalFormat)
+	return format == gl.DEPTH_STENCIL
+}
@@ -0,0 +1,388 @@
+// Package framebuffer makes render targets from a description of their
+// attachments instead of generating, allocating and attaching each by hand
+package framebuffer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Attachment is one image of a framebuffer
+type Attachment struct {
+	// InternalFormat is what's stored, such as RGBA16F, DEPTH_COMPONENT or
+	// DEPTH24_STENCIL8
+	InternalFormat int32
+	// Filter is used for minifying and magnifying, 0 is LINEAR for colors
+	// and NEAREST for depth
+	Filter int32
+	// Wrap is used in every direction, 0 is CLAMP_TO_EDGE
+	Wrap int32
+	// BorderColor is sampled outside of CLAMP_TO_BORDER attachments, shadow
+	// maps use white so everything outside them is lit
+	BorderColor mgl32.Vec4
+	// Renderbuffers are for attachments that are only drawn to or blitted,
+	// never sampled
+	Renderbuffer bool
+}
+
+// Description is the size and attachments of a framebuffer, all of its
+// attachments are the same kind of texture
+type Description struct {
+	Width  int32
+	Height int32
+	// Colors are bound to COLOR_ATTACHMENT0 on in order, all of them are
+	// drawn to
+	Colors []Attachment
+	// Depth is a depth or a depth and stencil attachment, nil for neither
+	Depth *Attachment
+	// Samples makes every attachment multisampled, resolve them with Blit
+	// to sample them
+	Samples int32
+	// Layers makes texture attachments 2D arrays attached whole, geometry
+	// shaders pick the layer with gl_Layer
+	Layers int32
+	// Cubemap makes texture attachments cubemaps attached whole, geometry
+	// shaders pick the face with gl_Layer
+	Cubemap bool
+}
+
+// Framebuffer is a framebuffer and the textures or renderbuffers attached
+type Framebuffer struct {
+	ID     uint32
+	Desc   Description
+	Colors []uint32
+	// Depth is 0 without a depth attachment
+	Depth uint32
+
+	deleted bool
+}
+
+// New makes a framebuffer, checking the description makes sense and that
+// GL can draw to it
+func New(desc Description) (*Framebuffer, error) {
+	if err := desc.validate(); err != nil {
+		return nil, err
+	}
+
+	f := &Framebuffer{Desc: desc, Colors: make([]uint32, len(desc.Colors))}
+	gl.GenFramebuffers(1, &f.ID)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	attachments := make([]uint32, len(desc.Colors))
+	for i, a := range desc.Colors {
+		attachments[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+		f.Colors[i] = desc.attach(a, attachments[i])
+	}
+	if desc.Depth != nil {
+		point := uint32(gl.DEPTH_ATTACHMENT)
+		if hasStencil(desc.Depth.InternalFormat) {
+			point = gl.DEPTH_STENCIL_ATTACHMENT
+		}
+		f.Depth = desc.attach(*desc.Depth, point)
+	}
+
+	// Depth only framebuffers have nothing to draw or read colors from
+	if len(attachments) == 0 {
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+	} else {
+		gl.DrawBuffers(int32(len(attachments)), &attachments[0])
+	}
+
+	if err := check(desc); err != nil {
+		f.Delete()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Make is New that panics on errors
+func Make(desc Description) *Framebuffer {
+	f, err := New(desc)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Bind makes the framebuffer the one drawn to and sets the viewport to
+// all of it
+func (f *Framebuffer) Bind() {
+	if f.deleted {
+		panic("framebuffer: Bind after Delete")
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	gl.Viewport(0, 0, f.Desc.Width, f.Desc.Height)
+}
+
+// Target is the texture target of the framebuffer's texture attachments
+func (f *Framebuffer) Target() uint32 {
+	return f.Desc.target()
+}
+
+// Resize gives every attachment new storage of the new size, what was
+// drawn is lost. The textures keep their names so they don't need binding
+// again.
+func (f *Framebuffer) Resize(width, height int32) error {
+	if f.deleted {
+		return errors.New("framebuffer: Resize after Delete")
+	}
+	desc := f.Desc
+	desc.Width, desc.Height = width, height
+	if err := desc.validate(); err != nil {
+		return err
+	}
+	f.Desc = desc
+
+	for i, a := range desc.Colors {
+		desc.allocate(a, f.Colors[i])
+	}
+	if desc.Depth != nil {
+		desc.allocate(*desc.Depth, f.Depth)
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return check(desc)
+}
+
+// Delete frees the framebuffer and its attachments. Deleting twice does
+// nothing.
+func (f *Framebuffer) Delete() {
+	if f.deleted {
+		return
+	}
+	f.deleted = true
+
+	gl.DeleteFramebuffers(1, &f.ID)
+	attachments := f.Desc.Colors
+	names := f.Colors
+	if f.Desc.Depth != nil {
+		attachments = append(attachments[:len(attachments):len(attachments)],
+			*f.Desc.Depth)
+		names = append(names[:len(names):len(names)], f.Depth)
+	}
+	for i, a := range attachments {
+		if a.Renderbuffer {
+			gl.DeleteRenderbuffers(1, &names[i])
+		} else {
+			gl.DeleteTextures(1, &names[i])
+		}
+	}
+	f.ID, f.Colors, f.Depth = 0, nil, 0
+}
+
+// Deleted is true once Delete has been called
+func (f *Framebuffer) Deleted() bool {
+	return f.deleted
+}
+
+// Blit copies the buffers in mask, such as COLOR_BUFFER_BIT or
+// DEPTH_BUFFER_BIT, from all of src to all of dst, or the default
+// framebuffer of the same size when dst is nil. Blitting a multisampled
+// framebuffer resolves it. Colors go from the first attachment to the
+// first, see Resolve for the rest. Depth and stencil need NEAREST.
+func Blit(src, dst *Framebuffer, mask uint32, filter uint32) {
+	width, height := src.Desc.Width, src.Desc.Height
+	var dstID uint32
+	dstWidth, dstHeight := width, height
+	if dst != nil {
+		dstID = dst.ID
+		dstWidth, dstHeight = dst.Desc.Width, dst.Desc.Height
+	}
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, src.ID)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, dstID)
+	gl.BlitFramebuffer(0, 0, width, height, 0, 0, dstWidth, dstHeight, mask,
+		filter)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// Resolve blits every color attachment of a multisampled framebuffer into
+// the same attachment of dst so they can be sampled
+func (f *Framebuffer) Resolve(dst *Framebuffer) {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.ID)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, dst.ID)
+	n := len(f.Colors)
+	if len(dst.Colors) < n {
+		n = len(dst.Colors)
+	}
+	for i := 0; i < n; i++ {
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0 + uint32(i))
+		gl.DrawBuffer(gl.COLOR_ATTACHMENT0 + uint32(i))
+		gl.BlitFramebuffer(0, 0, f.Desc.Width, f.Desc.Height, 0, 0,
+			dst.Desc.Width, dst.Desc.Height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	}
+
+	// Put back drawing to every attachment
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	attachments := make([]uint32, len(dst.Colors))
+	for i := range attachments {
+		attachments[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+	}
+	if len(attachments) > 0 {
+		gl.DrawBuffers(int32(len(attachments)), &attachments[0])
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+func (d *Description) validate() error {
+	switch {
+	case d.Width <= 0 || d.Height <= 0:
+		return fmt.Errorf("framebuffer: size %dx%d isn't positive", d.Width,
+			d.Height)
+	case len(d.Colors) == 0 && d.Depth == nil:
+		return errors.New("framebuffer: there are no attachments")
+	case d.Samples < 0 || d.Layers < 0:
+		return errors.New("framebuffer: negative samples or layers")
+	case d.Samples > 0 && (d.Layers > 0 || d.Cubemap):
+		return errors.New("framebuffer: multisampled attachments can't be " +
+			"layered")
+	case d.Layers > 0 && d.Cubemap:
+		return errors.New("framebuffer: attachments can't be both layers " +
+			"and cubemaps")
+	case d.Cubemap && d.Width != d.Height:
+		return fmt.Errorf("framebuffer: cubemap faces are %dx%d, they "+
+			"need to be square", d.Width, d.Height)
+	}
+
+	var maxColors, maxSamples int32
+	gl.GetIntegerv(gl.MAX_COLOR_ATTACHMENTS, &maxColors)
+	gl.GetIntegerv(gl.MAX_SAMPLES, &maxSamples)
+	if int32(len(d.Colors)) > maxColors {
+		return fmt.Errorf("framebuffer: %d color attachments but the "+
+			"driver only has %d", len(d.Colors), maxColors)
+	}
+	if d.Samples > maxSamples {
+		return fmt.Errorf("framebuffer: %d samples but the driver only has "+
+			"%d", d.Samples, maxSamples)
+	}
+
+	attachments := d.Colors
+	if d.Depth != nil {
+		attachments = append(attachments[:len(attachments):len(attachments)],
+			*d.Depth)
+	}
+	for i, a := range attachments {
+		name := fmt.Sprintf("color attachment %d", i)
+		depth := d.Depth != nil && i == len(attachments)-1
+		if depth {
+			name = "depth attachment"
+		}
+
+		if _, _, err := transferFormat(a.InternalFormat); err != nil {
+			return fmt.Errorf("framebuffer: %s: %v", name, err)
+		}
+		if isDepth(a.InternalFormat) != depth {
+			return fmt.Errorf("framebuffer: %s has format %s", name,
+				formatName(a.InternalFormat))
+		}
+		if a.Renderbuffer && (d.Layers > 0 || d.Cubemap) {
+			// GL would say the layer targets are incomplete
+			return fmt.Errorf("framebuffer: %s is a renderbuffer but "+
+				"renderbuffers can't be layered", name)
+		}
+	}
+	return nil
+}
+
+func (d *Description) target() uint32 {
+	switch {
+	case d.Samples > 0:
+		return gl.TEXTURE_2D_MULTISAMPLE
+	case d.Layers > 0:
+		return gl.TEXTURE_2D_ARRAY
+	case d.Cubemap:
+		return gl.TEXTURE_CUBE_MAP
+	}
+	return gl.TEXTURE_2D
+}
+
+// attach makes an attachment and attaches it to the bound framebuffer
+func (d *Description) attach(a Attachment, point uint32) uint32 {
+	var name uint32
+	if a.Renderbuffer {
+		gl.GenRenderbuffers(1, &name)
+		d.allocate(a, name)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, point, gl.RENDERBUFFER,
+			name)
+		return name
+	}
+
+	gl.GenTextures(1, &name)
+	d.allocate(a, name)
+	target := d.target()
+	gl.BindTexture(target, name)
+	if target != gl.TEXTURE_2D_MULTISAMPLE {
+		filter := a.Filter
+		if filter == 0 {
+			filter = gl.LINEAR
+			if isDepth(a.InternalFormat) {
+				filter = gl.NEAREST
+			}
+		}
+		wrap := a.Wrap
+		if wrap == 0 {
+			wrap = gl.CLAMP_TO_EDGE
+		}
+		gl.TexParameteri(target, gl.TEXTURE_MIN_FILTER, filter)
+		gl.TexParameteri(target, gl.TEXTURE_MAG_FILTER, filter)
+		gl.TexParameteri(target, gl.TEXTURE_WRAP_S, wrap)
+		gl.TexParameteri(target, gl.TEXTURE_WRAP_T, wrap)
+		gl.TexParameteri(target, gl.TEXTURE_WRAP_R, wrap)
+		if wrap == gl.CLAMP_TO_BORDER {
+			gl.TexParameterfv(target, gl.TEXTURE_BORDER_COLOR,
+				&a.BorderColor[0])
+		}
+	}
+	gl.BindTexture(target, 0)
+
+	if target == gl.TEXTURE_2D || target == gl.TEXTURE_2D_MULTISAMPLE {
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, point, target, name, 0)
+	} else {
+		// Layered, every layer or face at once
+		gl.FramebufferTexture(gl.FRAMEBUFFER, point, name, 0)
+	}
+	return name
+}
+
+// allocate gives an attachment storage of the description's size
+func (d *Description) allocate(a Attachment, name uint32) {
+	if a.Renderbuffer {
+		gl.BindRenderbuffer(gl.RENDERBUFFER, name)
+		if d.Samples > 0 {
+			gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, d.Samples,
+				uint32(a.InternalFormat), d.Width, d.Height)
+		} else {
+			gl.RenderbufferStorage(gl.RENDERBUFFER,
+				uint32(a.InternalFormat), d.Width, d.Height)
+		}
+		gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+		return
+	}
+
+	target := d.target()
+	format, xtype, _ := transferFormat(a.InternalFormat)
+	gl.BindTexture(target, name)
+	switch target {
+	case gl.TEXTURE_2D_MULTISAMPLE:
+		gl.TexImage2DMultisample(target, d.Samples, uint32(a.InternalFormat),
+			d.Width, d.Height, true)
+	case gl.TEXTURE_2D_ARRAY:
+		gl.TexImage3D(target, 0, a.InternalFormat, d.Width, d.Height,
+			d.Layers, 0, format, xtype, nil)
+	case gl.TEXTURE_CUBE_MAP:
+		for face := uint32(0); face < 6; face++ {
+			gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+face, 0,
+				a.InternalFormat, d.Width, d.Height, 0, format, xtype, nil)
+		}
+	default:
+		gl.TexImage2D(target, 0, a.InternalFormat, d.Width, d.Height, 0,
+			format, xtype, nil)
+	}
+	gl.BindTexture(target, 0)
+}
@@ -0,0 +1,79 @@
+package mesh
+
+import (
+	"fmt"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/meshdata"
+)
+
+// LoadFile makes meshes from a mesh file written by cmd/assetc. The file
+// is mapped and its vertices go straight from the mapping into buffers.
+// Materials are only named in the file, each mesh gets the default one
+// with the name set.
+func LoadFile(path string) ([]*Mesh, error) {
+	file, err := meshdata.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var meshes []*Mesh
+	for _, m := range file.Meshes {
+		format, err := fileFormat(m)
+		if err != nil {
+			for _, mesh := range meshes {
+				mesh.Delete()
+			}
+			return nil, fmt.Errorf("mesh: %s: mesh %q: %v", path, m.Name, err)
+		}
+
+		// Draw needs the indices after the file is unmapped
+		indices := make([]uint32, m.IndexCount())
+		for i := range indices {
+			indices[i] = m.Index(i)
+		}
+		data := &VertexData{Format: format, Count: m.VertexCount,
+			Streams: [][]byte{m.Vertices}}
+
+		mesh := NewMeshFormat(data, indices, nil)
+		mesh.Material.Name = m.Material
+		meshes = append(meshes, mesh)
+	}
+	return meshes, nil
+}
+
+// MakeFile is LoadFile that panics on errors
+func MakeFile(path string) []*Mesh {
+	meshes, err := LoadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	return meshes
+}
+
+// fileFormat checks a mesh file's layout is the one NewVertexFormat would
+// make from its attributes
+func fileFormat(m meshdata.Mesh) (*VertexFormat, error) {
+	attributes := make([]Attribute, len(m.Attributes))
+	for i, a := range m.Attributes {
+		attributes[i] = Attribute{Name: a.Name, Location: a.Location,
+			Size: a.Size, Type: a.Type, Normalized: a.Normalized,
+			Integer: a.Integer}
+	}
+	format, err := NewVertexFormat(attributes...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, a := range format.Attributes {
+		if a.Offset != m.Attributes[i].Offset {
+			return nil, fmt.Errorf("attribute %s is at %d not %d", a.Name,
+				m.Attributes[i].Offset, a.Offset)
+		}
+	}
+	if format.Strides[0] != m.Stride {
+		return nil, fmt.Errorf("stride is %d not %d", m.Stride,
+			format.Strides[0])
+	}
+	return format, nil
+}
@@ -0,0 +1,176 @@
+package mesh
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Attribute describes one vertex shader input and how it's stored
+type Attribute struct {
+	Name     string
+	Location uint32
+	// Size is the number of components, 1 to 4
+	Size int32
+	// Type is the GL type of each component, FLOAT, HALF_FLOAT, BYTE,
+	// UNSIGNED_BYTE, SHORT, UNSIGNED_SHORT, INT or UNSIGNED_INT
+	Type uint32
+	// Normalized integers are read as floats from 0 to 1, or -1 to 1 when
+	// signed
+	Normalized bool
+	// Integer attributes stay integers for ivec and uvec inputs
+	Integer bool
+	// Stream is the buffer the attribute is in. Attributes in the same
+	// stream are interleaved, give each its own stream to keep them apart.
+	Stream int
+	// Divisor is 0 for per vertex attributes or how many instances share
+	// each value
+	Divisor uint32
+
+	// Offset into a vertex of the stream, set by NewVertexFormat
+	Offset int
+}
+
+// VertexFormat is the layout of a mesh's vertices across one or more
+// buffers
+type VertexFormat struct {
+	Attributes []Attribute
+	// Strides are the bytes between vertices of each stream
+	Strides []int
+}
+
+// DefaultFormat is the layout of Vertex, attributes at locations 0 to 6 in
+// field order in a single stream
+var DefaultFormat = MakeVertexFormat(
+	Attribute{Name: "aPos", Location: 0, Size: 3, Type: gl.FLOAT},
+	Attribute{Name: "aNormal", Location: 1, Size: 3, Type: gl.FLOAT},
+	Attribute{Name: "aTexCoords", Location: 2, Size: 2, Type: gl.FLOAT},
+	Attribute{Name: "aTangent", Location: 3, Size: 3, Type: gl.FLOAT},
+	Attribute{Name: "aBitangent", Location: 4, Size: 3, Type: gl.FLOAT},
+	Attribute{Name: "aBoneIDs", Location: 5, Size: MAX_BONE_INFLUENCE,
+		Type: gl.INT, Integer: true},
+	Attribute{Name: "aWeights", Location: 6, Size: MAX_BONE_INFLUENCE,
+		Type: gl.FLOAT})
+
+// NewVertexFormat lays out attributes in the order given, each stream
+// packed with every attribute 4 byte aligned
+func NewVertexFormat(attributes ...Attribute) (*VertexFormat, error) {
+	if len(attributes) == 0 {
+		return nil, errors.New("mesh: vertex format has no attributes")
+	}
+	format := &VertexFormat{}
+	locations := map[uint32]string{}
+
+	for _, a := range attributes {
+		size := typeSize(a.Type)
+		switch {
+		case size == 0:
+			return nil, fmt.Errorf("mesh: attribute %s: unknown type 0x%x",
+				a.Name, a.Type)
+		case a.Size < 1 || a.Size > 4:
+			return nil, fmt.Errorf("mesh: attribute %s: size %d is not 1 to 4",
+				a.Name, a.Size)
+		case a.Integer && (a.Type == gl.FLOAT || a.Type == gl.HALF_FLOAT):
+			return nil, fmt.Errorf("mesh: attribute %s: integer attributes "+
+				"need an integer type", a.Name)
+		case a.Stream < 0:
+			return nil, fmt.Errorf("mesh: attribute %s: negative stream",
+				a.Name)
+		}
+		if other, ok := locations[a.Location]; ok {
+			return nil, fmt.Errorf("mesh: attributes %s and %s both use "+
+				"location %d", other, a.Name, a.Location)
+		}
+		locations[a.Location] = a.Name
+
+		for len(format.Strides) <= a.Stream {
+			format.Strides = append(format.Strides, 0)
+		}
+		a.Offset = format.Strides[a.Stream]
+		format.Strides[a.Stream] += align4(size * int(a.Size))
+		format.Attributes = append(format.Attributes, a)
+	}
+	return format, nil
+}
+
+// MakeVertexFormat is NewVertexFormat that panics on errors
+func MakeVertexFormat(attributes ...Attribute) *VertexFormat {
+	format, err := NewVertexFormat(attributes...)
+	if err != nil {
+		panic(err)
+	}
+	return format
+}
+
+// Mat4Attributes is a mat4 input, which takes four locations starting at
+// location, one for each column
+func Mat4Attributes(name string, location uint32, stream int,
+	divisor uint32) []Attribute {
+
+	var attributes []Attribute
+	for i := uint32(0); i < 4; i++ {
+		attributes = append(attributes, Attribute{
+			Name: fmt.Sprintf("%s[%d]", name, i), Location: location + i,
+			Size: 4, Type: gl.FLOAT, Stream: stream, Divisor: divisor})
+	}
+	return attributes
+}
+
+// Attribute gets the index of the attribute called name or -1
+func (f *VertexFormat) Attribute(name string) int {
+	for i, a := range f.Attributes {
+		if a.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// NextLocation is the location after the highest one the format uses,
+// where extra attributes such as instance data can safely go
+func (f *VertexFormat) NextLocation() uint32 {
+	var next uint32
+	for _, a := range f.Attributes {
+		if a.Location >= next {
+			next = a.Location + 1
+		}
+	}
+	return next
+}
+
+// enable sets the attribute pointers of a stream to the bound array buffer
+func (f *VertexFormat) enable(stream int) {
+	stride := int32(f.Strides[stream])
+	for _, a := range f.Attributes {
+		if a.Stream != stream {
+			continue
+		}
+		gl.EnableVertexAttribArray(a.Location)
+		if a.Integer {
+			gl.VertexAttribIPointer(a.Location, a.Size, a.Type, stride,
+				gl.PtrOffset(a.Offset))
+		} else {
+			gl.VertexAttribPointer(a.Location, a.Size, a.Type, a.Normalized,
+				stride, gl.PtrOffset(a.Offset))
+		}
+		gl.VertexAttribDivisor(a.Location, a.Divisor)
+	}
+}
+
+// typeSize is the size of a component in bytes or 0 if it isn't supported
+func typeSize(glType uint32) int {
+	switch glType {
+	case gl.BYTE, gl.UNSIGNED_BYTE:
+		return 1
+	case gl.SHORT, gl.UNSIGNED_SHORT, gl.HALF_FLOAT:
+		return 2
+	case gl.INT, gl.UNSIGNED_INT, gl.FLOAT:
+		return 4
+	}
+	return 0
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
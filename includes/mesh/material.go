@@ -0,0 +1,114 @@
+package mesh
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
+)
+
+// Alpha modes, the same as glTF's
+const ALPHA_OPAQUE = 0
+const ALPHA_MASK = 1
+const ALPHA_BLEND = 2
+
+// TextureSlot is a texture as a material samples it. UVIndex is the set of
+// texture coordinates it uses and WrapS and WrapT are GL wrap modes.
+type TextureSlot struct {
+	Texture
+	UVIndex int
+	WrapS   int32
+	WrapT   int32
+}
+
+// Bind binds the texture to a texture unit with the slot's wrap modes
+func (t TextureSlot) Bind(unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D, t.Id)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, t.WrapS)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, t.WrapT)
+}
+
+// Material holds both the classic Phong colours and the PBR metallic
+// roughness factors, loaders fill in what the file has and derive the
+// rest. Textures use the same TextureType names as Draw.
+type Material struct {
+	Name string
+
+	Ambient   mgl32.Vec3
+	Diffuse   mgl32.Vec3
+	Specular  mgl32.Vec3
+	Emissive  mgl32.Vec3
+	Shininess float32
+	Opacity   float32
+
+	BaseColor mgl32.Vec4
+	Metallic  float32
+	Roughness float32
+	AO        float32
+
+	AlphaMode int
+	// Fragments under the cutoff are discarded with ALPHA_MASK
+	AlphaCutoff float32
+	DoubleSided bool
+
+	Textures []TextureSlot
+}
+
+func DefaultMaterial() Material {
+	return Material{
+		Diffuse:     mgl32.Vec3{1.0, 1.0, 1.0},
+		Shininess:   32.0,
+		Opacity:     1.0,
+		BaseColor:   mgl32.Vec4{1.0, 1.0, 1.0, 1.0},
+		Roughness:   1.0,
+		AO:          1.0,
+		AlphaMode:   ALPHA_OPAQUE,
+		AlphaCutoff: 0.5}
+}
+
+// Texture gets the first texture of a type or nil
+func (m *Material) Texture(textureType string) *TextureSlot {
+	for i := range m.Textures {
+		if m.Textures[i].TextureType == textureType {
+			return &m.Textures[i]
+		}
+	}
+	return nil
+}
+
+// SetPBR sets the uniforms the 6.pbr shaders use, albedo, metallic,
+// roughness and ao, and binds whichever of albedoMap, normalMap,
+// metallicMap, roughnessMap and aoMap the material has starting at
+// texture unit firstUnit. It gives back the next free unit.
+func (m *Material) SetPBR(s shader.Shader, firstUnit uint32) uint32 {
+	s.SetVec3("albedo", m.BaseColor.Vec3())
+	s.SetFloat("metallic", m.Metallic)
+	s.SetFloat("roughness", m.Roughness)
+	s.SetFloat("ao", m.AO)
+
+	maps := []struct {
+		uniform string
+		types   []string
+	}{
+		{"albedoMap", []string{"texture_base_color", "texture_diffuse"}},
+		{"normalMap", []string{"texture_normal"}},
+		{"metallicMap", []string{"texture_metallic"}},
+		{"roughnessMap", []string{"texture_roughness"}},
+		{"aoMap", []string{"texture_ao"}},
+	}
+
+	unit := firstUnit
+	for _, sampler := range maps {
+		for _, textureType := range sampler.types {
+			if t := m.Texture(textureType); t != nil {
+				t.Bind(unit)
+				s.SetInt(sampler.uniform, int32(unit))
+				unit++
+				break
+			}
+		}
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	return unit
+}
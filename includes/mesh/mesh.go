@@ -7,18 +7,17 @@ import (
 	"unsafe"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/go-gl/mathgl/mgl32"
 
+	"github.com/nicholasblaskey/go-learn-opengl/includes/meshdata"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
 
-type Vertex struct {
-	Position  mgl32.Vec3
-	Normal    mgl32.Vec3
-	TexCoords mgl32.Vec2
-	Tangent   mgl32.Vec3
-	Bitangent mgl32.Vec3
-}
+// Most bones that can move a single vertex
+const MAX_BONE_INFLUENCE = meshdata.MAX_BONE_INFLUENCE
+
+// Vertex is kept in meshdata so tools can make vertices without cgo, see
+// DefaultFormat for its attributes
+type Vertex = meshdata.Vertex
 
 type Texture struct {
 	Id          uint32
@@ -27,28 +26,54 @@ type Texture struct {
 }
 
 type Mesh struct {
-	vertices []Vertex
 	Indices  []uint32
 	textures []Texture
 	VAO      uint32
+	// VBO is the first of Buffers
 	VBO      uint32
 	EBO      uint32
+	Material Material
+
+	// Format is the layout of the mesh's vertices with a buffer for each
+	// of its streams
+	Format  *VertexFormat
+	Buffers []uint32
+
+	deleted bool
 }
 
+// NewMesh makes a mesh of Vertex in DefaultFormat
 func NewMesh(vertices []Vertex, indices []uint32, textures []Texture) *Mesh {
-	// give buffers value of 0 to avoid complaing
-	mesh := Mesh{vertices, indices, textures, 0, 0, 0}
-	mesh.setUpMesh()
+	data := &VertexData{Format: DefaultFormat, Count: len(vertices)}
+	// Take advantage of sequential struct layout
+	if len(vertices) > 0 {
+		size := len(vertices) * int(unsafe.Sizeof(vertices[0]))
+		data.Streams = [][]byte{
+			(*[1 << 30]byte)(unsafe.Pointer(&vertices[0]))[:size:size]}
+	} else {
+		data.Streams = [][]byte{nil}
+	}
+	return NewMeshFormat(data, indices, textures)
+}
+
+// NewMeshFormat makes a mesh from vertices in any format
+func NewMeshFormat(data *VertexData, indices []uint32,
+	textures []Texture) *Mesh {
+
+	mesh := Mesh{Indices: indices, textures: textures,
+		Material: DefaultMaterial(), Format: data.Format}
+	mesh.setUpMesh(data)
 
 	return &mesh
 }
 
 func (m *Mesh) Draw(shader shader.Shader) {
-	// Bind appropriate textures
-	var diffuseNr uint32 = 1
-	var specularNr uint32 = 1
-	var normalNr uint32 = 1
-	var heightNr uint32 = 1
+	if m.deleted {
+		panic("mesh: Draw after Delete")
+	}
+
+	// Bind appropriate textures, counting each type from 1
+	numbers := map[string]int{}
 
 	for i := 0; i < len(m.textures); i++ {
 		// Active proper texture unit before binding it
@@ -56,23 +81,10 @@ func (m *Mesh) Draw(shader shader.Shader) {
 
 		// retrieve textre number (the n in diffuse_textureN)
 		name := m.textures[i].TextureType
-		var number string
-		if name == "texture_diffuse" {
-			number = strconv.Itoa(int(diffuseNr))
-			diffuseNr++
-		} else if name == "texture_specular" {
-			number = strconv.Itoa(int(specularNr))
-			specularNr++
-		} else if name == "texture_normal" {
-			number = strconv.Itoa(int(normalNr))
-			normalNr++
-		} else if name == "texture_height" {
-			number = strconv.Itoa(int(heightNr))
-			heightNr++
-		}
+		numbers[name]++
+		number := strconv.Itoa(numbers[name])
 
-		gl.Uniform1i(gl.GetUniformLocation(
-			shader.ID, gl.Str(name+number+"\x00")), int32(i))
+		shader.SetInt(name+number, int32(i))
 		gl.BindTexture(gl.TEXTURE_2D, m.textures[i].Id)
 	}
 
@@ -86,46 +98,63 @@ func (m *Mesh) Draw(shader shader.Shader) {
 	gl.ActiveTexture(gl.TEXTURE0)
 }
 
-func (m *Mesh) setUpMesh() {
-	vertexSize := int(unsafe.Sizeof(m.vertices[0]))
+// AddStream points the mesh's vertex array at one stream of another
+// format in buffer, for extra per vertex or instance data. The buffer
+// still belongs to the caller. Use locations from Format.NextLocation on.
+func (m *Mesh) AddStream(buffer uint32, format *VertexFormat, stream int) {
+	gl.BindVertexArray(m.VAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, buffer)
+	format.enable(stream)
+	gl.BindVertexArray(0)
+}
+
+// Delete frees the mesh's vertex array and buffers. Its textures belong to
+// whatever loaded them and are left alone. Deleting twice does nothing but
+// drawing afterwards panics.
+func (m *Mesh) Delete() {
+	if m.deleted {
+		return
+	}
+	m.deleted = true
+
+	gl.DeleteVertexArrays(1, &m.VAO)
+	gl.DeleteBuffers(int32(len(m.Buffers)), &m.Buffers[0])
+	gl.DeleteBuffers(1, &m.EBO)
+	TrackObjects(VERTEX_ARRAYS, -1)
+	TrackObjects(BUFFERS, -len(m.Buffers)-1)
+	m.VAO, m.VBO, m.EBO, m.Buffers = 0, 0, 0, nil
+}
 
+// Deleted is true once Delete has been called
+func (m *Mesh) Deleted() bool {
+	return m.deleted
+}
+
+func (m *Mesh) setUpMesh(data *VertexData) {
 	// Create buffers / arrays
 	gl.GenVertexArrays(1, &m.VAO)
-	gl.GenBuffers(1, &m.VBO)
+	m.Buffers = make([]uint32, len(data.Streams))
+	gl.GenBuffers(int32(len(m.Buffers)), &m.Buffers[0])
 	gl.GenBuffers(1, &m.EBO)
+	m.VBO = m.Buffers[0]
+	TrackObjects(VERTEX_ARRAYS, 1)
+	TrackObjects(BUFFERS, len(m.Buffers)+1)
 
 	gl.BindVertexArray(m.VAO)
-	// Load data into vertex buffers
-	gl.BindBuffer(gl.ARRAY_BUFFER, m.VBO)
-	// Take advantage of sequential struct layout
-	gl.BufferData(gl.ARRAY_BUFFER, len(m.vertices)*vertexSize,
-		gl.Ptr(m.vertices), gl.STATIC_DRAW)
+	// Load data into vertex buffers and set the vertex attrib pointers
+	for i, stream := range data.Streams {
+		gl.BindBuffer(gl.ARRAY_BUFFER, m.Buffers[i])
+		var ptr unsafe.Pointer
+		if len(stream) > 0 {
+			ptr = gl.Ptr(stream)
+		}
+		gl.BufferData(gl.ARRAY_BUFFER, len(stream), ptr, gl.STATIC_DRAW)
+		m.Format.enable(i)
+	}
 
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.EBO)
 	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(m.Indices)*4,
 		gl.Ptr(m.Indices), gl.STATIC_DRAW)
 
-	// Set the vertex attrib pointers
-	// Vertex positions
-	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, int32(vertexSize),
-		gl.PtrOffset(0))
-	// Vertex normals
-	gl.EnableVertexAttribArray(1)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, int32(vertexSize),
-		gl.PtrOffset(int(unsafe.Offsetof(m.vertices[0].Normal))))
-	// Vertex texture coords
-	gl.EnableVertexAttribArray(2)
-	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, int32(vertexSize),
-		gl.PtrOffset(int(unsafe.Offsetof(m.vertices[0].TexCoords))))
-	// Vertex tangent
-	gl.EnableVertexAttribArray(3)
-	gl.VertexAttribPointer(3, 3, gl.FLOAT, false, int32(vertexSize),
-		gl.PtrOffset(int(unsafe.Offsetof(m.vertices[0].Tangent))))
-	// Vertex bitangent
-	gl.EnableVertexAttribArray(4)
-	gl.VertexAttribPointer(4, 3, gl.FLOAT, false, int32(vertexSize),
-		gl.PtrOffset(int(unsafe.Offsetof(m.vertices[0].Bitangent))))
-
 	gl.BindVertexArray(0)
 }
@@ -0,0 +1,37 @@
+package mesh
+
+import (
+	"sync"
+)
+
+// Kinds of GL object counted by LiveObjects
+const VERTEX_ARRAYS = "vertex arrays"
+const BUFFERS = "buffers"
+const TEXTURES = "textures"
+
+var live = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: map[string]int{}}
+
+// TrackObjects adds n, or takes away if negative, from the count of live
+// GL objects of a kind. Meshes count themselves, the model package counts
+// the textures it makes.
+func TrackObjects(kind string, n int) {
+	live.Lock()
+	defer live.Unlock()
+	live.counts[kind] += n
+}
+
+// LiveObjects is how many GL objects of each kind have been made and not
+// deleted yet, for checking nothing leaks once everything is deleted
+func LiveObjects() map[string]int {
+	live.Lock()
+	defer live.Unlock()
+
+	counts := map[string]int{}
+	for kind, n := range live.counts {
+		counts[kind] = n
+	}
+	return counts
+}
@@ -0,0 +1,149 @@
+package mesh
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// VertexData is vertices packed into the buffers of a format, ready to
+// upload with NewMeshFormat
+type VertexData struct {
+	Format  *VertexFormat
+	Count   int
+	Streams [][]byte
+}
+
+func NewVertexData(format *VertexFormat, count int) *VertexData {
+	data := &VertexData{Format: format, Count: count}
+	for _, stride := range format.Strides {
+		data.Streams = append(data.Streams, make([]byte, stride*count))
+	}
+	return data
+}
+
+// Set stores a vertex's value of an attribute, converting to the
+// attribute's type. Normalized types are clamped to their range and
+// missing components are left zero.
+func (d *VertexData) Set(attribute, vertex int, values ...float32) {
+	a, buf := d.component(attribute, vertex)
+	for i, v := range values[:min(len(values), int(a.Size))] {
+		putFloat(buf[i*typeSize(a.Type):], a.Type, float64(v), a.Normalized)
+	}
+}
+
+// SetInt is Set for integer attributes
+func (d *VertexData) SetInt(attribute, vertex int, values ...int32) {
+	a, buf := d.component(attribute, vertex)
+	for i, v := range values[:min(len(values), int(a.Size))] {
+		putInt(buf[i*typeSize(a.Type):], a.Type, int64(v))
+	}
+}
+
+func (d *VertexData) component(attribute, vertex int) (Attribute, []byte) {
+	a := d.Format.Attributes[attribute]
+	start := vertex*d.Format.Strides[a.Stream] + a.Offset
+	return a, d.Streams[a.Stream][start:]
+}
+
+func putFloat(buf []byte, glType uint32, v float64, normalized bool) {
+	switch glType {
+	case gl.FLOAT:
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(v)))
+		return
+	case gl.HALF_FLOAT:
+		binary.LittleEndian.PutUint16(buf, halfFloat(float32(v)))
+		return
+	}
+
+	if normalized {
+		if signed(glType) {
+			v = math.Max(-1.0, math.Min(1.0, v)) * maxInt(glType)
+		} else {
+			v = math.Max(0.0, math.Min(1.0, v)) * maxInt(glType)
+		}
+	}
+	putInt(buf, glType, int64(math.Round(v)))
+}
+
+func putInt(buf []byte, glType uint32, v int64) {
+	switch glType {
+	case gl.BYTE, gl.UNSIGNED_BYTE:
+		buf[0] = byte(v)
+	case gl.SHORT, gl.UNSIGNED_SHORT:
+		binary.LittleEndian.PutUint16(buf, uint16(v))
+	case gl.INT, gl.UNSIGNED_INT:
+		binary.LittleEndian.PutUint32(buf, uint32(v))
+	case gl.FLOAT:
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(v)))
+	case gl.HALF_FLOAT:
+		binary.LittleEndian.PutUint16(buf, halfFloat(float32(v)))
+	}
+}
+
+func signed(glType uint32) bool {
+	return glType == gl.BYTE || glType == gl.SHORT || glType == gl.INT
+}
+
+// maxInt is the largest value of an integer type, which normalizes to 1
+func maxInt(glType uint32) float64 {
+	switch glType {
+	case gl.BYTE:
+		return math.MaxInt8
+	case gl.UNSIGNED_BYTE:
+		return math.MaxUint8
+	case gl.SHORT:
+		return math.MaxInt16
+	case gl.UNSIGNED_SHORT:
+		return math.MaxUint16
+	case gl.INT:
+		return math.MaxInt32
+	}
+	return math.MaxUint32
+}
+
+// halfFloat rounds a float32 to the nearest 16 bit float
+func halfFloat(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := (bits >> 16) & 0x8000
+	exp := int32(bits>>23&0xff) - 127 + 15
+	mantissa := bits & 0x7fffff
+
+	switch {
+	case bits&0x7fffffff >= 0x7f800000:
+		// Infinity or NaN
+		if mantissa != 0 {
+			return uint16(sign | 0x7e00)
+		}
+		return uint16(sign | 0x7c00)
+	case exp >= 31:
+		return uint16(sign | 0x7c00)
+	case exp <= 0:
+		// Too small for a normal half so make it subnormal
+		if exp < -10 {
+			return uint16(sign)
+		}
+		mantissa |= 0x800000
+		shift := uint(14 - exp)
+		half := sign | mantissa>>shift
+		if mantissa&(1<<(shift-1)) != 0 {
+			half++
+		}
+		return uint16(half)
+	}
+
+	// Rounding up can carry into the exponent, which is still right
+	half := sign | uint32(exp)<<10 | mantissa>>13
+	if mantissa&0x1000 != 0 {
+		half++
+	}
+	return uint16(half)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
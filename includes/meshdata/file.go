@@ -0,0 +1,327 @@
+package meshdata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Mesh files hold meshes ready to copy straight into buffers. Everything
+// is little endian. A header of "MESH", the version and the mesh count is
+// followed by a description of each mesh, then their vertices and indices,
+// each starting 16 byte aligned so they can be used where they're mapped.
+//
+// A description is the name and material, each a uint16 length and the
+// bytes, the stride, vertex count, index count, vertex offset and index
+// offset as uint32s, the bounds as six float32s, then a uint8 count of
+// attributes. Each attribute is its name, the location as a uint32, size
+// as a uint8, GL type as a uint32, a uint8 of flags and its offset as a
+// uint16. Indices are uint32s.
+
+const FILE_VERSION = 1
+
+var fileMagic = []byte("MESH")
+
+// GL's numbers for the types attributes can be, so files can be written
+// without importing GL
+const (
+	BYTE           = 0x1400
+	UNSIGNED_BYTE  = 0x1401
+	SHORT          = 0x1402
+	UNSIGNED_SHORT = 0x1403
+	INT            = 0x1404
+	UNSIGNED_INT   = 0x1405
+	FLOAT          = 0x1406
+	HALF_FLOAT     = 0x140B
+)
+
+// Attribute flags
+const (
+	normalizedFlag = 1
+	integerFlag    = 2
+)
+
+// Attribute is a vertex shader input of a mesh, like mesh.Attribute but
+// always in a single interleaved stream
+type Attribute struct {
+	Name       string
+	Location   uint32
+	Size       int32
+	Type       uint32
+	Normalized bool
+	Integer    bool
+	Offset     int
+}
+
+// Mesh is one mesh of a file. Opened files keep Vertices and Indices in
+// the mapping so they're only good until the file is closed.
+type Mesh struct {
+	Name     string
+	Material string
+
+	Attributes  []Attribute
+	Stride      int
+	VertexCount int
+	Vertices    []byte
+	// Indices are triangles as little endian uint32s, see Index
+	Indices []byte
+
+	// Bounds of the positions
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+}
+
+// IndexCount is the number of indices, three per triangle
+func (m *Mesh) IndexCount() int {
+	return len(m.Indices) / 4
+}
+
+func (m *Mesh) Index(i int) uint32 {
+	return binary.LittleEndian.Uint32(m.Indices[i*4:])
+}
+
+// File is the meshes of a mesh file
+type File struct {
+	Meshes []Mesh
+
+	data  []byte
+	unmap func([]byte) error
+}
+
+// Close unmaps an opened file, its meshes' data can't be used afterwards
+func (f *File) Close() error {
+	if f.unmap == nil {
+		return nil
+	}
+	err := f.unmap(f.data)
+	f.data, f.unmap, f.Meshes = nil, nil, nil
+	return err
+}
+
+// Write writes meshes as a mesh file. The same meshes always make the same
+// bytes.
+func Write(w io.Writer, meshes []Mesh) error {
+	var header bytes.Buffer
+	header.Write(fileMagic)
+	put32 := func(v uint32) {
+		binary.Write(&header, binary.LittleEndian, v)
+	}
+	putString := func(s string) error {
+		if len(s) > math.MaxUint16 {
+			return fmt.Errorf("meshdata: name %.20q... is too long", s)
+		}
+		binary.Write(&header, binary.LittleEndian, uint16(len(s)))
+		header.WriteString(s)
+		return nil
+	}
+	put32(FILE_VERSION)
+	put32(uint32(len(meshes)))
+
+	// The data goes after the descriptions so their size is needed first
+	size := header.Len()
+	for _, m := range meshes {
+		size += 2 + len(m.Name) + 2 + len(m.Material) + 5*4 + 6*4 + 1
+		for _, a := range m.Attributes {
+			size += 2 + len(a.Name) + 4 + 1 + 4 + 1 + 2
+		}
+	}
+	offset := align16(size)
+
+	var offsets [][2]uint32
+	for _, m := range meshes {
+		if len(m.Vertices) != m.Stride*m.VertexCount {
+			return fmt.Errorf("meshdata: mesh %q has %d bytes of vertices "+
+				"for %d of %d bytes", m.Name, len(m.Vertices),
+				m.VertexCount, m.Stride)
+		}
+		if len(m.Attributes) > math.MaxUint8 {
+			return fmt.Errorf("meshdata: mesh %q has too many attributes",
+				m.Name)
+		}
+		vertexOffset := offset
+		indexOffset := align16(vertexOffset + len(m.Vertices))
+		offset = align16(indexOffset + len(m.Indices))
+		if uint64(offset) > math.MaxUint32 {
+			return errors.New("meshdata: mesh file is over 4GB")
+		}
+		offsets = append(offsets, [2]uint32{uint32(vertexOffset),
+			uint32(indexOffset)})
+
+		if err := putString(m.Name); err != nil {
+			return err
+		}
+		if err := putString(m.Material); err != nil {
+			return err
+		}
+		put32(uint32(m.Stride))
+		put32(uint32(m.VertexCount))
+		put32(uint32(m.IndexCount()))
+		put32(uint32(vertexOffset))
+		put32(uint32(indexOffset))
+		binary.Write(&header, binary.LittleEndian, m.Min)
+		binary.Write(&header, binary.LittleEndian, m.Max)
+
+		header.WriteByte(byte(len(m.Attributes)))
+		for _, a := range m.Attributes {
+			if err := putString(a.Name); err != nil {
+				return err
+			}
+			var flags byte
+			if a.Normalized {
+				flags |= normalizedFlag
+			}
+			if a.Integer {
+				flags |= integerFlag
+			}
+			put32(a.Location)
+			header.WriteByte(byte(a.Size))
+			put32(a.Type)
+			header.WriteByte(flags)
+			binary.Write(&header, binary.LittleEndian, uint16(a.Offset))
+		}
+	}
+
+	written := header.Len()
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	pad := func(to int) error {
+		_, err := w.Write(make([]byte, to-written))
+		written = to
+		return err
+	}
+	for i, m := range meshes {
+		if err := pad(int(offsets[i][0])); err != nil {
+			return err
+		}
+		if _, err := w.Write(m.Vertices); err != nil {
+			return err
+		}
+		written += len(m.Vertices)
+		if err := pad(int(offsets[i][1])); err != nil {
+			return err
+		}
+		if _, err := w.Write(m.Indices); err != nil {
+			return err
+		}
+		written += len(m.Indices)
+	}
+	return nil
+}
+
+// Decode reads the meshes of a mesh file, their data is left in data
+func Decode(data []byte) (*File, error) {
+	r := fileReader{data: data}
+	if !bytes.Equal(r.bytes(4), fileMagic) {
+		return nil, errors.New("meshdata: not a mesh file")
+	}
+	if version := r.uint32(); version != FILE_VERSION {
+		return nil, fmt.Errorf("meshdata: mesh file version %d isn't %d",
+			version, FILE_VERSION)
+	}
+
+	count := int(r.uint32())
+	if count > len(data) {
+		return nil, errors.New("meshdata: mesh file is cut short")
+	}
+	f := &File{data: data, Meshes: make([]Mesh, count)}
+	for i := range f.Meshes {
+		m := &f.Meshes[i]
+		m.Name, m.Material = r.string(), r.string()
+		m.Stride, m.VertexCount = int(r.uint32()), int(r.uint32())
+		indexCount := int(r.uint32())
+		vertexOffset, indexOffset := int(r.uint32()), int(r.uint32())
+		for _, bound := range []*mgl32.Vec3{&m.Min, &m.Max} {
+			for c := range bound {
+				bound[c] = math.Float32frombits(r.uint32())
+			}
+		}
+
+		m.Attributes = make([]Attribute, r.uint8())
+		for j := range m.Attributes {
+			a := &m.Attributes[j]
+			a.Name, a.Location = r.string(), r.uint32()
+			a.Size, a.Type = int32(r.uint8()), r.uint32()
+			flags := r.uint8()
+			a.Normalized = flags&normalizedFlag != 0
+			a.Integer = flags&integerFlag != 0
+			a.Offset = int(r.uint16())
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		var err error
+		m.Vertices, err = slice(data, vertexOffset, m.Stride*m.VertexCount)
+		if err != nil {
+			return nil, fmt.Errorf("meshdata: mesh %q vertices: %v", m.Name,
+				err)
+		}
+		m.Indices, err = slice(data, indexOffset, indexCount*4)
+		if err != nil {
+			return nil, fmt.Errorf("meshdata: mesh %q indices: %v", m.Name,
+				err)
+		}
+		for j := 0; j < indexCount; j++ {
+			if int(m.Index(j)) >= m.VertexCount {
+				return nil, fmt.Errorf("meshdata: mesh %q index %d is out "+
+					"of range", m.Name, j)
+			}
+		}
+	}
+	return f, nil
+}
+
+func slice(data []byte, offset, size int) ([]byte, error) {
+	if offset%16 != 0 {
+		return nil, errors.New("not aligned")
+	}
+	if offset < 0 || size < 0 || offset > len(data) ||
+		size > len(data)-offset {
+		return nil, errors.New("cut short")
+	}
+	return data[offset : offset+size : offset+size], nil
+}
+
+// fileReader reads the descriptions, after running off the end everything
+// is zero and err is set
+type fileReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *fileReader) bytes(n int) []byte {
+	if r.err != nil || r.offset+n > len(r.data) {
+		r.err = errors.New("meshdata: mesh file is cut short")
+		return make([]byte, n)
+	}
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *fileReader) uint8() uint8 {
+	return r.bytes(1)[0]
+}
+
+func (r *fileReader) uint16() uint16 {
+	return binary.LittleEndian.Uint16(r.bytes(2))
+}
+
+func (r *fileReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.bytes(4))
+}
+
+func (r *fileReader) string() string {
+	return string(r.bytes(int(r.uint16())))
+}
+
+func align16(n int) int {
+	return (n + 15) &^ 15
+}
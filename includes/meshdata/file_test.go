@@ -0,0 +1,150 @@
+package meshdata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func triangle(offset float32) []Vertex {
+	return []Vertex{
+		{Position: mgl32.Vec3{offset, 0, 0}, Normal: mgl32.Vec3{0, 0, 1}},
+		{Position: mgl32.Vec3{offset + 1, 0, 0}, Normal: mgl32.Vec3{0, 0, 1},
+			TexCoords: mgl32.Vec2{1, 0}},
+		{Position: mgl32.Vec3{offset, 2, -1}, Normal: mgl32.Vec3{0, 0, 1},
+			TexCoords: mgl32.Vec2{0, 1}},
+	}
+}
+
+// testMeshes have vertices and indices that aren't multiples of 16 bytes,
+// so each one after the first needs padding
+func testMeshes() []Mesh {
+	return []Mesh{
+		Pack("first", "stone", triangle(0), []uint32{0, 1, 2}),
+		Pack("second", "", triangle(5),
+			[]uint32{0, 1, 2, 2, 1, 0, 0, 2, 1}),
+		Pack("", "wood", triangle(-3), []uint32{2, 0, 1}),
+	}
+}
+
+func writeMeshes(t *testing.T, meshes []Mesh) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := Write(&b, meshes); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// offsetIn is where part starts in data
+func offsetIn(data, part []byte) int {
+	return int(reflect.ValueOf(part).Pointer() -
+		reflect.ValueOf(data).Pointer())
+}
+
+func TestWriteDecode(t *testing.T) {
+	meshes := testMeshes()
+	data := writeMeshes(t, meshes)
+	if again := writeMeshes(t, meshes); !bytes.Equal(data, again) {
+		t.Errorf("writing the same meshes gave different bytes")
+	}
+
+	f, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Meshes) != len(meshes) {
+		t.Fatalf("%d meshes, want %d", len(f.Meshes), len(meshes))
+	}
+	for i, got := range f.Meshes {
+		want := meshes[i]
+		if got.Name != want.Name || got.Material != want.Material ||
+			got.Stride != want.Stride ||
+			got.VertexCount != want.VertexCount || got.Min != want.Min ||
+			got.Max != want.Max {
+			t.Errorf("mesh %d is %+v, want %+v", i, got, want)
+		}
+		if !reflect.DeepEqual(got.Attributes, want.Attributes) {
+			t.Errorf("mesh %d attributes %+v, want %+v", i, got.Attributes,
+				want.Attributes)
+		}
+		if !bytes.Equal(got.Vertices, want.Vertices) ||
+			!bytes.Equal(got.Indices, want.Indices) {
+			t.Errorf("mesh %d data doesn't match", i)
+		}
+
+		// The data is left in place, aligned for mapping
+		for _, part := range [][]byte{got.Vertices, got.Indices} {
+			if offset := offsetIn(data, part); offset%16 != 0 {
+				t.Errorf("mesh %d data at %d isn't 16 byte aligned", i,
+					offset)
+			}
+		}
+	}
+}
+
+func TestDecodeBroken(t *testing.T) {
+	meshes := testMeshes()
+	data := writeMeshes(t, meshes)
+	// The first description starts after the magic, version and count
+	first := 12
+	// Its counts and offsets come after its name and material
+	counts := first + 2 + len("first") + 2 + len("stone")
+	vertexOffset, indexOffset := counts+12, counts+16
+
+	edit := func(change func(data []byte)) []byte {
+		data := append([]byte(nil), data...)
+		change(data)
+		return data
+	}
+	put32 := func(offset int, v uint32) func([]byte) {
+		return func(data []byte) {
+			binary.LittleEndian.PutUint32(data[offset:], v)
+		}
+	}
+
+	outOfRange := testMeshes()
+	outOfRange[1] = Pack("bad", "", triangle(0), []uint32{0, 1, 3})
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not a mesh file", []byte("MASH"), "not a mesh file"},
+		{"wrong version", edit(put32(4, FILE_VERSION+1)), "version"},
+		{"description cut short", data[:counts], "cut short"},
+		{"data cut short", data[:len(data)-1], "cut short"},
+		{"huge count", edit(put32(8, 0xffffffff)), "cut short"},
+		{"vertices not aligned", edit(func(data []byte) {
+			v := binary.LittleEndian.Uint32(data[vertexOffset:])
+			put32(vertexOffset, v+4)(data)
+		}), "not aligned"},
+		{"indices not aligned", edit(func(data []byte) {
+			i := binary.LittleEndian.Uint32(data[indexOffset:])
+			put32(indexOffset, i+4)(data)
+		}), "not aligned"},
+		{"vertices past the end", edit(put32(vertexOffset,
+			uint32(align16(len(data))+16))), "cut short"},
+		{"huge vertex count", edit(put32(counts+4, 0x7fffffff)),
+			"cut short"},
+		{"huge stride and count", edit(func(data []byte) {
+			put32(counts, 0xffffffff)(data)
+			put32(counts+4, 0x7fffffff)(data)
+		}), "cut short"},
+		{"index out of range", writeMeshes(t, outOfRange), "out of range"},
+	}
+	for _, test := range tests {
+		_, err := Decode(test.data)
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+		} else if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: error %q, want it to say %q", test.name, err,
+				test.want)
+		}
+	}
+}
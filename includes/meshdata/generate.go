@@ -0,0 +1,80 @@
+package meshdata
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ComputeNormals gives each vertex the average normal of the triangles
+// around it, weighted by area. Vertices in the same place share a normal
+// so seams in the texture coordinates don't show.
+func ComputeNormals(vertices []Vertex, indices []uint32) {
+	sums := map[mgl32.Vec3]mgl32.Vec3{}
+	for i := 0; i+2 < len(indices); i += 3 {
+		p0 := vertices[indices[i]].Position
+		p1 := vertices[indices[i+1]].Position
+		p2 := vertices[indices[i+2]].Position
+
+		// Not normalized so bigger triangles count for more
+		n := p1.Sub(p0).Cross(p2.Sub(p0))
+		for _, p := range []mgl32.Vec3{p0, p1, p2} {
+			sums[p] = sums[p].Add(n)
+		}
+	}
+
+	for i := range vertices {
+		n := sums[vertices[i].Position]
+		if n.Len() > 0 {
+			vertices[i].Normal = n.Normalize()
+		}
+	}
+}
+
+// ComputeTangents sets the tangent and bitangent of each vertex from its
+// normal and texture coordinates. Tangents are made perpendicular to the
+// normal and the bitangent keeps the handedness of the texture mapping.
+func ComputeTangents(vertices []Vertex, indices []uint32) {
+	tangents := make([]mgl32.Vec3, len(vertices))
+	bitangents := make([]mgl32.Vec3, len(vertices))
+
+	for i := 0; i+2 < len(indices); i += 3 {
+		i0, i1, i2 := indices[i], indices[i+1], indices[i+2]
+		v0, v1, v2 := vertices[i0], vertices[i1], vertices[i2]
+
+		edge1 := v1.Position.Sub(v0.Position)
+		edge2 := v2.Position.Sub(v0.Position)
+		deltaUV1 := v1.TexCoords.Sub(v0.TexCoords)
+		deltaUV2 := v2.TexCoords.Sub(v0.TexCoords)
+
+		det := deltaUV1[0]*deltaUV2[1] - deltaUV2[0]*deltaUV1[1]
+		if det == 0 {
+			continue
+		}
+		f := 1.0 / det
+
+		tangent := edge1.Mul(deltaUV2[1]).Sub(edge2.Mul(deltaUV1[1])).Mul(f)
+		bitangent := edge2.Mul(deltaUV1[0]).Sub(edge1.Mul(deltaUV2[0])).Mul(f)
+		for _, j := range []uint32{i0, i1, i2} {
+			tangents[j] = tangents[j].Add(tangent)
+			bitangents[j] = bitangents[j].Add(bitangent)
+		}
+	}
+
+	for i := range vertices {
+		n := vertices[i].Normal
+		t := tangents[i]
+
+		// Gram-Schmidt
+		t = t.Sub(n.Mul(n.Dot(t)))
+		if t.Len() == 0 {
+			continue
+		}
+		t = t.Normalize()
+
+		b := n.Cross(t)
+		if b.Dot(bitangents[i]) < 0 {
+			b = b.Mul(-1.0)
+		}
+		vertices[i].Tangent = t
+		vertices[i].Bitangent = b
+	}
+}
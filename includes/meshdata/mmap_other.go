@@ -0,0 +1,23 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package meshdata
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+// Open reads a mesh file, there's no mapping on this platform so it's all
+// read in
+func Open(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%v in %s", err, path)
+	}
+	return f, nil
+}
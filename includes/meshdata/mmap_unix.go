@@ -0,0 +1,41 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package meshdata
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Open maps a mesh file into memory, Close it once its meshes have been
+// uploaded
+func Open(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("meshdata: %s is empty", path)
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(info.Size()),
+		syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("meshdata: mapping %s: %v", path, err)
+	}
+
+	f, err := Decode(data)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, fmt.Errorf("%v in %s", err, path)
+	}
+	f.unmap = syscall.Munmap
+	return f, nil
+}
@@ -0,0 +1,236 @@
+package meshdata
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The geometry of Wavefront OBJ files, read a line at a time so only the
+// vertex data is ever held in memory, not the text. Faces are split by
+// object and material. Texture coordinates are flipped like
+// aiProcess_FlipUVs so a model looks the same whichever loader read it.
+
+// OBJGroup is the faces of one object with one material
+type OBJGroup struct {
+	Object   string
+	Material string
+	Vertices []Vertex
+	Indices  []uint32
+	// Set when any corner didn't come with a normal, see ComputeNormals
+	MissingNormals bool
+
+	lookup map[objKey]uint32
+	// Which vertices came without a normal
+	missing []bool
+}
+
+// ComputeNormals makes normals for the vertices that came without one,
+// the normals in the file are kept
+func (g *OBJGroup) ComputeNormals() {
+	if !g.MissingNormals {
+		return
+	}
+	made := append([]Vertex(nil), g.Vertices...)
+	ComputeNormals(made, g.Indices)
+	for i, missing := range g.missing {
+		if missing {
+			g.Vertices[i].Normal = made[i].Normal
+		}
+	}
+}
+
+// objKey is a corner of a face, -1 where it has no texture coordinate or
+// normal
+type objKey struct {
+	position, texCoord, normal int
+}
+
+type objParser struct {
+	positions []mgl32.Vec3
+	texCoords []mgl32.Vec2
+	normals   []mgl32.Vec3
+
+	groups   []*OBJGroup
+	object   string
+	material string
+	current  *OBJGroup
+}
+
+// ReadOBJ reads the faces of an OBJ file as triangles. mtllib is called
+// with each material library named as it's reached, it can be nil.
+func ReadOBJ(r io.Reader, mtllib func(name string) error) ([]*OBJGroup,
+	error) {
+
+	p := objParser{}
+	scanner := bufio.NewScanner(r)
+	// Faces with lots of corners make long lines
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "v":
+			var v []float32
+			if v, err = ParseFloats(fields[1:], 3); err == nil {
+				p.positions = append(p.positions, mgl32.Vec3{v[0], v[1], v[2]})
+			}
+		case "vt":
+			var v []float32
+			if v, err = ParseFloats(fields[1:], 1); err == nil {
+				uv := mgl32.Vec2{v[0], 0.0}
+				if len(v) > 1 {
+					uv[1] = v[1]
+				}
+				// Like aiProcess_FlipUVs
+				uv[1] = 1.0 - uv[1]
+				p.texCoords = append(p.texCoords, uv)
+			}
+		case "vn":
+			var v []float32
+			if v, err = ParseFloats(fields[1:], 3); err == nil {
+				p.normals = append(p.normals, mgl32.Vec3{v[0], v[1], v[2]})
+			}
+		case "f":
+			err = p.face(fields[1:])
+		case "o", "g":
+			p.object = strings.Join(fields[1:], " ")
+			p.current = nil
+		case "usemtl":
+			p.material = strings.Join(fields[1:], " ")
+			p.current = nil
+		case "mtllib":
+			if mtllib == nil {
+				break
+			}
+			for _, name := range fields[1:] {
+				if err = mtllib(name); err != nil {
+					break
+				}
+			}
+		}
+		// Lines, points, smoothing groups and free form geometry are
+		// ignored
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, g := range p.groups {
+		g.lookup = nil
+	}
+	return p.groups, nil
+}
+
+// face adds a polygon as a fan of triangles
+func (p *objParser) face(corners []string) error {
+	if len(corners) < 3 {
+		return fmt.Errorf("face has %d corners", len(corners))
+	}
+	if p.current == nil {
+		p.current = &OBJGroup{Object: p.object, Material: p.material,
+			lookup: map[objKey]uint32{}}
+		p.groups = append(p.groups, p.current)
+	}
+	g := p.current
+
+	indices := make([]uint32, len(corners))
+	for i, corner := range corners {
+		key, err := p.parseCorner(corner)
+		if err != nil {
+			return err
+		}
+
+		// Corners that are the same in every way share a vertex
+		index, ok := g.lookup[key]
+		if !ok {
+			var v Vertex
+			v.Position = p.positions[key.position]
+			if key.texCoord >= 0 {
+				v.TexCoords = p.texCoords[key.texCoord]
+			}
+			if key.normal >= 0 {
+				v.Normal = p.normals[key.normal]
+			} else {
+				g.MissingNormals = true
+			}
+
+			index = uint32(len(g.Vertices))
+			g.Vertices = append(g.Vertices, v)
+			g.missing = append(g.missing, key.normal < 0)
+			g.lookup[key] = index
+		}
+		indices[i] = index
+	}
+
+	for i := 2; i < len(indices); i++ {
+		g.Indices = append(g.Indices, indices[0], indices[i-1], indices[i])
+	}
+	return nil
+}
+
+// parseCorner reads v, v/vt, v//vn or v/vt/vn. Negative indices count back
+// from the last element read.
+func (p *objParser) parseCorner(corner string) (objKey, error) {
+	key := objKey{-1, -1, -1}
+	parts := strings.Split(corner, "/")
+	if len(parts) > 3 {
+		return key, fmt.Errorf("bad face corner %q", corner)
+	}
+
+	counts := []int{len(p.positions), len(p.texCoords), len(p.normals)}
+	out := []*int{&key.position, &key.texCoord, &key.normal}
+	for i, part := range parts {
+		if part == "" {
+			if i == 0 {
+				return key, fmt.Errorf("bad face corner %q", corner)
+			}
+			continue
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return key, fmt.Errorf("bad face corner %q", corner)
+		}
+		if n < 0 {
+			n += counts[i]
+		} else {
+			n--
+		}
+		if n < 0 || n >= counts[i] {
+			return key, fmt.Errorf("face corner %q out of range", corner)
+		}
+		*out[i] = n
+	}
+	return key, nil
+}
+
+// ParseFloats parses the numbers of an OBJ or MTL statement, there have to
+// be at least min of them
+func ParseFloats(fields []string, min int) ([]float32, error) {
+	if len(fields) < min {
+		return nil, fmt.Errorf("expected %d numbers, got %d", min, len(fields))
+	}
+	v := make([]float32, len(fields))
+	for i, f := range fields {
+		x, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = float32(x)
+	}
+	return v, nil
+}
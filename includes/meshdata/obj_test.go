@@ -0,0 +1,300 @@
+package meshdata
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const objects = "../../resources/objects"
+
+// The shipped models, vertices is how many different v/vt/vn corners
+// their faces use
+var shippedOBJs = []struct {
+	path     string
+	mtllib   string
+	object   string
+	material string
+	faces    int
+	vertices int
+	optional bool
+}{
+	{"rock/rock.obj", "rock.mtl", "Cube", "Material", 192, 165, false},
+	{"planet/planet.obj", "planet.mtl", "Mars_Cube.002", "Mars", 768, 441,
+		false},
+	// Too big to keep in the repository, it's tested when it's been
+	// downloaded
+	{"backpack/backpack.obj", "backpack.mtl", "", "", -1, -1, true},
+}
+
+func readShipped(t *testing.T, path string, optional bool) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join(objects, path))
+	if os.IsNotExist(err) && optional {
+		t.Skipf("%s isn't in resources", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func finite(v ...float32) bool {
+	for _, x := range v {
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isUnit(v mgl32.Vec3) bool {
+	return math.Abs(float64(v.Len())-1.0) < 1e-3
+}
+
+func TestReadShippedOBJs(t *testing.T) {
+	for _, test := range shippedOBJs {
+		t.Run(filepath.Dir(test.path), func(t *testing.T) {
+			data := readShipped(t, test.path, test.optional)
+
+			var libs []string
+			groups, err := ReadOBJ(bytes.NewReader(data),
+				func(name string) error {
+					libs = append(libs, name)
+					return nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(libs) != 1 || libs[0] != test.mtllib {
+				t.Errorf("material libraries %v, want %s", libs,
+					test.mtllib)
+			}
+			if test.faces < 0 {
+				// Only the general checks for models that may change
+				checkGroups(t, groups)
+				return
+			}
+
+			if len(groups) != 1 {
+				t.Fatalf("%d groups, want 1", len(groups))
+			}
+			g := groups[0]
+			if g.Object != test.object || g.Material != test.material {
+				t.Errorf("group is %q with %q, want %q with %q", g.Object,
+					g.Material, test.object, test.material)
+			}
+			if len(g.Indices) != test.faces*3 {
+				t.Errorf("%d indices, want %d", len(g.Indices),
+					test.faces*3)
+			}
+			// Corners shared between faces are one vertex
+			if len(g.Vertices) != test.vertices {
+				t.Errorf("%d vertices, want %d", len(g.Vertices),
+					test.vertices)
+			}
+			if g.MissingNormals {
+				t.Errorf("normals were in the file")
+			}
+			checkGroups(t, groups)
+		})
+	}
+}
+
+// checkGroups checks indices are in range, no vertex is repeated and
+// that the normals and tangents made for the groups are usable
+func checkGroups(t *testing.T, groups []*OBJGroup) {
+	t.Helper()
+	for _, g := range groups {
+		if len(g.Indices)%3 != 0 {
+			t.Errorf("%s: %d indices isn't whole triangles", g.Object,
+				len(g.Indices))
+		}
+		for _, i := range g.Indices {
+			if int(i) >= len(g.Vertices) {
+				t.Fatalf("%s: index %d out of range", g.Object, i)
+			}
+		}
+		seen := map[Vertex]bool{}
+		for _, v := range g.Vertices {
+			if seen[v] {
+				t.Errorf("%s: vertex %+v is repeated", g.Object, v)
+				break
+			}
+			seen[v] = true
+		}
+
+		g.ComputeNormals()
+		ComputeTangents(g.Vertices, g.Indices)
+		for i, v := range g.Vertices {
+			if !isUnit(v.Normal) {
+				t.Errorf("%s: vertex %d normal %v isn't unit length",
+					g.Object, i, v.Normal)
+				break
+			}
+			if !finite(v.Tangent[:]...) || !finite(v.Bitangent[:]...) {
+				t.Errorf("%s: vertex %d tangents %v %v aren't finite",
+					g.Object, i, v.Tangent, v.Bitangent)
+				break
+			}
+		}
+	}
+}
+
+func TestReadOBJMissingNormals(t *testing.T) {
+	// The rock without its normals, each face corner loses its /vn
+	data := readShipped(t, "rock/rock.obj", false)
+	data = regexp.MustCompile(`(?m)^vn .*\n`).ReplaceAll(data, nil)
+	data = regexp.MustCompile(`(\d+/\d+)/\d+`).ReplaceAll(data,
+		[]byte("$1"))
+
+	groups, err := ReadOBJ(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || !groups[0].MissingNormals {
+		t.Fatalf("normals weren't reported missing")
+	}
+	if n := len(groups[0].Vertices); n != 165 {
+		t.Errorf("%d vertices, want 165", n)
+	}
+	for _, v := range groups[0].Vertices {
+		if v.Normal != (mgl32.Vec3{}) {
+			t.Fatalf("vertex has normal %v before they're made", v.Normal)
+		}
+	}
+	checkGroups(t, groups)
+}
+
+func TestReadOBJMixedNormals(t *testing.T) {
+	// The file's normal points up the Y axis, not out of the faces, so
+	// it's clear which normals were kept
+	obj := `v 0 0 0
+v 1 0 0
+v 1 1 0
+v 2 0 0
+vn 0 1 0
+f 1//1 2//1 3//1
+f 2 4 3
+`
+	groups, err := ReadOBJ(strings.NewReader(obj), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || !groups[0].MissingNormals {
+		t.Fatalf("normals weren't reported missing")
+	}
+	g := groups[0]
+	g.ComputeNormals()
+
+	want := []mgl32.Vec3{{0, 1, 0}, {0, 1, 0}, {0, 1, 0},
+		{0, 0, 1}, {0, 0, 1}, {0, 0, 1}}
+	if len(g.Vertices) != len(want) {
+		t.Fatalf("%d vertices, want %d", len(g.Vertices), len(want))
+	}
+	for i, v := range g.Vertices {
+		if !v.Normal.ApproxEqual(want[i]) {
+			t.Errorf("vertex %d normal %v, want %v", i, v.Normal, want[i])
+		}
+	}
+}
+
+func TestReadOBJPolygons(t *testing.T) {
+	obj := `# a pentagon and a quad
+v 0 0 0
+v 1 0 0
+v 2 1 0
+v 1 2 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vn 0 0 1
+o shape
+usemtl a
+f 1 2 3 4 5
+usemtl b
+f -5/1/1 -4/2/1 -3/3/1 -2/3/1
+`
+	groups, err := ReadOBJ(strings.NewReader(obj), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("%d groups, want one per material", len(groups))
+	}
+
+	// Fanned from the first corner
+	pentagon := groups[0]
+	want := []uint32{0, 1, 2, 0, 2, 3, 0, 3, 4}
+	if !reflect.DeepEqual(pentagon.Indices, want) {
+		t.Errorf("pentagon indices %v, want %v", pentagon.Indices, want)
+	}
+	if len(pentagon.Vertices) != 5 || !pentagon.MissingNormals {
+		t.Errorf("pentagon has %d vertices, missing normals %t",
+			len(pentagon.Vertices), pentagon.MissingNormals)
+	}
+
+	quad := groups[1]
+	if quad.Object != "shape" || quad.Material != "b" {
+		t.Errorf("quad is %q with %q", quad.Object, quad.Material)
+	}
+	want = []uint32{0, 1, 2, 0, 2, 3}
+	if !reflect.DeepEqual(quad.Indices, want) {
+		t.Errorf("quad indices %v, want %v", quad.Indices, want)
+	}
+	// Negative indices count back and texture coordinates are flipped
+	if v := quad.Vertices[2]; v.Position != (mgl32.Vec3{2, 1, 0}) ||
+		v.TexCoords != (mgl32.Vec2{1, 0}) {
+		t.Errorf("third corner is %+v", v)
+	}
+}
+
+func TestReadOBJErrors(t *testing.T) {
+	tests := []string{
+		"v 0 0 0\nv 1 0 0\nf 1 2\n",
+		"v 0 0 0\nf 1 2 3\n",
+		"v 0 0 0\nf 1/2 1 1\n",
+		"v 0 0\n",
+		"v 0 0 x\n",
+		"v 0 0 0\nf 1/1/1/1 1 1\n",
+	}
+	for _, obj := range tests {
+		if _, err := ReadOBJ(strings.NewReader(obj), nil); err == nil {
+			t.Errorf("no error for %q", obj)
+		}
+	}
+}
+
+func TestReadOBJStreamed(t *testing.T) {
+	// Reading a byte at a time gives the same as reading it all at once
+	data := readShipped(t, "planet/planet.obj", false)
+	whole, err := ReadOBJ(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := ReadOBJ(iotest.OneByteReader(bytes.NewReader(data)),
+		nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(whole, streamed) {
+		t.Errorf("streaming the OBJ gave different groups")
+	}
+
+	// Errors from the reader are passed on
+	_, err = ReadOBJ(iotest.TimeoutReader(iotest.OneByteReader(
+		bytes.NewReader(data))), nil)
+	if err != iotest.ErrTimeout {
+		t.Errorf("reader error came back as %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package meshdata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// PackedAttributes is the layout Pack uses, at the same locations as
+// mesh.DefaultFormat. Unit vectors are normalized shorts which is plenty
+// for lighting and takes 44 bytes a vertex instead of 56.
+var PackedAttributes = []Attribute{
+	{Name: "aPos", Location: 0, Size: 3, Type: FLOAT, Offset: 0},
+	{Name: "aNormal", Location: 1, Size: 3, Type: SHORT, Normalized: true,
+		Offset: 12},
+	{Name: "aTexCoords", Location: 2, Size: 2, Type: FLOAT, Offset: 20},
+	{Name: "aTangent", Location: 3, Size: 3, Type: SHORT, Normalized: true,
+		Offset: 28},
+	{Name: "aBitangent", Location: 4, Size: 3, Type: SHORT,
+		Normalized: true, Offset: 36},
+}
+
+const packedStride = 44
+
+// Pack lays out vertices in PackedAttributes for a mesh file. Bones aren't
+// kept, skinned meshes should stay in the formats that have animations.
+func Pack(name, material string, vertices []Vertex,
+	indices []uint32) Mesh {
+
+	m := Mesh{Name: name, Material: material,
+		Attributes: PackedAttributes, Stride: packedStride,
+		VertexCount: len(vertices)}
+
+	var buf bytes.Buffer
+	buf.Grow(len(vertices) * packedStride)
+	put := func(v interface{}) {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	putUnit := func(v mgl32.Vec3) {
+		var shorts [4]int16
+		for i := 0; i < 3; i++ {
+			shorts[i] = int16(math.Round(float64(mgl32.Clamp(v[i], -1.0,
+				1.0)) * math.MaxInt16))
+		}
+		// The fourth is padding to 4 bytes
+		put(shorts)
+	}
+	for i, v := range vertices {
+		put(v.Position)
+		putUnit(v.Normal)
+		put(v.TexCoords)
+		putUnit(v.Tangent)
+		putUnit(v.Bitangent)
+
+		if i == 0 {
+			m.Min, m.Max = v.Position, v.Position
+		}
+		for c := 0; c < 3; c++ {
+			if v.Position[c] < m.Min[c] {
+				m.Min[c] = v.Position[c]
+			}
+			if v.Position[c] > m.Max[c] {
+				m.Max[c] = v.Position[c]
+			}
+		}
+	}
+	m.Vertices = buf.Bytes()
+
+	m.Indices = make([]byte, len(indices)*4)
+	for i, index := range indices {
+		binary.LittleEndian.PutUint32(m.Indices[i*4:], index)
+	}
+	return m
+}
@@ -0,0 +1,51 @@
+// Package meshdata is meshes on the CPU: vertices, the geometry of OBJ
+// files and the binary mesh files cmd/assetc writes. It has no cgo so tools
+// can use it without a GPU, the mesh package uploads what it makes.
+package meshdata
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Most bones that can move a single vertex
+const MAX_BONE_INFLUENCE = 4
+
+// Vertex attributes are at locations 0 to 6 in field order, see
+// mesh.DefaultFormat. Vertices not moved by any bones have all zero weights.
+type Vertex struct {
+	Position  mgl32.Vec3
+	Normal    mgl32.Vec3
+	TexCoords mgl32.Vec2
+	Tangent   mgl32.Vec3
+	Bitangent mgl32.Vec3
+	BoneIDs   [MAX_BONE_INFLUENCE]int32
+	Weights   [MAX_BONE_INFLUENCE]float32
+}
+
+// AddBoneWeight adds a bone influence to the vertex. Past
+// MAX_BONE_INFLUENCE the smallest weight is dropped, call NormalizeWeights
+// afterwards so they sum to one again.
+func (v *Vertex) AddBoneWeight(boneID int32, weight float32) {
+	smallest := 0
+	for i := 1; i < MAX_BONE_INFLUENCE; i++ {
+		if v.Weights[i] < v.Weights[smallest] {
+			smallest = i
+		}
+	}
+	if weight > v.Weights[smallest] {
+		v.BoneIDs[smallest] = boneID
+		v.Weights[smallest] = weight
+	}
+}
+
+func (v *Vertex) NormalizeWeights() {
+	var sum float32
+	for _, w := range v.Weights {
+		sum += w
+	}
+	if sum > 0 {
+		for i := range v.Weights {
+			v.Weights[i] /= sum
+		}
+	}
+}
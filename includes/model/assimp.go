@@ -0,0 +1,274 @@
+//go:build !noassimp
+// +build !noassimp
+
+// used a lot of cgo stuff from
+// https://github.com/tbogdala/assimp-go/blob/master/assimp.go
+
+package model
+
+/*
+#cgo linux pkg-config: assimp
+#cgo darwin pkg-config: assimp
+
+#cgo windows CPPFLAGS: -I/mingw64/include -std=c99
+#cgo windows LDFLAGS: -L/mingw64/lib -lassimp -lz -lstdc++
+
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include <assimp/cimport.h>
+#include <assimp/scene.h>
+#include <assimp/mesh.h>
+#include <assimp/cimport.h>
+#include <assimp/matrix4x4.h>
+#include <assimp/postprocess.h>
+
+struct aiNode* get_child(struct aiNode* n, unsigned int index)
+{
+	return n->mChildren[index];
+}
+
+unsigned int get_mesh_index(struct aiNode* n, unsigned int index)
+{
+	return n->mMeshes[index];
+}
+
+struct aiMesh* get_scene_mesh(struct aiScene* s, unsigned int index)
+{
+	return s->mMeshes[index];
+}
+
+struct aiVector3D* mesh_vertex_at(struct aiMesh* m, unsigned int index)
+{
+	return &(m->mVertices[index]);
+}
+
+_Bool has_normals(struct aiMesh* m) {
+	return m->mNormals;
+}
+
+_Bool has_tangents(struct aiMesh* m) {
+	return m->mTangents && m->mBitangents;
+}
+
+struct aiVector3D* mesh_normal_at(struct aiMesh* m, unsigned int index)
+{
+	return &(m->mNormals[index]);
+}
+
+_Bool has_tex_coords(struct aiMesh* m) {
+	return m->mTextureCoords[0];
+}
+
+struct aiVector3D* mesh_texture_at(struct aiMesh* m, unsigned int index)
+{
+	return &(m->mTextureCoords[0][index]);
+}
+
+struct aiVector3D* mesh_tangent_at(struct aiMesh* m, unsigned int index)
+{
+	return &(m->mTangents[index]);
+}
+
+struct aiVector3D* mesh_bitangent_at(struct aiMesh* m, unsigned int index)
+{
+	return &(m->mBitangents[index]);
+}
+
+struct aiFace* get_face(struct aiMesh* m, unsigned int index)
+{
+	return &(m->mFaces[index]);
+}
+
+unsigned int get_face_indices(struct aiFace* f, unsigned int index)
+{
+	return f->mIndices[index];
+}
+
+struct aiMaterial* get_material(struct aiScene* s, struct aiMesh* m)
+{
+	return s->mMaterials[m->mMaterialIndex];
+}
+
+//int get_num_textures(struct aiMaterial* mat, enum aiTextureType type) {
+//	return mat->GetTextureCount(type);
+//}
+*/
+import "C"
+
+import (
+	"unsafe"
+	//"math"
+	//"strconv"
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
+)
+
+const haveAssimp = true
+
+// loadAssimp imports everything but glTF with assimp
+func (model *Model) loadAssimp(path string) error {
+	cPathString := C.CString(path)
+	defer C.free(unsafe.Pointer(cPathString))
+
+	// Smooth normals are only generated for meshes that don't have any
+	scene := C.aiImportFile(cPathString,
+		C.aiProcess_Triangulate|
+			C.aiProcess_FlipUVs|
+			C.aiProcess_GenSmoothNormals|
+			C.aiProcess_CalcTangentSpace)
+
+	// Make sure we loaded meshes properly
+	if uintptr(unsafe.Pointer(scene)) == 0 {
+		return &LoadError{Path: path, Mesh: -1, Reason: "import failed",
+			Assimp: C.GoString(C.aiGetErrorString())}
+	}
+	defer C.aiReleaseImport(scene)
+
+	if scene.mFlags&C.AI_SCENE_FLAGS_INCOMPLETE != 0 {
+		return &LoadError{Path: path, Mesh: -1, Reason: "scene is incomplete",
+			Assimp: C.GoString(C.aiGetErrorString())}
+	}
+	if scene.mNumMeshes < 1 {
+		return &LoadError{Path: path, Mesh: -1, Reason: "scene has no meshes"}
+	}
+	if uintptr(unsafe.Pointer(scene.mRootNode)) == 0 {
+		return &LoadError{Path: path, Mesh: -1, Reason: "scene has no root node"}
+	}
+
+	// Meshes are loaded once in scene order and shared between the nodes
+	// that use them
+	for i := 0; i < int(scene.mNumMeshes); i++ {
+		m, err := model.processMesh(C.get_scene_mesh(scene, C.uint(i)), scene)
+		if err != nil {
+			return &LoadError{Path: path, Mesh: i, Reason: err.Error()}
+		}
+		model.Meshes = append(model.Meshes, m)
+	}
+
+	model.Root = model.processNode(scene.mRootNode, nil)
+	model.processAnimations(scene)
+	if err := model.buildSkeleton(); err != nil {
+		return &LoadError{Path: path, Mesh: -1, Reason: err.Error()}
+	}
+	return nil
+}
+
+func (model *Model) processNode(aiNode *C.struct_aiNode, parent *Node) *Node {
+	node := NewNode(goString(&aiNode.mName),
+		convertMatrix(&aiNode.mTransformation), parent)
+
+	for i := 0; i < int(aiNode.mNumMeshes); i++ {
+		node.Meshes = append(node.Meshes,
+			int(C.get_mesh_index(aiNode, C.uint(i))))
+	}
+	// Call process node on all the children nodes
+	for i := 0; i < int(aiNode.mNumChildren); i++ {
+		model.processNode(C.get_child(aiNode, C.uint(i)), node)
+	}
+	return node
+}
+
+// convertMatrix turns assimp's row major matrix into a column major one
+func convertMatrix(m *C.struct_aiMatrix4x4) mgl32.Mat4 {
+	return mgl32.Mat4{
+		float32(m.a1), float32(m.b1), float32(m.c1), float32(m.d1),
+		float32(m.a2), float32(m.b2), float32(m.c2), float32(m.d2),
+		float32(m.a3), float32(m.b3), float32(m.c3), float32(m.d3),
+		float32(m.a4), float32(m.b4), float32(m.c4), float32(m.d4)}
+}
+
+func (model *Model) processMesh(aiMesh *C.struct_aiMesh,
+	aiScene *C.struct_aiScene) (*mesh.Mesh, error) {
+
+	numVertices := int(aiMesh.mNumVertices)
+	if numVertices == 0 || uintptr(unsafe.Pointer(aiMesh.mVertices)) == 0 {
+		return nil, errors.New("mesh has no vertices")
+	}
+	// Point clouds and lines don't get normals or tangents
+	hasNormals := bool(C.has_normals(aiMesh))
+	hasTangents := bool(C.has_tangents(aiMesh))
+	hasTexCoords := bool(C.has_tex_coords(aiMesh))
+
+	// Data to fill
+	var vertices []mesh.Vertex
+	var indices []uint32
+	var textures []mesh.Texture
+
+	// Loop through all of the mesh's vertices
+	for i := 0; i < numVertices; i++ {
+		var vertex mesh.Vertex
+
+		// Position
+		cVec := C.mesh_vertex_at(aiMesh, C.uint(i))
+		vertex.Position[0] = float32(cVec.x)
+		vertex.Position[1] = float32(cVec.y)
+		vertex.Position[2] = float32(cVec.z)
+
+		// Normals
+		if hasNormals {
+			cVec = C.mesh_normal_at(aiMesh, C.uint(i))
+			vertex.Normal[0] = float32(cVec.x)
+			vertex.Normal[1] = float32(cVec.y)
+			vertex.Normal[2] = float32(cVec.z)
+		}
+
+		// Texture coords (assuming we only use the first uv channel)
+		if hasTexCoords {
+			cVec = C.mesh_texture_at(aiMesh, C.uint(i))
+			vertex.TexCoords[0] = float32(cVec.x)
+			vertex.TexCoords[1] = float32(cVec.y)
+		} // No need for else when mgl vecs are inited to 0
+
+		// Tangent and bitangent, these need normals and texture coords
+		if hasTangents {
+			cVec = C.mesh_tangent_at(aiMesh, C.uint(i))
+			vertex.Tangent[0] = float32(cVec.x)
+			vertex.Tangent[1] = float32(cVec.y)
+			vertex.Tangent[2] = float32(cVec.z)
+
+			cVec = C.mesh_bitangent_at(aiMesh, C.uint(i))
+			vertex.Bitangent[0] = float32(cVec.x)
+			vertex.Bitangent[1] = float32(cVec.y)
+			vertex.Bitangent[2] = float32(cVec.z)
+		}
+
+		vertices = append(vertices, vertex)
+	}
+
+	if err := model.processBones(aiMesh, vertices); err != nil {
+		return nil, err
+	}
+
+	// Now handle all the mesh's faces abd retrieve corresponding vertex indices.
+	for i := 0; i < int(aiMesh.mNumFaces); i++ {
+		face := C.get_face(aiMesh, C.uint(i))
+
+		for j := 0; j < int(face.mNumIndices); j++ {
+			index := uint32(C.get_face_indices(face, C.uint(j)))
+			if int(index) >= numVertices {
+				return nil, fmt.Errorf("face %d index %d out of range of %d "+
+					"vertices", i, index, numVertices)
+			}
+			indices = append(indices, index)
+		}
+	}
+
+	// Process materials
+	material, err := model.processMaterial(C.get_material(aiScene, aiMesh))
+	if err != nil {
+		return nil, err
+	}
+	for _, slot := range material.Textures {
+		textures = append(textures, slot.Texture)
+	}
+
+	m := mesh.NewMesh(vertices, indices, textures)
+	m.Material = material
+	return m, nil
+}
@@ -0,0 +1,143 @@
+//go:build !noassimp
+// +build !noassimp
+
+package model
+
+/*
+#include <assimp/scene.h>
+#include <assimp/mesh.h>
+#include <assimp/anim.h>
+
+struct aiBone* get_bone(struct aiMesh* m, unsigned int index)
+{
+	return m->mBones[index];
+}
+
+struct aiVertexWeight* get_bone_weight(struct aiBone* b, unsigned int index)
+{
+	return &(b->mWeights[index]);
+}
+
+struct aiAnimation* get_animation(struct aiScene* s, unsigned int index)
+{
+	return s->mAnimations[index];
+}
+
+struct aiNodeAnim* get_channel(struct aiAnimation* a, unsigned int index)
+{
+	return a->mChannels[index];
+}
+
+struct aiVectorKey* get_position_key(struct aiNodeAnim* c, unsigned int index)
+{
+	return &(c->mPositionKeys[index]);
+}
+
+struct aiQuatKey* get_rotation_key(struct aiNodeAnim* c, unsigned int index)
+{
+	return &(c->mRotationKeys[index]);
+}
+
+struct aiVectorKey* get_scaling_key(struct aiNodeAnim* c, unsigned int index)
+{
+	return &(c->mScalingKeys[index]);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/animation"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
+)
+
+// Used when a file doesn't say how fast its animations run
+const DEFAULT_TICKS_PER_SECOND = 25.0
+
+func goString(s *C.struct_aiString) string {
+	return C.GoStringN(&s.data[0], C.int(s.length))
+}
+
+// processBones adds each bone's weights to the vertices it moves
+func (model *Model) processBones(aiMesh *C.struct_aiMesh,
+	vertices []mesh.Vertex) error {
+
+	if aiMesh.mNumBones == 0 {
+		return nil
+	}
+
+	for i := 0; i < int(aiMesh.mNumBones); i++ {
+		bone := C.get_bone(aiMesh, C.uint(i))
+		id := model.boneID(goString(&bone.mName),
+			convertMatrix(&bone.mOffsetMatrix))
+
+		for j := 0; j < int(bone.mNumWeights); j++ {
+			weight := C.get_bone_weight(bone, C.uint(j))
+			vertex := int(weight.mVertexId)
+			if vertex >= len(vertices) {
+				return fmt.Errorf("bone %d weight %d vertex %d out of range "+
+					"of %d vertices", i, j, vertex, len(vertices))
+			}
+			vertices[vertex].AddBoneWeight(int32(id), float32(weight.mWeight))
+		}
+	}
+
+	for i := range vertices {
+		vertices[i].NormalizeWeights()
+	}
+	return nil
+}
+
+func (model *Model) processAnimations(aiScene *C.struct_aiScene) {
+	for i := 0; i < int(aiScene.mNumAnimations); i++ {
+		aiAnim := C.get_animation(aiScene, C.uint(i))
+
+		// Keys are in ticks, convert them to seconds
+		ticks := float64(aiAnim.mTicksPerSecond)
+		if ticks <= 0 {
+			ticks = DEFAULT_TICKS_PER_SECOND
+		}
+		seconds := func(t C.double) float32 {
+			return float32(float64(t) / ticks)
+		}
+
+		channels := make([]animation.Channel, int(aiAnim.mNumChannels))
+		for j := range channels {
+			aiChannel := C.get_channel(aiAnim, C.uint(j))
+			channel := &channels[j]
+			channel.Node = goString(&aiChannel.mNodeName)
+
+			for k := 0; k < int(aiChannel.mNumPositionKeys); k++ {
+				key := C.get_position_key(aiChannel, C.uint(k))
+				channel.Positions = append(channel.Positions,
+					animation.VectorKey{Time: seconds(key.mTime),
+						Value: convertVector(&key.mValue)})
+			}
+			for k := 0; k < int(aiChannel.mNumRotationKeys); k++ {
+				key := C.get_rotation_key(aiChannel, C.uint(k))
+				channel.Rotations = append(channel.Rotations,
+					animation.QuatKey{Time: seconds(key.mTime),
+						Value: mgl32.Quat{W: float32(key.mValue.w),
+							V: mgl32.Vec3{float32(key.mValue.x),
+								float32(key.mValue.y),
+								float32(key.mValue.z)}}})
+			}
+			for k := 0; k < int(aiChannel.mNumScalingKeys); k++ {
+				key := C.get_scaling_key(aiChannel, C.uint(k))
+				channel.Scales = append(channel.Scales,
+					animation.VectorKey{Time: seconds(key.mTime),
+						Value: convertVector(&key.mValue)})
+			}
+		}
+
+		model.Animations = append(model.Animations, animation.NewClip(
+			goString(&aiAnim.mName), seconds(aiAnim.mDuration), channels))
+	}
+}
+
+func convertVector(v *C.struct_aiVector3D) mgl32.Vec3 {
+	return mgl32.Vec3{float32(v.x), float32(v.y), float32(v.z)}
+}
@@ -0,0 +1,263 @@
+//go:build !noassimp
+// +build !noassimp
+
+package model
+
+/*
+#include <stdlib.h>
+
+#include <assimp/material.h>
+#include <assimp/version.h>
+
+int get_material_color(const struct aiMaterial* m, const char* key,
+	struct aiColor4D* out)
+{
+	return aiGetMaterialColor(m, key, 0, 0, out) == AI_SUCCESS;
+}
+
+int get_material_float(const struct aiMaterial* m, const char* key,
+	float* out)
+{
+	return aiGetMaterialFloatArray(m, key, 0, 0, out, 0) == AI_SUCCESS;
+}
+
+int get_material_int(const struct aiMaterial* m, const char* key, int* out)
+{
+	return aiGetMaterialIntegerArray(m, key, 0, 0, out, 0) == AI_SUCCESS;
+}
+
+int get_material_string(const struct aiMaterial* m, const char* key,
+	struct aiString* out)
+{
+	return aiGetMaterialString(m, key, 0, 0, out) == AI_SUCCESS;
+}
+*/
+import "C"
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
+)
+
+// Texture types assimp 5 added for PBR. Older versions used 12 for
+// aiTextureType_UNKNOWN so these are only looked up on assimp 5 or later.
+const (
+	aiTextureType_BASE_COLOR        = 12
+	aiTextureType_METALNESS         = 15
+	aiTextureType_DIFFUSE_ROUGHNESS = 16
+	aiTextureType_AMBIENT_OCCLUSION = 17
+)
+
+// processMaterial reads an assimp material's constants and loads its
+// textures. PBR factors come from the assimp 5 keys or the older glTF ones
+// and are derived from the Phong values when the file has neither.
+func (model *Model) processMaterial(mat *C.struct_aiMaterial) (mesh.Material,
+	error) {
+
+	m := mesh.DefaultMaterial()
+
+	m.Name, _ = materialString(mat, "?mat.name")
+	if c, ok := materialColor(mat, "$clr.ambient"); ok {
+		m.Ambient = c.Vec3()
+	}
+	if c, ok := materialColor(mat, "$clr.diffuse"); ok {
+		m.Diffuse = c.Vec3()
+	}
+	if c, ok := materialColor(mat, "$clr.specular"); ok {
+		m.Specular = c.Vec3()
+	}
+	if c, ok := materialColor(mat, "$clr.emissive"); ok {
+		m.Emissive = c.Vec3()
+	}
+	if f, ok := materialFloat(mat, "$mat.shininess"); ok && f > 0 {
+		m.Shininess = f
+	}
+	if f, ok := materialFloat(mat, "$mat.opacity"); ok {
+		m.Opacity = f
+	}
+	if i, ok := materialInt(mat, "$mat.twosided"); ok {
+		m.DoubleSided = i != 0
+	}
+
+	// Base colour
+	m.BaseColor = m.Diffuse.Vec4(m.Opacity)
+	if c, ok := materialColor(mat, "$clr.base",
+		"$mat.gltf.pbrMetallicRoughness.baseColorFactor"); ok {
+		m.BaseColor = c
+	}
+	if f, ok := materialFloat(mat, "$mat.metallicFactor",
+		"$mat.gltf.pbrMetallicRoughness.metallicFactor"); ok {
+		m.Metallic = f
+	}
+	// Roughness from the Blinn-Phong exponent if there isn't any
+	m.Roughness = float32(math.Sqrt(2.0 / (float64(m.Shininess) + 2.0)))
+	if f, ok := materialFloat(mat, "$mat.roughnessFactor",
+		"$mat.gltf.pbrMetallicRoughness.roughnessFactor"); ok {
+		m.Roughness = f
+	}
+
+	// Alpha
+	if mode, ok := materialString(mat, "$mat.gltf.alphaMode"); ok {
+		switch mode {
+		case "MASK":
+			m.AlphaMode = mesh.ALPHA_MASK
+		case "BLEND":
+			m.AlphaMode = mesh.ALPHA_BLEND
+		}
+	} else if m.Opacity < 1.0 || m.BaseColor[3] < 1.0 {
+		m.AlphaMode = mesh.ALPHA_BLEND
+	}
+	if f, ok := materialFloat(mat, "$mat.gltf.alphaCutoff"); ok {
+		m.AlphaCutoff = f
+	}
+
+	// Textures. OBJ files put normal maps in map_Bump which assimp calls a
+	// height map, so height maps are normal maps unless there are both.
+	var err error
+	add := func(textType uint32, typeName string) {
+		if err != nil {
+			return
+		}
+		var textures []mesh.TextureSlot
+		textures, err = model.loadMaterialTextures(mat, textType, typeName)
+		m.Textures = append(m.Textures, textures...)
+	}
+	hasNormals := C.aiGetMaterialTextureCount(mat, C.aiTextureType_NORMALS) > 0
+
+	add(C.aiTextureType_DIFFUSE, "texture_diffuse")
+	add(C.aiTextureType_SPECULAR, "texture_specular")
+	if hasNormals {
+		add(C.aiTextureType_NORMALS, "texture_normal")
+		add(C.aiTextureType_HEIGHT, "texture_height")
+	} else {
+		add(C.aiTextureType_HEIGHT, "texture_normal")
+	}
+	add(C.aiTextureType_DISPLACEMENT, "texture_height")
+	add(C.aiTextureType_AMBIENT, "texture_ambient")
+	add(C.aiTextureType_EMISSIVE, "texture_emissive")
+	add(C.aiTextureType_OPACITY, "texture_opacity")
+
+	if C.aiGetVersionMajor() >= 5 {
+		add(aiTextureType_BASE_COLOR, "texture_base_color")
+		add(aiTextureType_METALNESS, "texture_metallic")
+		add(aiTextureType_DIFFUSE_ROUGHNESS, "texture_roughness")
+		add(aiTextureType_AMBIENT_OCCLUSION, "texture_ao")
+	}
+	// glTF occlusion maps come in as light maps
+	if m.Texture("texture_ao") == nil {
+		add(C.aiTextureType_LIGHTMAP, "texture_ao")
+	}
+	if m.Texture("texture_opacity") != nil && m.AlphaMode == mesh.ALPHA_OPAQUE {
+		m.AlphaMode = mesh.ALPHA_BLEND
+	}
+
+	return m, err
+}
+
+func (model *Model) loadMaterialTextures(mat *C.struct_aiMaterial,
+	textType uint32, /**C.enum_aiTextureType*/
+	typeName string) ([]mesh.TextureSlot, error) {
+
+	var textures []mesh.TextureSlot
+
+	textCount := C.aiGetMaterialTextureCount(mat, textType)
+	for i := uint32(0); i < uint32(textCount); i++ {
+		var path C.struct_aiString
+		var uvIndex C.uint
+		var mapModes [3]C.enum_aiTextureMapMode
+
+		C.aiGetMaterialTexture(
+			mat,          // Material
+			textType,     // Type of texture
+			C.uint(i),    // Index
+			&path,        // Path to string
+			nil,          // Texture mapping
+			&uvIndex,     // UV index
+			nil,          // Blend
+			nil,          // Texture op
+			&mapModes[0], // Map mode
+			nil)          // Flags
+		pathAsGoString := C.GoString(&path.data[0])
+
+		// The cache only loads each texture once
+		texture, err := model.loadTexture(
+			texturePath(pathAsGoString, model.directory), typeName, true)
+		if err != nil {
+			return nil, err
+		}
+		textures = append(textures, mesh.TextureSlot{Texture: texture,
+			UVIndex: int(uvIndex), WrapS: wrapMode(mapModes[0]),
+			WrapT: wrapMode(mapModes[1])})
+	}
+	return textures, nil
+}
+
+func wrapMode(mode C.enum_aiTextureMapMode) int32 {
+	switch mode {
+	case C.aiTextureMapMode_Clamp:
+		return gl.CLAMP_TO_EDGE
+	case C.aiTextureMapMode_Mirror:
+		return gl.MIRRORED_REPEAT
+	case C.aiTextureMapMode_Decal:
+		return gl.CLAMP_TO_BORDER
+	}
+	return gl.REPEAT
+}
+
+// The material getters try each key in turn
+
+func materialColor(mat *C.struct_aiMaterial, keys ...string) (mgl32.Vec4,
+	bool) {
+
+	for _, key := range keys {
+		var c C.struct_aiColor4D
+		if withKey(key, func(k *C.char) bool {
+			return C.get_material_color(mat, k, &c) != 0
+		}) {
+			return mgl32.Vec4{float32(c.r), float32(c.g), float32(c.b),
+				float32(c.a)}, true
+		}
+	}
+	return mgl32.Vec4{}, false
+}
+
+func materialFloat(mat *C.struct_aiMaterial, keys ...string) (float32, bool) {
+	for _, key := range keys {
+		var f C.float
+		if withKey(key, func(k *C.char) bool {
+			return C.get_material_float(mat, k, &f) != 0
+		}) {
+			return float32(f), true
+		}
+	}
+	return 0, false
+}
+
+func materialInt(mat *C.struct_aiMaterial, key string) (int, bool) {
+	var i C.int
+	ok := withKey(key, func(k *C.char) bool {
+		return C.get_material_int(mat, k, &i) != 0
+	})
+	return int(i), ok
+}
+
+func materialString(mat *C.struct_aiMaterial, key string) (string, bool) {
+	var s C.struct_aiString
+	if !withKey(key, func(k *C.char) bool {
+		return C.get_material_string(mat, k, &s) != 0
+	}) {
+		return "", false
+	}
+	return goString(&s), true
+}
+
+func withKey(key string, f func(*C.char) bool) bool {
+	cKey := C.CString(key)
+	defer C.free(unsafe.Pointer(cKey))
+	return f(cKey)
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"runtime"
+
+	"github.com/disintegration/imaging"
+)
+
+// TextureLoader decodes texture images on a pool of goroutines. Each
+// texture gets a 1x1 placeholder straight away and Poll, called on the GL
+// thread, fills it in with the real image once it's decoded. Texture ids
+// never change so meshes don't need to know when that happens.
+//
+// Pass one in Options to get a model back before its textures are ready:
+//
+//	loader := model.NewTextureLoader(0)
+//	loader.Progress = func(done, total int) { ... }
+//	backpack, err := model.Load(path, model.Options{Loader: loader})
+//	for !window.ShouldClose() {
+//		loader.Poll()
+//		...
+//	}
+type TextureLoader struct {
+	// Progress is called by Poll and Wait after each texture is uploaded
+	Progress func(done, total int)
+
+	workers chan struct{}
+	results chan decodeResult
+	done    int
+	total   int
+}
+
+type decodeJob struct {
+	id    uint32
+	key   textureKey
+	cache *TextureCache
+	read  func() ([]byte, error)
+}
+
+type decodeResult struct {
+	decodeJob
+	img *image.NRGBA
+	err error
+}
+
+// NewTextureLoader makes a loader decoding up to workers images at once,
+// 0 or less uses one per CPU
+func NewTextureLoader(workers int) *TextureLoader {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &TextureLoader{workers: make(chan struct{}, workers),
+		results: make(chan decodeResult)}
+}
+
+// Pending is the number of textures still waiting to be uploaded
+func (l *TextureLoader) Pending() int {
+	return l.total - l.done
+}
+
+// Poll uploads the textures that have finished decoding without blocking.
+// It must be called from the GL thread. Textures that fail to decode keep
+// their placeholder until another model loads them, and the first error is
+// returned.
+func (l *TextureLoader) Poll() error {
+	var firstErr error
+	for l.Pending() > 0 {
+		select {
+		case r := <-l.results:
+			if err := l.upload(r); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		default:
+			return firstErr
+		}
+	}
+	return firstErr
+}
+
+// Wait is Poll that blocks until every texture is uploaded
+func (l *TextureLoader) Wait() error {
+	var firstErr error
+	for l.Pending() > 0 {
+		if err := l.upload(<-l.results); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// queue makes a placeholder texture and starts decoding the real one,
+// only called from the GL thread. Jobs with an id decode into that texture
+// again, it already has a placeholder.
+func (l *TextureLoader) queue(job decodeJob, typeName string) uint32 {
+	if job.id == 0 {
+		job.id = newTexture()
+		pixel := placeholder(typeName)
+		fillTexture(job.id, image.Point{1, 1}, pixel[:], job.key.srgb)
+	}
+
+	l.total++
+	go func() {
+		l.workers <- struct{}{}
+		img, err := decodeImage(job.read, job.key.flip)
+		<-l.workers
+		l.results <- decodeResult{decodeJob: job, img: img, err: err}
+	}()
+	return job.id
+}
+
+func (l *TextureLoader) upload(r decodeResult) error {
+	l.done++
+	defer func() {
+		if l.Progress != nil {
+			l.Progress(l.done, l.total)
+		}
+	}()
+
+	if r.err != nil {
+		// So the next model to want it doesn't just get the placeholder
+		r.cache.fail(r.key, r.id)
+		return fmt.Errorf("texture %s: %v", r.key.path, r.err)
+	}
+	// The model may have been released while the image was decoding
+	if !r.cache.holds(r.key, r.id) {
+		return nil
+	}
+	fillTexture(r.id, r.img.Rect.Size(), r.img.Pix, r.key.srgb)
+	return nil
+}
+
+func decodeImage(read func() ([]byte, error), flip bool) (*image.NRGBA,
+	error) {
+
+	data, err := read()
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	rgba := image.NewNRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	if flip {
+		rgba = imaging.FlipV(rgba)
+	}
+	return rgba, nil
+}
+
+// placeholder is white, or a flat normal for normal maps so lighting
+// looks right before they arrive
+func placeholder(typeName string) [4]uint8 {
+	if typeName == "texture_normal" {
+		return [4]uint8{128, 128, 255, 255}
+	}
+	return [4]uint8{255, 255, 255, 255}
+}
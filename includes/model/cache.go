@@ -0,0 +1,223 @@
+package model
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
+)
+
+// TextureCache shares GL textures between models. Each texture is
+// reference counted, a model takes a reference to every texture it uses
+// once and Release gives them back, deleting the textures nothing else
+// uses.
+type TextureCache struct {
+	mu      sync.Mutex
+	entries map[textureKey]*cacheEntry
+}
+
+// The same file loaded with different colour spaces or flipping are
+// different textures
+type textureKey struct {
+	path string
+	srgb bool
+	flip bool
+}
+
+type cacheEntry struct {
+	id      uint32
+	refs    int
+	deleted bool
+	// failed is set when the image couldn't be decoded, the texture is
+	// still the placeholder
+	failed bool
+}
+
+// DefaultCache is used by models loaded without their own cache
+var DefaultCache = NewTextureCache()
+
+func NewTextureCache() *TextureCache {
+	return &TextureCache{entries: map[textureKey]*cacheEntry{}}
+}
+
+// Len is the number of textures in the cache
+func (c *TextureCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Delete frees every texture in the cache, even ones models still use.
+// Models using them panic if drawn, the cache itself can be used again.
+func (c *TextureCache) Delete() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, e := range c.entries {
+		c.delete(key, e)
+	}
+}
+
+// acquire gets a texture adding a reference, load makes it the first time.
+// load is given 0 for a new texture, or the id of one that failed to
+// decode to try it again in the same texture.
+func (c *TextureCache) acquire(key textureKey,
+	load func(id uint32) (uint32, error)) (*cacheEntry, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		if e.failed {
+			if _, err := load(e.id); err != nil {
+				return nil, err
+			}
+			e.failed = false
+		}
+		e.refs++
+		return e, nil
+	}
+	id, err := load(0)
+	if err != nil {
+		return nil, err
+	}
+	e := &cacheEntry{id: id, refs: 1}
+	c.entries[key] = e
+	mesh.TrackObjects(mesh.TEXTURES, 1)
+	return e, nil
+}
+
+// holds is true while the cache has id for key
+func (c *TextureCache) holds(key textureKey, id uint32) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	return ok && e.id == id
+}
+
+// fail marks a texture that couldn't be decoded so the next model to use
+// it tries again
+func (c *TextureCache) fail(key textureKey, id uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok && e.id == id {
+		e.failed = true
+	}
+}
+
+// release drops a reference and deletes the texture with the last one.
+// Entries already deleted with the cache are skipped.
+func (c *TextureCache) release(key textureKey, e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e.deleted || c.entries[key] != e {
+		return
+	}
+	e.refs--
+	if e.refs <= 0 {
+		c.delete(key, e)
+	}
+}
+
+func (c *TextureCache) delete(key textureKey, e *cacheEntry) {
+	gl.DeleteTextures(1, &e.id)
+	e.deleted = true
+	delete(c.entries, key)
+	mesh.TrackObjects(mesh.TEXTURES, -1)
+}
+
+// isColor is true for the texture types that hold colours, which are
+// loaded as sRGB when the model is gamma corrected
+func isColor(typeName string) bool {
+	switch typeName {
+	case "texture_diffuse", "texture_base_color", "texture_emissive",
+		"texture_ambient":
+		return true
+	}
+	return false
+}
+
+// loadTexture gets a texture file through the model's cache. Each distinct
+// texture is only referenced once by a model however many meshes use it.
+// New textures are decoded by the model's loader.
+func (model *Model) loadTexture(path, typeName string,
+	flip bool) (mesh.Texture, error) {
+
+	return model.loadTextureWith(path, typeName, flip, func() ([]byte, error) {
+		return ioutil.ReadFile(path)
+	})
+}
+
+// loadTextureWith is loadTexture for images that aren't a file of their
+// own, path only has to be unique
+func (model *Model) loadTextureWith(path, typeName string, flip bool,
+	read func() ([]byte, error)) (mesh.Texture, error) {
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	key := textureKey{path: path,
+		srgb: model.gammaCorrection && isColor(typeName), flip: flip}
+
+	texture := mesh.Texture{TextureType: typeName, Path: path}
+	if e, ok := model.textures[key]; ok {
+		texture.Id = e.id
+		return texture, nil
+	}
+
+	e, err := model.cache.acquire(key, func(id uint32) (uint32, error) {
+		job := decodeJob{id: id, key: key, cache: model.cache, read: read}
+		return model.loader.queue(job, typeName), nil
+	})
+	if err != nil {
+		return texture, err
+	}
+
+	model.textures[key] = e
+	texture.Id = e.id
+	model.TexturesLoaded = append(model.TexturesLoaded, texture)
+	return texture, nil
+}
+
+// Release gives the model's textures back to its cache, deleting the ones
+// no other model is using. The model shouldn't be drawn afterwards, see
+// Delete to free its meshes as well.
+func (model *Model) Release() {
+	for key, e := range model.textures {
+		model.cache.release(key, e)
+	}
+	model.textures = map[textureKey]*cacheEntry{}
+	model.TexturesLoaded = nil
+}
+
+// Delete frees the model's meshes and releases its textures. Deleting
+// twice does nothing but drawing afterwards panics.
+func (model *Model) Delete() {
+	if model.deleted {
+		return
+	}
+	model.deleted = true
+
+	model.Release()
+	for _, m := range model.Meshes {
+		m.Delete()
+	}
+}
+
+// checkAlive panics if the model or any of its textures have been freed
+func (model *Model) checkAlive() {
+	if model.deleted {
+		panic("model: Draw after Delete")
+	}
+	for key, e := range model.textures {
+		if e.deleted {
+			panic("model: Draw after the texture cache deleted " + key.path)
+		}
+	}
+}
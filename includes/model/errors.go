@@ -0,0 +1,27 @@
+package model
+
+import (
+	"fmt"
+)
+
+// LoadError is returned when a model can't be loaded. Mesh is the index of
+// the mesh in the scene at fault, or -1 when it's the whole file. Assimp is
+// assimp's own error message when it gave one.
+type LoadError struct {
+	Path   string
+	Mesh   int
+	Reason string
+	Assimp string
+}
+
+func (e *LoadError) Error() string {
+	s := "model: " + e.Path
+	if e.Mesh >= 0 {
+		s += fmt.Sprintf(": mesh %d", e.Mesh)
+	}
+	s += ": " + e.Reason
+	if e.Assimp != "" {
+		s += ": " + e.Assimp
+	}
+	return s
+}
@@ -0,0 +1,1066 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/animation"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/meshdata"
+)
+
+// glTF 2.0 without cgo. Only the parts of the spec that map onto Model are
+// read: nodes, triangle meshes, skins, animations and metallic roughness
+// materials. https://www.khronos.org/registry/glTF/specs/2.0/glTF-2.0.html
+
+// The JSON keys only differ from the field names by case, which
+// encoding/json ignores
+type gltfDocument struct {
+	Scene  *int
+	Scenes []struct {
+		Nodes []int
+	}
+	Nodes       []gltfNode
+	Meshes      []gltfMesh
+	Accessors   []gltfAccessor
+	BufferViews []gltfBufferView
+	Buffers     []struct {
+		URI        string
+		ByteLength int
+	}
+	Images []struct {
+		URI        string
+		MimeType   string
+		BufferView *int
+	}
+	Textures []struct {
+		Source  *int
+		Sampler *int
+	}
+	Samplers []struct {
+		WrapS *int32
+		WrapT *int32
+	}
+	Materials  []gltfMaterial
+	Skins      []gltfSkin
+	Animations []gltfAnimation
+}
+
+type gltfNode struct {
+	Name        string
+	Children    []int
+	Mesh        *int
+	Skin        *int
+	Matrix      *[16]float32
+	Translation *[3]float32
+	Rotation    *[4]float32
+	Scale       *[3]float32
+}
+
+type gltfMesh struct {
+	Primitives []struct {
+		Attributes map[string]int
+		Indices    *int
+		Material   *int
+		Mode       *int
+	}
+}
+
+type gltfAccessor struct {
+	BufferView    *int
+	ByteOffset    int
+	ComponentType int
+	Normalized    bool
+	Count         int
+	Type          string
+	Sparse        *struct {
+		Count   int
+		Indices struct {
+			BufferView    int
+			ByteOffset    int
+			ComponentType int
+		}
+		Values struct {
+			BufferView int
+			ByteOffset int
+		}
+	}
+}
+
+type gltfBufferView struct {
+	Buffer     int
+	ByteOffset int
+	ByteLength int
+	ByteStride int
+}
+
+type gltfTextureInfo struct {
+	Index    int
+	TexCoord int
+}
+
+type gltfMaterial struct {
+	Name                 string
+	PbrMetallicRoughness *struct {
+		BaseColorFactor          *[4]float32
+		BaseColorTexture         *gltfTextureInfo
+		MetallicFactor           *float32
+		RoughnessFactor          *float32
+		MetallicRoughnessTexture *gltfTextureInfo
+	}
+	NormalTexture    *gltfTextureInfo
+	OcclusionTexture *gltfTextureInfo
+	EmissiveTexture  *gltfTextureInfo
+	EmissiveFactor   *[3]float32
+	AlphaMode        string
+	AlphaCutoff      *float32
+	DoubleSided      bool
+}
+
+type gltfSkin struct {
+	InverseBindMatrices *int
+	Joints              []int
+}
+
+type gltfAnimation struct {
+	Name     string
+	Channels []struct {
+		Sampler int
+		Target  struct {
+			Node *int
+			Path string
+		}
+	}
+	Samplers []struct {
+		Input         int
+		Output        int
+		Interpolation string
+	}
+}
+
+// Accessor component types
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+// The most components an accessor without a buffer view can have, they're
+// all zeros so nothing in the file bounds them
+const gltfMaxComponents = 1 << 24
+
+// Chunk types of a .glb
+const (
+	glbMagic     = 0x46546C67
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+type gltfLoader struct {
+	model   *Model
+	path    string
+	doc     gltfDocument
+	buffers [][]byte
+	// The GL texture for each glTF texture once it's been loaded
+	textures map[int]mesh.Texture
+	// Names of the nodes, made unique so skeletons and animations can
+	// find them
+	nodeNames []string
+	// The model meshes made from each glTF mesh's primitives
+	meshes [][]int
+}
+
+func (model *Model) loadGLTF(path string) error {
+	l := gltfLoader{model: model, path: path, textures: map[int]mesh.Texture{}}
+	if err := l.load(); err != nil {
+		if _, ok := err.(*LoadError); ok {
+			return err
+		}
+		return &LoadError{Path: path, Mesh: -1, Reason: err.Error()}
+	}
+	return nil
+}
+
+func (l *gltfLoader) load() error {
+	data, err := ioutil.ReadFile(l.path)
+	if err != nil {
+		return err
+	}
+	if err := l.decode(data); err != nil {
+		return err
+	}
+
+	l.nameNodes()
+	if err := l.loadMeshes(); err != nil {
+		return err
+	}
+
+	// A scene can have many roots so they all go under one empty node
+	l.model.Root = NewNode("", mgl32.Ident4(), nil)
+	var roots []int
+	if len(l.doc.Scenes) > 0 {
+		scene := 0
+		if l.doc.Scene != nil {
+			scene = *l.doc.Scene
+		}
+		if scene < 0 || scene >= len(l.doc.Scenes) {
+			return fmt.Errorf("scene %d doesn't exist", scene)
+		}
+		roots = l.doc.Scenes[scene].Nodes
+	}
+	for _, i := range roots {
+		if err := l.processNode(i, l.model.Root, 0); err != nil {
+			return err
+		}
+	}
+
+	if err := l.loadAnimations(); err != nil {
+		return err
+	}
+	return l.model.buildSkeleton()
+}
+
+// decode reads the JSON of a .gltf or .glb and the buffers it uses
+func (l *gltfLoader) decode(data []byte) error {
+	var bin []byte
+	var err error
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if data, bin, err = splitGLB(data); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(data, &l.doc); err != nil {
+		return err
+	}
+
+	for i, b := range l.doc.Buffers {
+		var data []byte
+		if b.URI == "" {
+			// Only the first buffer of a .glb can be the BIN chunk
+			if i != 0 || bin == nil {
+				return fmt.Errorf("buffer %d has no uri", i)
+			}
+			data = bin
+		} else if data, err = l.readURI(b.URI); err != nil {
+			return err
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("buffer %d is %d bytes, expected %d", i,
+				len(data), b.ByteLength)
+		}
+		l.buffers = append(l.buffers, data)
+	}
+	return nil
+}
+
+// splitGLB gets the JSON and BIN chunks out of a .glb
+func splitGLB(data []byte) ([]byte, []byte, error) {
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb header is truncated")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("glb version %d isn't supported", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, errors.New("glb is truncated")
+	}
+
+	var jsonChunk, bin []byte
+	for offset := 12; offset+8 <= length; {
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		start := offset + 8
+		if start+chunkLength > length {
+			return nil, nil, errors.New("glb chunk is truncated")
+		}
+
+		switch chunkType {
+		case glbChunkJSON:
+			jsonChunk = data[start : start+chunkLength]
+		case glbChunkBIN:
+			bin = data[start : start+chunkLength]
+		}
+		// Chunks are padded to 4 bytes
+		offset = start + (chunkLength+3)&^3
+	}
+	if jsonChunk == nil {
+		return nil, nil, errors.New("glb has no JSON chunk")
+	}
+	return jsonChunk, bin, nil
+}
+
+// readURI reads a data uri or a file relative to the glTF file
+func (l *gltfLoader) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, errors.New("only base64 data uris are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(texturePath(path, l.model.directory))
+}
+
+func (l *gltfLoader) nameNodes() {
+	used := map[string]bool{}
+	for i, n := range l.doc.Nodes {
+		name := n.Name
+		if name == "" {
+			name = "node" + strconv.Itoa(i)
+		} else if used[name] {
+			name += "_node" + strconv.Itoa(i)
+		}
+		used[name] = true
+		l.nodeNames = append(l.nodeNames, name)
+	}
+}
+
+// processNode builds the node tree, depth guards against cycles
+func (l *gltfLoader) processNode(i int, parent *Node, depth int) error {
+	if i < 0 || i >= len(l.doc.Nodes) {
+		return fmt.Errorf("node %d doesn't exist", i)
+	}
+	if depth > len(l.doc.Nodes) {
+		return errors.New("node hierarchy has a cycle")
+	}
+	n := l.doc.Nodes[i]
+
+	transform := mgl32.Ident4()
+	if n.Matrix != nil {
+		transform = mgl32.Mat4(*n.Matrix)
+	} else {
+		t := animation.Transform{Rotation: mgl32.QuatIdent(),
+			Scale: mgl32.Vec3{1.0, 1.0, 1.0}}
+		if n.Translation != nil {
+			t.Translation = *n.Translation
+		}
+		if n.Rotation != nil {
+			t.Rotation = gltfQuat(n.Rotation[:])
+		}
+		if n.Scale != nil {
+			t.Scale = *n.Scale
+		}
+		transform = t.Mat4()
+	}
+
+	node := NewNode(l.nodeNames[i], transform, parent)
+	if n.Mesh != nil {
+		if *n.Mesh < 0 || *n.Mesh >= len(l.meshes) {
+			return fmt.Errorf("node %d mesh %d doesn't exist", i, *n.Mesh)
+		}
+		node.Meshes = append(node.Meshes, l.meshes[*n.Mesh]...)
+	}
+
+	for _, child := range n.Children {
+		if err := l.processNode(child, node, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gltfQuat(v []float32) mgl32.Quat {
+	return mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}.Normalize()
+}
+
+func (l *gltfLoader) loadMeshes() error {
+	// Joint indices in a mesh are into the skin of the node using it
+	skins := map[int]int{}
+	for _, n := range l.doc.Nodes {
+		if n.Mesh != nil && n.Skin != nil {
+			skins[*n.Mesh] = *n.Skin
+		}
+	}
+
+	for i, m := range l.doc.Meshes {
+		var bones []int32
+		if skin, ok := skins[i]; ok {
+			var err error
+			if bones, err = l.loadSkin(skin); err != nil {
+				return err
+			}
+		}
+
+		var indices []int
+		for _, p := range m.Primitives {
+			// Points and lines are usually helpers, they're left out
+			// rather than failing the whole model
+			if p.Mode != nil && isPointsOrLines(*p.Mode) {
+				continue
+			}
+			index := len(l.model.Meshes)
+			result, err := l.loadPrimitive(p.Attributes, p.Indices,
+				p.Material, p.Mode, bones)
+			if err != nil {
+				return &LoadError{Path: l.path, Mesh: index,
+					Reason: err.Error()}
+			}
+			l.model.Meshes = append(l.model.Meshes, result)
+			indices = append(indices, index)
+		}
+		l.meshes = append(l.meshes, indices)
+	}
+	return nil
+}
+
+// loadSkin gets the bone id of each of the skin's joints
+func (l *gltfLoader) loadSkin(i int) ([]int32, error) {
+	if i < 0 || i >= len(l.doc.Skins) {
+		return nil, fmt.Errorf("skin %d doesn't exist", i)
+	}
+	skin := l.doc.Skins[i]
+
+	var inverseBinds []float32
+	if skin.InverseBindMatrices != nil {
+		var err error
+		inverseBinds, _, err = l.readAccessor(*skin.InverseBindMatrices)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bones := make([]int32, len(skin.Joints))
+	for j, joint := range skin.Joints {
+		if joint < 0 || joint >= len(l.nodeNames) {
+			return nil, fmt.Errorf("skin %d joint %d doesn't exist", i, joint)
+		}
+		offset := mgl32.Ident4()
+		if len(inverseBinds) >= (j+1)*16 {
+			copy(offset[:], inverseBinds[j*16:])
+		}
+		bones[j] = int32(l.model.boneID(l.nodeNames[joint], offset))
+	}
+	return bones, nil
+}
+
+func (l *gltfLoader) loadPrimitive(attributes map[string]int, indexAccessor,
+	material, mode *int, bones []int32) (*mesh.Mesh, error) {
+
+	position, ok := attributes["POSITION"]
+	if !ok {
+		return nil, errors.New("primitive has no positions")
+	}
+	positions, _, err := l.readAccessor(position)
+	if err != nil {
+		return nil, err
+	}
+	if a := l.doc.Accessors[position]; a.Type != "VEC3" ||
+		a.ComponentType != gltfFloat {
+		return nil, fmt.Errorf("POSITION is %s of type %d, expected VEC3 "+
+			"floats", a.Type, a.ComponentType)
+	}
+	numVertices := len(positions) / 3
+	if numVertices == 0 {
+		return nil, errors.New("mesh has no vertices")
+	}
+	vertices := make([]mesh.Vertex, numVertices)
+	for i := range vertices {
+		copy(vertices[i].Position[:], positions[i*3:])
+	}
+
+	// Optional attributes, each read into the vertices if they're there
+	read := func(name string, size int, f func(v *mesh.Vertex, x []float32)) error {
+		accessor, ok := attributes[name]
+		if !ok {
+			return nil
+		}
+		data, n, err := l.readAccessor(accessor)
+		if err != nil {
+			return err
+		}
+		if n < size || len(data)/n != numVertices {
+			return fmt.Errorf("%s doesn't match the positions", name)
+		}
+		for i := range vertices {
+			f(&vertices[i], data[i*n:i*n+n])
+		}
+		return nil
+	}
+
+	err = read("NORMAL", 3, func(v *mesh.Vertex, x []float32) {
+		copy(v.Normal[:], x)
+	})
+	if err == nil {
+		err = read("TEXCOORD_0", 2, func(v *mesh.Vertex, x []float32) {
+			copy(v.TexCoords[:], x)
+		})
+	}
+	if err == nil {
+		// The bitangent comes from w, which says which way it points
+		err = read("TANGENT", 4, func(v *mesh.Vertex, x []float32) {
+			copy(v.Tangent[:], x)
+			v.Bitangent = v.Normal.Cross(v.Tangent).Mul(x[3])
+		})
+	}
+	if err == nil && bones != nil {
+		var joints []float32
+		err = read("JOINTS_0", 4, func(v *mesh.Vertex, x []float32) {
+			joints = append(joints, x[:4]...)
+		})
+		if err == nil && joints != nil {
+			i := 0
+			err = read("WEIGHTS_0", 4, func(v *mesh.Vertex, x []float32) {
+				for j := 0; j < 4; j++ {
+					joint := int(joints[i*4+j])
+					if joint < len(bones) && x[j] > 0 {
+						v.AddBoneWeight(bones[joint], x[j])
+					}
+				}
+				v.NormalizeWeights()
+				i++
+			})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var indices []uint32
+	if indexAccessor != nil {
+		if indices, err = l.readIndices(*indexAccessor); err != nil {
+			return nil, err
+		}
+		for _, index := range indices {
+			if int(index) >= numVertices {
+				return nil, fmt.Errorf("index %d out of range of %d vertices",
+					index, numVertices)
+			}
+		}
+	} else {
+		indices = make([]uint32, numVertices)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+
+	primitiveMode := gl.TRIANGLES
+	if mode != nil {
+		primitiveMode = *mode
+	}
+	if indices, err = triangulate(indices, primitiveMode); err != nil {
+		return nil, err
+	}
+
+	if _, ok := attributes["NORMAL"]; !ok {
+		meshdata.ComputeNormals(vertices, indices)
+	}
+	_, hasTangents := attributes["TANGENT"]
+	if _, ok := attributes["TEXCOORD_0"]; ok && !hasTangents {
+		meshdata.ComputeTangents(vertices, indices)
+	}
+
+	m := mesh.DefaultMaterial()
+	if material != nil {
+		if m, err = l.loadMaterial(*material); err != nil {
+			return nil, err
+		}
+	}
+	var textures []mesh.Texture
+	for _, slot := range m.Textures {
+		textures = append(textures, slot.Texture)
+	}
+
+	result := mesh.NewMesh(vertices, indices, textures)
+	result.Material = m
+	return result, nil
+}
+
+func isPointsOrLines(mode int) bool {
+	switch mode {
+	case gl.POINTS, gl.LINES, gl.LINE_LOOP, gl.LINE_STRIP:
+		return true
+	}
+	return false
+}
+
+// triangulate turns strips and fans into a list of triangles
+func triangulate(indices []uint32, mode int) ([]uint32, error) {
+	switch mode {
+	case gl.TRIANGLES:
+		return indices, nil
+	case gl.TRIANGLE_STRIP:
+		var triangles []uint32
+		for i := 2; i < len(indices); i++ {
+			// Every other triangle is wound backwards
+			if i%2 == 0 {
+				triangles = append(triangles, indices[i-2], indices[i-1],
+					indices[i])
+			} else {
+				triangles = append(triangles, indices[i-1], indices[i-2],
+					indices[i])
+			}
+		}
+		return triangles, nil
+	case gl.TRIANGLE_FAN:
+		var triangles []uint32
+		for i := 2; i < len(indices); i++ {
+			triangles = append(triangles, indices[0], indices[i-1],
+				indices[i])
+		}
+		return triangles, nil
+	}
+	return nil, fmt.Errorf("primitive mode %d isn't triangles", mode)
+}
+
+func (l *gltfLoader) loadMaterial(i int) (mesh.Material, error) {
+	m := mesh.DefaultMaterial()
+	if i < 0 || i >= len(l.doc.Materials) {
+		return m, fmt.Errorf("material %d doesn't exist", i)
+	}
+	g := l.doc.Materials[i]
+	m.Name = g.Name
+
+	// glTF defaults to fully metallic
+	m.Metallic = 1.0
+	add := func(info *gltfTextureInfo, typeName string) error {
+		if info == nil {
+			return nil
+		}
+		slot, err := l.loadTexture(info, typeName)
+		if err == nil {
+			m.Textures = append(m.Textures, slot)
+		}
+		return err
+	}
+
+	var err error
+	if pbr := g.PbrMetallicRoughness; pbr != nil {
+		if pbr.BaseColorFactor != nil {
+			m.BaseColor = *pbr.BaseColorFactor
+		}
+		if pbr.MetallicFactor != nil {
+			m.Metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			m.Roughness = *pbr.RoughnessFactor
+		}
+
+		// Base colour is the diffuse texture so the Phong chapters can draw
+		// glTF models too. Metallic is the blue channel and roughness the
+		// green of the same texture.
+		if err == nil {
+			err = add(pbr.BaseColorTexture, "texture_diffuse")
+		}
+		if err == nil {
+			err = add(pbr.MetallicRoughnessTexture, "texture_metallic")
+		}
+		if err == nil {
+			err = add(pbr.MetallicRoughnessTexture, "texture_roughness")
+		}
+	}
+	if err == nil {
+		err = add(g.NormalTexture, "texture_normal")
+	}
+	if err == nil {
+		err = add(g.OcclusionTexture, "texture_ao")
+	}
+	if err == nil {
+		err = add(g.EmissiveTexture, "texture_emissive")
+	}
+	if err != nil {
+		return m, err
+	}
+
+	m.Diffuse = m.BaseColor.Vec3()
+	m.Opacity = m.BaseColor[3]
+	if g.EmissiveFactor != nil {
+		m.Emissive = *g.EmissiveFactor
+	}
+
+	switch g.AlphaMode {
+	case "MASK":
+		m.AlphaMode = mesh.ALPHA_MASK
+	case "BLEND":
+		m.AlphaMode = mesh.ALPHA_BLEND
+	}
+	if g.AlphaCutoff != nil {
+		m.AlphaCutoff = *g.AlphaCutoff
+	}
+	m.DoubleSided = g.DoubleSided
+
+	return m, nil
+}
+
+func (l *gltfLoader) loadTexture(info *gltfTextureInfo,
+	typeName string) (mesh.TextureSlot, error) {
+
+	slot := mesh.TextureSlot{UVIndex: info.TexCoord, WrapS: gl.REPEAT,
+		WrapT: gl.REPEAT}
+	if info.Index < 0 || info.Index >= len(l.doc.Textures) {
+		return slot, fmt.Errorf("texture %d doesn't exist", info.Index)
+	}
+	t := l.doc.Textures[info.Index]
+	if t.Sampler != nil && *t.Sampler >= 0 && *t.Sampler < len(l.doc.Samplers) {
+		sampler := l.doc.Samplers[*t.Sampler]
+		if sampler.WrapS != nil {
+			slot.WrapS = *sampler.WrapS
+		}
+		if sampler.WrapT != nil {
+			slot.WrapT = *sampler.WrapT
+		}
+	}
+
+	if texture, ok := l.textures[info.Index]; ok {
+		slot.Texture = texture
+		slot.TextureType = typeName
+		return slot, nil
+	}
+
+	if t.Source == nil || *t.Source < 0 || *t.Source >= len(l.doc.Images) {
+		return slot, fmt.Errorf("texture %d has no image", info.Index)
+	}
+	source := l.doc.Images[*t.Source]
+
+	// Embedded images are keyed by the file they're in
+	path := l.path + "#image" + strconv.Itoa(*t.Source)
+	read := func() ([]byte, error) {
+		if source.BufferView != nil {
+			return l.bufferView(*source.BufferView)
+		}
+		return l.readURI(source.URI)
+	}
+	if source.BufferView == nil && !strings.HasPrefix(source.URI, "data:") {
+		if unescaped, err := url.PathUnescape(source.URI); err == nil {
+			path = texturePath(unescaped, l.model.directory)
+		}
+	}
+
+	// glTF's texture coordinates start at the top left like the image rows
+	// so it doesn't need flipping
+	texture, err := l.model.loadTextureWith(path, typeName, false, read)
+	if err != nil {
+		return slot, fmt.Errorf("image %d: %v", *t.Source, err)
+	}
+	l.textures[info.Index] = texture
+
+	slot.Texture = texture
+	return slot, nil
+}
+
+func (l *gltfLoader) loadAnimations() error {
+	for i, a := range l.doc.Animations {
+		var channels []animation.Channel
+		byNode := map[int]int{}
+		var duration float32
+
+		for _, c := range a.Channels {
+			if c.Target.Node == nil || c.Sampler < 0 ||
+				c.Sampler >= len(a.Samplers) {
+				continue
+			}
+			node := *c.Target.Node
+			if node < 0 || node >= len(l.nodeNames) {
+				return fmt.Errorf("animation %d node %d doesn't exist", i, node)
+			}
+			sampler := a.Samplers[c.Sampler]
+
+			times, _, err := l.readAccessor(sampler.Input)
+			if err != nil {
+				return err
+			}
+			values, n, err := l.readAccessor(sampler.Output)
+			if err != nil {
+				return err
+			}
+			// Cubic splines have an in and out tangent around each value,
+			// just the values are used
+			stride, offset := n, 0
+			if sampler.Interpolation == "CUBICSPLINE" {
+				stride, offset = n*3, n
+			}
+			if len(values) < len(times)*stride {
+				return fmt.Errorf("animation %d has too few values", i)
+			}
+			value := func(k int) []float32 {
+				return values[k*stride+offset : k*stride+offset+n]
+			}
+
+			index, ok := byNode[node]
+			if !ok {
+				index = len(channels)
+				byNode[node] = index
+				channels = append(channels,
+					animation.Channel{Node: l.nodeNames[node]})
+			}
+			channel := &channels[index]
+
+			for k, t := range times {
+				if t > duration {
+					duration = t
+				}
+				// A step is the previous value held until the next key
+				keys := []int{k}
+				if sampler.Interpolation == "STEP" && k > 0 {
+					keys = []int{k - 1, k}
+				}
+				for _, key := range keys {
+					v := value(key)
+					switch c.Target.Path {
+					case "translation":
+						channel.Positions = append(channel.Positions,
+							animation.VectorKey{Time: t,
+								Value: mgl32.Vec3{v[0], v[1], v[2]}})
+					case "rotation":
+						channel.Rotations = append(channel.Rotations,
+							animation.QuatKey{Time: t, Value: gltfQuat(v)})
+					case "scale":
+						channel.Scales = append(channel.Scales,
+							animation.VectorKey{Time: t,
+								Value: mgl32.Vec3{v[0], v[1], v[2]}})
+					}
+				}
+			}
+		}
+
+		name := a.Name
+		if name == "" {
+			name = "animation" + strconv.Itoa(i)
+		}
+		l.model.Animations = append(l.model.Animations,
+			animation.NewClip(name, duration, channels))
+	}
+	return nil
+}
+
+func (l *gltfLoader) bufferView(i int) ([]byte, error) {
+	if i < 0 || i >= len(l.doc.BufferViews) {
+		return nil, fmt.Errorf("buffer view %d doesn't exist", i)
+	}
+	v := l.doc.BufferViews[i]
+	if v.Buffer < 0 || v.Buffer >= len(l.buffers) {
+		return nil, fmt.Errorf("buffer %d doesn't exist", v.Buffer)
+	}
+	buffer := l.buffers[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 ||
+		v.ByteOffset+v.ByteLength > len(buffer) {
+		return nil, fmt.Errorf("buffer view %d is out of range", i)
+	}
+	return buffer[v.ByteOffset : v.ByteOffset+v.ByteLength], nil
+}
+
+// readAccessor gets an accessor's elements as floats along with the
+// number of components in each element. Sparse values are applied on top.
+func (l *gltfLoader) readAccessor(i int) ([]float32, int, error) {
+	if i < 0 || i >= len(l.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d doesn't exist", i)
+	}
+	a := l.doc.Accessors[i]
+
+	n := map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4,
+		"MAT2": 4, "MAT3": 9, "MAT4": 16}[a.Type]
+	size := componentSize(a.ComponentType)
+	if n == 0 || size == 0 {
+		return nil, 0, fmt.Errorf("accessor %d has unknown type %s %d", i,
+			a.Type, a.ComponentType)
+	}
+
+	if a.Count < 0 || a.ByteOffset < 0 {
+		return nil, 0, fmt.Errorf("accessor %d has a negative count or "+
+			"offset", i)
+	}
+
+	// Everything is checked against the data before allocating, accessors
+	// without a buffer view are zeros and only have a limit
+	var data []byte
+	stride := n * size
+	if a.BufferView != nil {
+		var err error
+		if data, stride, err = l.accessorView(i, n*size); err != nil {
+			return nil, 0, err
+		}
+	} else if a.Count > gltfMaxComponents/n {
+		return nil, 0, fmt.Errorf("accessor %d has %d elements, too many "+
+			"without a buffer view", i, a.Count)
+	}
+
+	out := make([]float32, a.Count*n)
+	if a.BufferView != nil {
+		for e := 0; e < a.Count; e++ {
+			for c := 0; c < n; c++ {
+				out[e*n+c] = readComponent(data[a.ByteOffset+e*stride+c*size:],
+					a.ComponentType, a.Normalized)
+			}
+		}
+	}
+
+	if s := a.Sparse; s != nil {
+		indices, err := l.bufferView(s.Indices.BufferView)
+		if err != nil {
+			return nil, 0, err
+		}
+		values, err := l.bufferView(s.Values.BufferView)
+		if err != nil {
+			return nil, 0, err
+		}
+		indexSize := componentSize(s.Indices.ComponentType)
+		if indexSize == 0 ||
+			!inRange(len(indices), s.Indices.ByteOffset, indexSize,
+				s.Count, indexSize) ||
+			!inRange(len(values), s.Values.ByteOffset, n*size, s.Count,
+				n*size) {
+			return nil, 0, fmt.Errorf("accessor %d sparse data is out of "+
+				"range", i)
+		}
+		for k := 0; k < s.Count; k++ {
+			e := int(readComponent(indices[s.Indices.ByteOffset+k*indexSize:],
+				s.Indices.ComponentType, false))
+			if e < 0 || e >= a.Count {
+				return nil, 0, fmt.Errorf("accessor %d sparse index %d out "+
+					"of range", i, e)
+			}
+			for c := 0; c < n; c++ {
+				out[e*n+c] = readComponent(
+					values[s.Values.ByteOffset+(k*n+c)*size:],
+					a.ComponentType, a.Normalized)
+			}
+		}
+	}
+
+	return out, n, nil
+}
+
+// readIndices reads an index accessor without going through floats, which
+// can't hold every 32 bit index
+func (l *gltfLoader) readIndices(i int) ([]uint32, error) {
+	if i < 0 || i >= len(l.doc.Accessors) {
+		return nil, fmt.Errorf("accessor %d doesn't exist", i)
+	}
+	a := l.doc.Accessors[i]
+	size := componentSize(a.ComponentType)
+	if a.Type != "SCALAR" || a.BufferView == nil || a.Sparse != nil ||
+		a.ComponentType == gltfFloat || size == 0 {
+
+		data, _, err := l.readAccessor(i)
+		indices := make([]uint32, len(data))
+		for j, index := range data {
+			indices[j] = uint32(index)
+		}
+		return indices, err
+	}
+
+	if a.Count < 0 || a.ByteOffset < 0 {
+		return nil, fmt.Errorf("accessor %d has a negative count or offset",
+			i)
+	}
+	data, stride, err := l.accessorView(i, size)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]uint32, a.Count)
+	for j := range indices {
+		b := data[a.ByteOffset+j*stride:]
+		switch size {
+		case 1:
+			indices[j] = uint32(b[0])
+		case 2:
+			indices[j] = uint32(binary.LittleEndian.Uint16(b))
+		default:
+			indices[j] = binary.LittleEndian.Uint32(b)
+		}
+	}
+	return indices, nil
+}
+
+// accessorView gets the buffer view of accessor i and its stride, checking
+// every element of elementSize bytes is inside it
+func (l *gltfLoader) accessorView(i, elementSize int) ([]byte, int, error) {
+	a := l.doc.Accessors[i]
+	data, err := l.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, 0, err
+	}
+	stride := elementSize
+	if v := l.doc.BufferViews[*a.BufferView]; v.ByteStride < 0 {
+		return nil, 0, fmt.Errorf("buffer view %d has a negative stride",
+			*a.BufferView)
+	} else if v.ByteStride != 0 {
+		stride = v.ByteStride
+	}
+	if !inRange(len(data), a.ByteOffset, stride, a.Count, elementSize) {
+		return nil, 0, fmt.Errorf("accessor %d is out of range", i)
+	}
+	return data, stride, nil
+}
+
+// inRange is whether count elements of elementSize bytes, stride apart from
+// offset, fit in length bytes. It's worked out without multiplying so huge
+// counts can't overflow.
+func inRange(length, offset, stride, count, elementSize int) bool {
+	if offset < 0 || count < 0 || stride <= 0 {
+		return false
+	}
+	if count == 0 {
+		return offset <= length
+	}
+	last := length - offset - elementSize
+	return last >= 0 && (count-1) <= last/stride
+}
+
+func componentSize(componentType int) int {
+	switch componentType {
+	case gltfByte, gltfUnsignedByte:
+		return 1
+	case gltfShort, gltfUnsignedShort:
+		return 2
+	case gltfUnsignedInt, gltfFloat:
+		return 4
+	}
+	return 0
+}
+
+// readComponent reads one little endian component, normalized integers
+// are mapped to [0, 1] or [-1, 1]
+func readComponent(b []byte, componentType int, normalized bool) float32 {
+	switch componentType {
+	case gltfByte:
+		v := float32(int8(b[0]))
+		if normalized {
+			return float32(math.Max(float64(v)/127.0, -1.0))
+		}
+		return v
+	case gltfUnsignedByte:
+		v := float32(b[0])
+		if normalized {
+			return v / 255.0
+		}
+		return v
+	case gltfShort:
+		v := float32(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return float32(math.Max(float64(v)/32767.0, -1.0))
+		}
+		return v
+	case gltfUnsignedShort:
+		v := float32(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return v / 65535.0
+		}
+		return v
+	case gltfUnsignedInt:
+		return float32(binary.LittleEndian.Uint32(b))
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
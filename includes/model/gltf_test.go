@@ -0,0 +1,516 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// makeGLB wraps JSON and a BIN chunk in a .glb, each padded to 4 bytes
+func makeGLB(json string, bin []byte) []byte {
+	chunk := func(kind uint32, data []byte, pad byte) []byte {
+		for len(data)%4 != 0 {
+			data = append(data, pad)
+		}
+		header := make([]byte, 8)
+		binary.LittleEndian.PutUint32(header, uint32(len(data)))
+		binary.LittleEndian.PutUint32(header[4:], kind)
+		return append(header, data...)
+	}
+	body := chunk(glbChunkJSON, []byte(json), ' ')
+	if bin != nil {
+		body = append(body, chunk(glbChunkBIN, bin, 0)...)
+	}
+
+	header := make([]byte, 12)
+	binary.LittleEndian.PutUint32(header, glbMagic)
+	binary.LittleEndian.PutUint32(header[4:], 2)
+	binary.LittleEndian.PutUint32(header[8:], uint32(12+len(body)))
+	return append(header, body...)
+}
+
+// testBin builds a buffer with a buffer view for each call to add
+type testBin struct {
+	data  []byte
+	views []string
+}
+
+// add writes values little endian as a new buffer view, stride 0 leaves
+// it tightly packed
+func (b *testBin) add(stride int, values ...interface{}) {
+	var buf bytes.Buffer
+	for _, v := range values {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	view := fmt.Sprintf(`{"buffer": 0, "byteOffset": %d, "byteLength": %d`,
+		len(b.data), buf.Len())
+	if stride != 0 {
+		view += fmt.Sprintf(`, "byteStride": %d`, stride)
+	}
+	b.views = append(b.views, view+"}")
+
+	b.data = append(b.data, buf.Bytes()...)
+	for len(b.data)%4 != 0 {
+		b.data = append(b.data, 0)
+	}
+}
+
+// document is a glTF with the buffer as its BIN chunk, rest is any other
+// top level keys
+func (b *testBin) document(accessors []string, rest string) []byte {
+	json := fmt.Sprintf(`{"buffers": [{"byteLength": %d}],
+		"bufferViews": [%s], "accessors": [%s]%s}`, len(b.data),
+		strings.Join(b.views, ", "), strings.Join(accessors, ", "), rest)
+	return makeGLB(json, b.data)
+}
+
+func decodeTest(t *testing.T, data []byte) (*gltfLoader, error) {
+	t.Helper()
+	l := &gltfLoader{model: newModel("test.glb", Options{}),
+		path: "test.glb"}
+	return l, l.decode(data)
+}
+
+func TestSplitGLB(t *testing.T) {
+	glb := makeGLB(`{"asset": {"version": "2.0"}}`, []byte{1, 2, 3, 4, 5})
+	json, bin, err := splitGLB(glb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The JSON keeps its padding, which JSON ignores
+	if strings.TrimSpace(string(json)) != `{"asset": {"version": "2.0"}}` {
+		t.Errorf("JSON chunk is %q", json)
+	}
+	if !bytes.Equal(bin, []byte{1, 2, 3, 4, 5, 0, 0, 0}) {
+		t.Errorf("BIN chunk is %v", bin)
+	}
+
+	edit := func(change func(data []byte)) []byte {
+		data := append([]byte(nil), glb...)
+		change(data)
+		return data
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"header cut short", glb[:10], "truncated"},
+		{"version 1", edit(func(data []byte) {
+			binary.LittleEndian.PutUint32(data[4:], 1)
+		}), "version"},
+		{"length past the end", glb[:len(glb)-4], "truncated"},
+		{"chunk past the length", edit(func(data []byte) {
+			binary.LittleEndian.PutUint32(data[12:], 1000)
+		}), "chunk is truncated"},
+		{"huge chunk", edit(func(data []byte) {
+			binary.LittleEndian.PutUint32(data[12:], 0xffffffff)
+		}), "chunk is truncated"},
+		{"no JSON", edit(func(data []byte) {
+			binary.LittleEndian.PutUint32(data[16:], 0x12345678)
+		}), "no JSON"},
+	}
+	for _, test := range tests {
+		_, _, err := splitGLB(test.data)
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+		} else if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: error %q, want it to say %q", test.name, err,
+				test.want)
+		}
+	}
+}
+
+func TestDecodeBuffers(t *testing.T) {
+	bin := []byte{1, 2, 3, 4, 5, 6}
+	uri := "data:application/octet-stream;base64," +
+		base64.StdEncoding.EncodeToString(bin)
+
+	l, err := decodeTest(t, []byte(fmt.Sprintf(
+		`{"buffers": [{"uri": %q, "byteLength": 6}]}`, uri)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.buffers) != 1 || !bytes.Equal(l.buffers[0], bin) {
+		t.Errorf("data uri buffer is %v, want %v", l.buffers, bin)
+	}
+
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"not base64", `{"buffers": [{"uri": "data:text/plain,abc"}]}`,
+			"base64"},
+		{"bad base64", `{"buffers": [{"uri": "data:;base64,!!!"}]}`,
+			"illegal"},
+		{"too short", fmt.Sprintf(
+			`{"buffers": [{"uri": %q, "byteLength": 7}]}`, uri),
+			"expected 7"},
+		{"no uri outside a glb", `{"buffers": [{"byteLength": 1}]}`,
+			"no uri"},
+		{"bad JSON", `{"buffers": [}`, "invalid"},
+	}
+	for _, test := range tests {
+		_, err := decodeTest(t, []byte(test.json))
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+		} else if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: error %q, want it to say %q", test.name, err,
+				test.want)
+		}
+	}
+
+	// Only the first buffer of a .glb is the BIN chunk
+	_, err = decodeTest(t, makeGLB(
+		`{"buffers": [{"byteLength": 4}, {"byteLength": 4}]}`,
+		[]byte{1, 2, 3, 4}))
+	if err == nil || !strings.Contains(err.Error(), "buffer 1 has no uri") {
+		t.Errorf("second buffer without a uri gave %v", err)
+	}
+}
+
+// accessorBin has a buffer view of each kind of data the accessor tests
+// read
+func accessorBin() *testBin {
+	b := &testBin{}
+	// 0: three VEC3s
+	b.add(0, []float32{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	// 1: two VEC2s with a float of padding after each
+	b.add(12, []float32{1, 2, -1, 3, 4, -1})
+	// 2: normalized unsigned byte VEC2s
+	b.add(0, []uint8{0, 255, 51, 0})
+	// 3: normalized shorts
+	b.add(0, []int16{-32767, 32767, -32768, 0})
+	// 4: sparse indices, then 5: their VEC3 values
+	b.add(0, []uint16{2, 0})
+	b.add(0, []float32{10, 11, 12, 13, 14, 15})
+	// 6: a signed byte of -1 for a sparse index
+	b.add(0, []int8{-1})
+	return b
+}
+
+func TestReadAccessor(t *testing.T) {
+	tests := []struct {
+		name     string
+		accessor string
+		n        int
+		want     []float32
+	}{
+		{"floats", `{"bufferView": 0, "componentType": 5126, "count": 3,
+			"type": "VEC3"}`, 3, []float32{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{"offset", `{"bufferView": 0, "byteOffset": 12,
+			"componentType": 5126, "count": 2, "type": "VEC3"}`, 3,
+			[]float32{4, 5, 6, 7, 8, 9}},
+		{"scalars", `{"bufferView": 0, "componentType": 5126, "count": 4,
+			"type": "SCALAR"}`, 1, []float32{1, 2, 3, 4}},
+		{"strided", `{"bufferView": 1, "componentType": 5126, "count": 2,
+			"type": "VEC2"}`, 2, []float32{1, 2, 3, 4}},
+		{"normalized bytes", `{"bufferView": 2, "componentType": 5121,
+			"normalized": true, "count": 2, "type": "VEC2"}`, 2,
+			[]float32{0, 1, 0.2, 0}},
+		{"bytes", `{"bufferView": 2, "componentType": 5121, "count": 2,
+			"type": "VEC2"}`, 2, []float32{0, 255, 51, 0}},
+		{"normalized shorts", `{"bufferView": 3, "componentType": 5122,
+			"normalized": true, "count": 4, "type": "SCALAR"}`, 1,
+			[]float32{-1, 1, -1, 0}},
+		{"sparse", `{"bufferView": 0, "componentType": 5126, "count": 3,
+			"type": "VEC3", "sparse": {"count": 2,
+			"indices": {"bufferView": 4, "componentType": 5123},
+			"values": {"bufferView": 5}}}`, 3,
+			[]float32{13, 14, 15, 4, 5, 6, 10, 11, 12}},
+		{"sparse without a view", `{"componentType": 5126, "count": 4,
+			"type": "VEC3", "sparse": {"count": 2,
+			"indices": {"bufferView": 4, "componentType": 5123},
+			"values": {"bufferView": 5}}}`, 3,
+			[]float32{13, 14, 15, 0, 0, 0, 10, 11, 12, 0, 0, 0}},
+		{"sparse offsets", `{"componentType": 5126, "count": 1,
+			"type": "VEC3", "sparse": {"count": 1,
+			"indices": {"bufferView": 4, "byteOffset": 2,
+				"componentType": 5123},
+			"values": {"bufferView": 5, "byteOffset": 12}}}`, 3,
+			[]float32{13, 14, 15}},
+		{"empty", `{"bufferView": 0, "componentType": 5126, "count": 0,
+			"type": "VEC3"}`, 3, []float32{}},
+	}
+	b := accessorBin()
+	for _, test := range tests {
+		l, err := decodeTest(t, b.document([]string{test.accessor}, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, n, err := l.readAccessor(0)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if n != test.n || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v with %d components, want %v with %d",
+				test.name, got, n, test.want, test.n)
+		}
+	}
+}
+
+func TestReadBrokenAccessors(t *testing.T) {
+	tests := []struct {
+		name     string
+		accessor string
+		want     string
+	}{
+		{"unknown type", `{"bufferView": 0, "componentType": 5126,
+			"count": 1, "type": "VEC5"}`, "unknown type"},
+		{"unknown component", `{"bufferView": 0, "componentType": 5124,
+			"count": 1, "type": "VEC3"}`, "unknown type"},
+		{"negative count", `{"bufferView": 0, "componentType": 5126,
+			"count": -1, "type": "VEC3"}`, "negative"},
+		{"negative count without a view", `{"componentType": 5126,
+			"count": -5, "type": "SCALAR"}`, "negative"},
+		{"negative offset", `{"bufferView": 0, "byteOffset": -12,
+			"componentType": 5126, "count": 1, "type": "VEC3"}`,
+			"negative"},
+		{"count past the view", `{"bufferView": 0, "componentType": 5126,
+			"count": 4, "type": "VEC3"}`, "out of range"},
+		{"offset past the view", `{"bufferView": 0, "byteOffset": 28,
+			"componentType": 5126, "count": 1, "type": "VEC3"}`,
+			"out of range"},
+		{"huge count", `{"bufferView": 0, "componentType": 5126,
+			"count": 4611686018427387903, "type": "VEC3"}`,
+			"out of range"},
+		{"huge count without a view", `{"componentType": 5126,
+			"count": 1000000000, "type": "MAT4"}`, "too many"},
+		{"no buffer view", `{"bufferView": 20, "componentType": 5126,
+			"count": 1, "type": "VEC3"}`, "doesn't exist"},
+		{"negative sparse count", `{"componentType": 5126, "count": 3,
+			"type": "VEC3", "sparse": {"count": -1,
+			"indices": {"bufferView": 4, "componentType": 5123},
+			"values": {"bufferView": 5}}}`, "sparse data is out of range"},
+		{"negative sparse index offset", `{"componentType": 5126,
+			"count": 3, "type": "VEC3", "sparse": {"count": 1,
+			"indices": {"bufferView": 4, "byteOffset": -2,
+				"componentType": 5123},
+			"values": {"bufferView": 5}}}`, "sparse data is out of range"},
+		{"negative sparse value offset", `{"componentType": 5126,
+			"count": 3, "type": "VEC3", "sparse": {"count": 1,
+			"indices": {"bufferView": 4, "componentType": 5123},
+			"values": {"bufferView": 5, "byteOffset": -12}}}`,
+			"sparse data is out of range"},
+		{"sparse values past the view", `{"componentType": 5126,
+			"count": 3, "type": "VEC3", "sparse": {"count": 3,
+			"indices": {"bufferView": 4, "componentType": 5121},
+			"values": {"bufferView": 5}}}`, "sparse data is out of range"},
+		{"sparse index out of range", `{"componentType": 5126,
+			"count": 2, "type": "VEC3", "sparse": {"count": 1,
+			"indices": {"bufferView": 4, "componentType": 5123},
+			"values": {"bufferView": 5}}}`, "sparse index 2 out of range"},
+		{"negative sparse index", `{"componentType": 5126, "count": 2,
+			"type": "VEC3", "sparse": {"count": 1,
+			"indices": {"bufferView": 6, "componentType": 5120},
+			"values": {"bufferView": 5}}}`, "sparse index -1 out of range"},
+		{"sparse index type", `{"componentType": 5126, "count": 2,
+			"type": "VEC3", "sparse": {"count": 1,
+			"indices": {"bufferView": 4, "componentType": 1},
+			"values": {"bufferView": 5}}}`, "sparse data is out of range"},
+	}
+
+	b := accessorBin()
+	negativeStride := &testBin{}
+	negativeStride.add(-4, []float32{1, 2, 3})
+
+	for _, test := range tests {
+		l, err := decodeTest(t, b.document([]string{test.accessor}, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkError(t, test.name, test.want, func() error {
+			_, _, err := l.readAccessor(0)
+			return err
+		})
+	}
+
+	l, err := decodeTest(t, negativeStride.document([]string{
+		`{"bufferView": 0, "componentType": 5126, "count": 1,
+		"type": "SCALAR"}`}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkError(t, "negative stride", "negative stride", func() error {
+		_, _, err := l.readAccessor(0)
+		return err
+	})
+	checkError(t, "negative stride indices", "negative stride",
+		func() error {
+			_, err := l.readIndices(0)
+			return err
+		})
+	checkError(t, "missing accessor", "doesn't exist", func() error {
+		_, _, err := l.readAccessor(1)
+		return err
+	})
+}
+
+// checkError checks f returns an error saying want instead of panicking
+func checkError(t *testing.T, name, want string, f func() error) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("%s: panicked: %v", name, r)
+		}
+	}()
+	err := f()
+	if err == nil {
+		t.Errorf("%s: no error", name)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Errorf("%s: error %q, want it to say %q", name, err, want)
+	}
+}
+
+func TestReadIndices(t *testing.T) {
+	b := &testBin{}
+	b.add(0, []uint8{0, 1, 2, 250})
+	b.add(0, []uint16{0, 1, 2, 65535})
+	// Past what a float32 can hold exactly
+	b.add(0, []uint32{0, 16777217, 4294967295})
+	b.add(4, []uint16{7, 0xffff, 8, 0xffff})
+	b.add(0, []float32{3, 2, 1})
+
+	tests := []struct {
+		name     string
+		accessor string
+		want     []uint32
+	}{
+		{"bytes", `{"bufferView": 0, "componentType": 5121, "count": 4,
+			"type": "SCALAR"}`, []uint32{0, 1, 2, 250}},
+		{"shorts", `{"bufferView": 1, "componentType": 5123, "count": 4,
+			"type": "SCALAR"}`, []uint32{0, 1, 2, 65535}},
+		{"ints", `{"bufferView": 2, "componentType": 5125, "count": 3,
+			"type": "SCALAR"}`, []uint32{0, 16777217, 4294967295}},
+		{"offset", `{"bufferView": 1, "byteOffset": 4,
+			"componentType": 5123, "count": 2, "type": "SCALAR"}`,
+			[]uint32{2, 65535}},
+		{"strided", `{"bufferView": 3, "componentType": 5123, "count": 2,
+			"type": "SCALAR"}`, []uint32{7, 8}},
+		{"floats", `{"bufferView": 4, "componentType": 5126, "count": 3,
+			"type": "SCALAR"}`, []uint32{3, 2, 1}},
+	}
+	for _, test := range tests {
+		l, err := decodeTest(t, b.document([]string{test.accessor}, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := l.readIndices(0)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	broken := []struct {
+		name     string
+		accessor string
+		want     string
+	}{
+		{"negative offset", `{"bufferView": 1, "byteOffset": -2,
+			"componentType": 5123, "count": 1, "type": "SCALAR"}`,
+			"negative"},
+		{"negative count", `{"bufferView": 1, "componentType": 5123,
+			"count": -1, "type": "SCALAR"}`, "negative"},
+		{"past the view", `{"bufferView": 1, "componentType": 5123,
+			"count": 5, "type": "SCALAR"}`, "out of range"},
+		{"huge count", `{"bufferView": 2, "componentType": 5125,
+			"count": 2305843009213693951, "type": "SCALAR"}`,
+			"out of range"},
+	}
+	for _, test := range broken {
+		l, err := decodeTest(t, b.document([]string{test.accessor}, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkError(t, test.name, test.want, func() error {
+			_, err := l.readIndices(0)
+			return err
+		})
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	indices := []uint32{0, 1, 2, 3, 4}
+	tests := []struct {
+		name string
+		mode int
+		want []uint32
+	}{
+		{"triangles", gl.TRIANGLES, indices},
+		// Every other triangle of a strip is flipped so they all face the
+		// same way as the first
+		{"strip", gl.TRIANGLE_STRIP, []uint32{0, 1, 2, 2, 1, 3, 2, 3, 4}},
+		{"fan", gl.TRIANGLE_FAN, []uint32{0, 1, 2, 0, 2, 3, 0, 3, 4}},
+	}
+	for _, test := range tests {
+		got, err := triangulate(indices, test.mode)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	// Too few for a triangle is no triangles
+	for _, mode := range []int{gl.TRIANGLE_STRIP, gl.TRIANGLE_FAN} {
+		if got, err := triangulate([]uint32{0, 1}, mode); err != nil ||
+			len(got) != 0 {
+			t.Errorf("mode %d with two indices gave %v, %v", mode, got,
+				err)
+		}
+	}
+	if _, err := triangulate(indices, 7); err == nil {
+		t.Errorf("unknown mode didn't error")
+	}
+}
+
+func TestLoadPrimitives(t *testing.T) {
+	b := &testBin{}
+	b.add(0, []float32{0, 0, 1, 0, 0, 1})
+
+	// Points and lines are skipped, which doesn't need GL
+	for _, mode := range []int{gl.POINTS, gl.LINES, gl.LINE_LOOP,
+		gl.LINE_STRIP} {
+		l, err := decodeTest(t, b.document([]string{
+			`{"bufferView": 0, "componentType": 5126, "count": 3,
+			"type": "VEC2"}`}, fmt.Sprintf(`, "meshes": [{"primitives": [
+			{"attributes": {"POSITION": 0}, "mode": %d}]}]`, mode)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.loadMeshes(); err != nil {
+			t.Errorf("mode %d: %v", mode, err)
+		}
+		if len(l.model.Meshes) != 0 || len(l.meshes) != 1 ||
+			len(l.meshes[0]) != 0 {
+			t.Errorf("mode %d made meshes %v", mode, l.meshes)
+		}
+	}
+
+	// Positions that aren't VEC3 floats fail before any GL is used
+	for _, accessor := range []string{
+		`{"bufferView": 0, "componentType": 5126, "count": 3,
+			"type": "VEC2"}`,
+		`{"bufferView": 0, "componentType": 5126, "count": 1,
+			"type": "VEC4"}`,
+		`{"bufferView": 0, "componentType": 5123, "count": 2,
+			"type": "VEC3"}`,
+	} {
+		l, err := decodeTest(t, b.document([]string{accessor},
+			`, "meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkError(t, accessor, "expected VEC3 floats", l.loadMeshes)
+	}
+}
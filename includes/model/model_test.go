@@ -0,0 +1,146 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.1/glfw"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
+)
+
+// withGL makes a hidden window so the test can make GL objects, it's
+// skipped where there's no display. Call the function it returns when
+// done.
+func withGL(t *testing.T) func() {
+	t.Helper()
+	runtime.LockOSThread()
+	if err := glfw.Init(); err != nil {
+		runtime.UnlockOSThread()
+		t.Skipf("no GL: %v", err)
+	}
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	window, err := glfw.CreateWindow(1, 1, "test", nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		runtime.UnlockOSThread()
+		t.Skipf("no GL: %v", err)
+	}
+	window.MakeContextCurrent()
+	if err := gl.Init(); err != nil {
+		window.Destroy()
+		glfw.Terminate()
+		runtime.UnlockOSThread()
+		t.Skipf("no GL: %v", err)
+	}
+
+	return func() {
+		window.Destroy()
+		glfw.Terminate()
+		runtime.UnlockOSThread()
+	}
+}
+
+// triangleGLTF is a glTF with a triangle mesh using a texture and a
+// second mesh whose positions are extra, an accessor of the given type
+func triangleGLTF(extraType string) string {
+	var positions bytes.Buffer
+	binary.Write(&positions, binary.LittleEndian,
+		[]float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	uri := "data:application/octet-stream;base64," +
+		base64.StdEncoding.EncodeToString(positions.Bytes())
+
+	return fmt.Sprintf(`{
+	"scenes": [{"nodes": [0, 1]}],
+	"nodes": [{"mesh": 0}, {"mesh": 1}],
+	"meshes": [
+		{"primitives": [{"attributes": {"POSITION": 0}, "material": 0}]},
+		{"primitives": [{"attributes": {"POSITION": 1}}]}
+	],
+	"materials": [{"pbrMetallicRoughness": {"baseColorTexture":
+		{"index": 0}}}],
+	"textures": [{"source": 0}],
+	"images": [{"uri": "color.png"}],
+	"buffers": [{"uri": %q, "byteLength": 36}],
+	"bufferViews": [{"buffer": 0, "byteLength": 36}],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3,
+			"type": "VEC3"},
+		{"bufferView": 0, "componentType": 5126, "count": 1,
+			"type": %q}
+	]
+}`, uri, extraType)
+}
+
+func TestFailedLoadLeaksNothing(t *testing.T) {
+	defer withGL(t)()
+
+	dir, err := ioutil.TempDir("", "model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	// A 1x1 PNG
+	png, _ := base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAA" +
+		"AAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mP8z8DwHwAFBQIAX8jx0gAAAABJRU5E" +
+		"rkJggg==")
+
+	tests := []struct {
+		name  string
+		gltf  string
+		image []byte
+		fails bool
+	}{
+		{"loads", triangleGLTF("VEC3"), png, false},
+		// The first mesh and the texture are made before the second mesh
+		// fails
+		{"second mesh fails", triangleGLTF("VEC2"), png, true},
+		// Every mesh is made before the texture fails to decode
+		{"texture fails", triangleGLTF("VEC3"), []byte("not a png"),
+			true},
+	}
+	for _, test := range tests {
+		write("color.png", test.image)
+		path := write("model.gltf", []byte(test.gltf))
+
+		before := mesh.LiveObjects()
+		model, err := Load(path, Options{Cache: NewTextureCache()})
+		if test.fails != (err != nil) {
+			t.Errorf("%s: error %v", test.name, err)
+		}
+		if model != nil {
+			if n := mesh.LiveObjects()[mesh.VERTEX_ARRAYS] -
+				before[mesh.VERTEX_ARRAYS]; n != 2 {
+				t.Errorf("%s: made %d vertex arrays, want 2", test.name, n)
+			}
+			model.Delete()
+		}
+
+		after := mesh.LiveObjects()
+		for _, kind := range []string{mesh.VERTEX_ARRAYS, mesh.BUFFERS,
+			mesh.TEXTURES} {
+			if n := after[kind] - before[kind]; n != 0 {
+				t.Errorf("%s: %d %s left over", test.name, n, kind)
+			}
+		}
+	}
+}
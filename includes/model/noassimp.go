@@ -0,0 +1,12 @@
+//go:build noassimp
+// +build noassimp
+
+package model
+
+const haveAssimp = false
+
+// Built without assimp so only glTF can be loaded
+func (model *Model) loadAssimp(path string) error {
+	return &LoadError{Path: path, Mesh: -1,
+		Reason: "built with noassimp, only .gltf, .glb and .obj can be loaded"}
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
+)
+
+// Node is a part of the model's scene graph. Transform is relative to the
+// parent and can be changed to move the node and everything under it.
+// Meshes are indices into Model.Meshes.
+type Node struct {
+	Name      string
+	Transform mgl32.Mat4
+	Meshes    []int
+	Parent    *Node
+	Children  []*Node
+}
+
+func NewNode(name string, transform mgl32.Mat4, parent *Node) *Node {
+	node := &Node{Name: name, Transform: transform, Parent: parent}
+	if parent != nil {
+		parent.Children = append(parent.Children, node)
+	}
+	return node
+}
+
+// WorldTransform is the node's transform relative to the model's root
+func (n *Node) WorldTransform() mgl32.Mat4 {
+	world := n.Transform
+	for p := n.Parent; p != nil; p = p.Parent {
+		world = p.Transform.Mul4(world)
+	}
+	return world
+}
+
+// Find searches this node and everything under it depth first
+func (n *Node) Find(name string) *Node {
+	if n.Name == name {
+		return n
+	}
+	for _, child := range n.Children {
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// FindNode gets the first node called name or nil
+func (model *Model) FindNode(name string) *Node {
+	if model.Root == nil {
+		return nil
+	}
+	return model.Root.Find(name)
+}
+
+// Draw walks the node tree drawing each node's meshes. When
+// TransformUniform is set it is given Transform times the node's world
+// transform before each node is drawn, otherwise the caller's own model
+// matrix is used for every mesh.
+func (model *Model) Draw(shader shader.Shader) {
+	model.checkAlive()
+	if model.Root == nil {
+		if model.TransformUniform != "" {
+			shader.SetMat4(model.TransformUniform, model.Transform)
+		}
+		for i := 0; i < len(model.Meshes); i++ {
+			model.Meshes[i].Draw(shader)
+		}
+		return
+	}
+	model.drawNode(shader, model.Root, model.Transform)
+}
+
+func (model *Model) drawNode(shader shader.Shader, n *Node,
+	parent mgl32.Mat4) {
+
+	world := parent.Mul4(n.Transform)
+	if len(n.Meshes) > 0 && model.TransformUniform != "" {
+		shader.SetMat4(model.TransformUniform, world)
+	}
+	for _, i := range n.Meshes {
+		model.Meshes[i].Draw(shader)
+	}
+	for _, child := range n.Children {
+		model.drawNode(shader, child, world)
+	}
+}
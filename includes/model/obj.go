@@ -0,0 +1,277 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/meshdata"
+)
+
+// Wavefront OBJ and MTL without cgo, the geometry is read by meshdata.
+// Meshes are split by object and material and each object gets a node.
+//
+// Texture coordinates and images are both flipped the same as the assimp
+// loader does, so a model looks the same whichever loaded it.
+
+// LoadOBJ reads an OBJ without going through assimp
+func LoadOBJ(path string, opts Options) (*Model, error) {
+	return load(path, opts, (*Model).loadOBJ)
+}
+
+// objMaterial is a material from an MTL file with its textures not loaded
+// yet, only materials that are used get their textures loaded
+type objMaterial struct {
+	material mesh.Material
+	textures []objTexture
+	loaded   bool
+}
+
+type objTexture struct {
+	typeName string
+	path     string
+	clamp    bool
+}
+
+type objParser struct {
+	model     *Model
+	materials map[string]*objMaterial
+}
+
+func (model *Model) loadOBJ(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return &LoadError{Path: path, Mesh: -1, Reason: err.Error()}
+	}
+	defer file.Close()
+
+	p := objParser{model: model, materials: map[string]*objMaterial{}}
+	groups, err := meshdata.ReadOBJ(file, p.mtllib)
+	if err != nil {
+		return &LoadError{Path: path, Mesh: -1, Reason: err.Error()}
+	}
+	if len(groups) == 0 {
+		return &LoadError{Path: path, Mesh: -1, Reason: "scene has no meshes"}
+	}
+
+	model.Root = NewNode("", mgl32.Ident4(), nil)
+	objects := map[string]*Node{}
+	for _, g := range groups {
+		g.ComputeNormals()
+		meshdata.ComputeTangents(g.Vertices, g.Indices)
+
+		material := mesh.DefaultMaterial()
+		if m, ok := p.materials[g.Material]; ok {
+			var err error
+			if material, err = p.loadMaterial(m); err != nil {
+				return &LoadError{Path: path, Mesh: len(model.Meshes),
+					Reason: err.Error()}
+			}
+		}
+		var textures []mesh.Texture
+		for _, slot := range material.Textures {
+			textures = append(textures, slot.Texture)
+		}
+
+		m := mesh.NewMesh(g.Vertices, g.Indices, textures)
+		m.Material = material
+
+		node, ok := objects[g.Object]
+		if !ok {
+			node = NewNode(g.Object, mgl32.Ident4(), model.Root)
+			objects[g.Object] = node
+		}
+		node.Meshes = append(node.Meshes, len(model.Meshes))
+		model.Meshes = append(model.Meshes, m)
+	}
+	return nil
+}
+
+// mtllib reads a material library next to the model. A missing one leaves
+// the default materials like assimp does.
+func (p *objParser) mtllib(name string) error {
+	err := p.parseMTL(texturePath(name, p.model.directory))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (p *objParser) parseMTL(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var m *objMaterial
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] == "newmtl" {
+			name := strings.Join(fields[1:], " ")
+			m = &objMaterial{material: mesh.DefaultMaterial()}
+			m.material.Name = name
+			// The shininess default is only for files with no Ns
+			m.material.Roughness = -1.0
+			p.materials[name] = m
+			continue
+		}
+		if m == nil {
+			continue
+		}
+
+		if err := m.parse(fields, filepath.Dir(path)); err != nil {
+			return fmt.Errorf("%s:%d: %v", filepath.Base(path), line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Derive the PBR values the same way the assimp loader does
+	for _, m := range p.materials {
+		mat := &m.material
+		mat.BaseColor = mat.Diffuse.Vec4(mat.Opacity)
+		if mat.Roughness < 0 {
+			mat.Roughness = float32(math.Sqrt(2.0 /
+				(float64(mat.Shininess) + 2.0)))
+		}
+		if mat.Opacity < 1.0 {
+			mat.AlphaMode = mesh.ALPHA_BLEND
+		}
+	}
+	return nil
+}
+
+func (m *objMaterial) parse(fields []string, dir string) error {
+	mat := &m.material
+	key, args := fields[0], fields[1:]
+
+	color := func(c *mgl32.Vec3) error {
+		v, err := meshdata.ParseFloats(args, 1)
+		if err == nil {
+			// A single value is grey
+			*c = mgl32.Vec3{v[0], v[0], v[0]}
+			if len(v) >= 3 {
+				*c = mgl32.Vec3{v[0], v[1], v[2]}
+			}
+		}
+		return err
+	}
+	scalar := func(f *float32) error {
+		v, err := meshdata.ParseFloats(args, 1)
+		if err == nil {
+			*f = v[0]
+		}
+		return err
+	}
+	texture := func(typeName string) error {
+		t, err := parseTextureArgs(args)
+		if err == nil {
+			t.typeName = typeName
+			t.path = texturePath(t.path, dir)
+			m.textures = append(m.textures, t)
+		}
+		return err
+	}
+
+	switch key {
+	case "Ka":
+		return color(&mat.Ambient)
+	case "Kd":
+		return color(&mat.Diffuse)
+	case "Ks":
+		return color(&mat.Specular)
+	case "Ke":
+		return color(&mat.Emissive)
+	case "Ns":
+		return scalar(&mat.Shininess)
+	case "d":
+		return scalar(&mat.Opacity)
+	case "Tr":
+		var tr float32
+		err := scalar(&tr)
+		mat.Opacity = 1.0 - tr
+		return err
+	// The PBR extension to MTL
+	case "Pr":
+		return scalar(&mat.Roughness)
+	case "Pm":
+		return scalar(&mat.Metallic)
+
+	case "map_Kd":
+		return texture("texture_diffuse")
+	case "map_Ks":
+		return texture("texture_specular")
+	// Bump maps are normal maps, as with assimp's height maps
+	case "map_Bump", "map_bump", "bump":
+		return texture("texture_normal")
+	case "norm":
+		return texture("texture_normal")
+	case "disp":
+		return texture("texture_height")
+	case "map_Ka":
+		return texture("texture_ambient")
+	case "map_Ke":
+		return texture("texture_emissive")
+	case "map_d":
+		return texture("texture_opacity")
+	case "map_Pr":
+		return texture("texture_roughness")
+	case "map_Pm":
+		return texture("texture_metallic")
+	}
+	return nil
+}
+
+// parseTextureArgs takes the file name from the end of a map statement
+// and looks for -clamp in the options before it
+func parseTextureArgs(args []string) (objTexture, error) {
+	var t objTexture
+	if len(args) == 0 {
+		return t, fmt.Errorf("texture has no file")
+	}
+	for i := 0; i+1 < len(args)-1; i++ {
+		if args[i] == "-clamp" {
+			t.clamp = args[i+1] == "on"
+		}
+	}
+	t.path = args[len(args)-1]
+	return t, nil
+}
+
+func (p *objParser) loadMaterial(m *objMaterial) (mesh.Material, error) {
+	if !m.loaded {
+		for _, t := range m.textures {
+			// Flipped like the assimp loader's
+			texture, err := p.model.loadTexture(t.path, t.typeName, true)
+			if err != nil {
+				return mesh.Material{}, err
+			}
+
+			wrap := int32(gl.REPEAT)
+			if t.clamp {
+				wrap = gl.CLAMP_TO_EDGE
+			}
+			m.material.Textures = append(m.material.Textures,
+				mesh.TextureSlot{
+					Texture: texture, WrapS: wrap, WrapT: wrap})
+		}
+		m.loaded = true
+	}
+
+	material := m.material
+	material.Textures = append([]mesh.TextureSlot(nil), m.material.Textures...)
+	return material, nil
+}
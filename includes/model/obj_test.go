@@ -0,0 +1,198 @@
+package model
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
+)
+
+const testMTL = `# materials for the tests
+newmtl shiny
+Kd 0.5 0.25 1
+Ks 0.2
+Ns 98
+map_Kd textures/shiny.png
+map_Bump -bm 0.5 -clamp on normal.png
+
+newmtl see through
+d 0.25
+Pr 0.3
+Pm 0.7
+map_Kd -clamp off glass.png
+map_d textures\alpha.png
+
+newmtl transmitted
+Tr 0.4
+
+newmtl defaults
+`
+
+// parseTestMTL writes an MTL into a temporary folder and parses it
+func parseTestMTL(t *testing.T, mtl string) (*objParser, string, error) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "mtl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.mtl")
+	if err := ioutil.WriteFile(path, []byte(mtl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &objParser{model: newModel(filepath.Join(dir, "test.obj"),
+		Options{}), materials: map[string]*objMaterial{}}
+	return p, dir, p.parseMTL(path)
+}
+
+func approx(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func TestParseMTL(t *testing.T) {
+	p, dir, err := parseTestMTL(t, testMTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.materials) != 4 {
+		t.Fatalf("%d materials, want 4", len(p.materials))
+	}
+
+	tests := []struct {
+		name      string
+		diffuse   mgl32.Vec3
+		specular  mgl32.Vec3
+		opacity   float32
+		roughness float32
+		metallic  float32
+		alphaMode int
+		textures  []objTexture
+	}{
+		// Roughness comes from the shininess like the assimp loader
+		{"shiny", mgl32.Vec3{0.5, 0.25, 1}, mgl32.Vec3{0.2, 0.2, 0.2}, 1.0,
+			float32(math.Sqrt(2.0 / 100.0)), 0.0, mesh.ALPHA_OPAQUE,
+			[]objTexture{
+				{"texture_diffuse", filepath.Join(dir, "textures",
+					"shiny.png"), false},
+				{"texture_normal", filepath.Join(dir, "normal.png"), true},
+			}},
+		{"see through", mgl32.Vec3{1, 1, 1}, mgl32.Vec3{}, 0.25, 0.3, 0.7,
+			mesh.ALPHA_BLEND, []objTexture{
+				{"texture_diffuse", filepath.Join(dir, "glass.png"), false},
+				// Backslashes from Windows still find the file
+				{"texture_opacity", filepath.Join(dir, "textures",
+					"alpha.png"), false},
+			}},
+		{"transmitted", mgl32.Vec3{1, 1, 1}, mgl32.Vec3{}, 0.6,
+			float32(math.Sqrt(2.0 / 34.0)), 0.0, mesh.ALPHA_BLEND, nil},
+		// Without Ns the default shininess of 32 is used
+		{"defaults", mgl32.Vec3{1, 1, 1}, mgl32.Vec3{}, 1.0,
+			float32(math.Sqrt(2.0 / 34.0)), 0.0, mesh.ALPHA_OPAQUE, nil},
+	}
+	for _, test := range tests {
+		m, ok := p.materials[test.name]
+		if !ok {
+			t.Errorf("%s: missing", test.name)
+			continue
+		}
+		mat := m.material
+		if mat.Name != test.name {
+			t.Errorf("%s: named %q", test.name, mat.Name)
+		}
+		if mat.Diffuse != test.diffuse || mat.Specular != test.specular {
+			t.Errorf("%s: diffuse %v and specular %v, want %v and %v",
+				test.name, mat.Diffuse, mat.Specular, test.diffuse,
+				test.specular)
+		}
+		if !approx(mat.Opacity, test.opacity) ||
+			!approx(mat.BaseColor[3], test.opacity) ||
+			mat.BaseColor.Vec3() != test.diffuse {
+			t.Errorf("%s: opacity %f and base colour %v, want %f", test.name,
+				mat.Opacity, mat.BaseColor, test.opacity)
+		}
+		if !approx(mat.Roughness, test.roughness) ||
+			!approx(mat.Metallic, test.metallic) {
+			t.Errorf("%s: roughness %f and metallic %f, want %f and %f",
+				test.name, mat.Roughness, mat.Metallic, test.roughness,
+				test.metallic)
+		}
+		if mat.AlphaMode != test.alphaMode {
+			t.Errorf("%s: alpha mode %d, want %d", test.name, mat.AlphaMode,
+				test.alphaMode)
+		}
+		if !reflect.DeepEqual(m.textures, test.textures) {
+			t.Errorf("%s: textures %+v, want %+v", test.name, m.textures,
+				test.textures)
+		}
+	}
+}
+
+func TestParseMTLErrors(t *testing.T) {
+	tests := []struct {
+		mtl  string
+		want string
+	}{
+		{"newmtl a\nKd x 0 0\n", "test.mtl:2:"},
+		{"newmtl a\n\nNs\n", "test.mtl:3: expected 1 numbers"},
+		{"newmtl a\nmap_Kd\n", "test.mtl:2: texture has no file"},
+	}
+	for _, test := range tests {
+		_, _, err := parseTestMTL(t, test.mtl)
+		if err == nil {
+			t.Errorf("%q: no error", test.mtl)
+		} else if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: error %q, want it to say %q", test.mtl, err,
+				test.want)
+		}
+	}
+
+	// Statements before the first material are ignored
+	p, _, err := parseTestMTL(t, "Kd x\nnewmtl a\n")
+	if err != nil || len(p.materials) != 1 {
+		t.Errorf("statements before newmtl gave %v", err)
+	}
+
+	// A library that isn't there leaves the default materials
+	if err := p.mtllib("missing.mtl"); err != nil {
+		t.Errorf("missing library: %v", err)
+	}
+}
+
+func TestParseTextureArgs(t *testing.T) {
+	tests := []struct {
+		args  string
+		path  string
+		clamp bool
+	}{
+		{"wood.png", "wood.png", false},
+		{"-clamp on wood.png", "wood.png", true},
+		{"-clamp off wood.png", "wood.png", false},
+		{"-bm 0.5 -clamp on bump.png", "bump.png", true},
+		{"-s 2 2 1 -clamp on -o 0.5 0.5 0 tiles.png", "tiles.png", true},
+		// The file is the last argument even when it's called -clamp
+		{"-clamp", "-clamp", false},
+		{"-clamp on", "on", false},
+	}
+	for _, test := range tests {
+		got, err := parseTextureArgs(strings.Fields(test.args))
+		if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+			continue
+		}
+		if got.path != test.path || got.clamp != test.clamp {
+			t.Errorf("%q: got %q clamped %t, want %q clamped %t", test.args,
+				got.path, got.clamp, test.path, test.clamp)
+		}
+	}
+	if _, err := parseTextureArgs(nil); err == nil {
+		t.Errorf("no arguments didn't error")
+	}
+}
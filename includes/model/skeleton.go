@@ -0,0 +1,56 @@
+package model
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/nicholasblaskey/go-learn-opengl/includes/animation"
+)
+
+// boneID gets the palette index of a bone, bones with the same name in
+// different meshes share an index
+func (model *Model) boneID(name string, offset mgl32.Mat4) int {
+	if model.boneIDs == nil {
+		model.boneIDs = map[string]int{}
+		model.boneOffsets = map[string]mgl32.Mat4{}
+	}
+	id, ok := model.boneIDs[name]
+	if !ok {
+		id = len(model.boneIDs)
+		model.boneIDs[name] = id
+		model.boneOffsets[name] = offset
+	}
+	return id
+}
+
+// buildSkeleton flattens the node tree into a skeleton for animating, only
+// when the model has bones or animations
+func (model *Model) buildSkeleton() error {
+	if len(model.boneIDs) == 0 && len(model.Animations) == 0 {
+		return nil
+	}
+
+	var joints []animation.Joint
+	var walk func(n *Node, parent int)
+	walk = func(n *Node, parent int) {
+		joint := animation.Joint{Name: n.Name, Parent: parent,
+			Transform: n.Transform, Bone: -1, Offset: mgl32.Ident4()}
+		if id, ok := model.boneIDs[n.Name]; ok {
+			joint.Bone = id
+			joint.Offset = model.boneOffsets[n.Name]
+		}
+		joints = append(joints, joint)
+
+		index := len(joints) - 1
+		for _, child := range n.Children {
+			walk(child, index)
+		}
+	}
+	walk(model.Root, -1)
+
+	skeleton, err := animation.NewSkeleton(joints)
+	if err != nil {
+		return err
+	}
+	model.Skeleton = skeleton
+	return nil
+}
@@ -0,0 +1,112 @@
+package shader
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// BinaryCacheDir turns on caching of linked program binaries when set.
+// Programs are keyed by their preprocessed source and the driver's vendor,
+// renderer and version strings, so a driver update just means recompiling.
+// If the driver rejects a cached binary the program is compiled as normal
+// and the cache entry is replaced.
+var BinaryCacheDir string = ""
+
+func binaryCacheKey(p program, codes []string) string {
+	h := sha256.New()
+	for _, name := range []uint32{gl.VENDOR, gl.RENDERER, gl.VERSION} {
+		h.Write([]byte(gl.GoStr(gl.GetString(name))))
+		h.Write([]byte{0})
+	}
+	if p.separable {
+		h.Write([]byte("separable"))
+	}
+	for i, st := range p.stages {
+		binary.Write(h, binary.LittleEndian, st.shaderType)
+		h.Write([]byte(codes[i]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func binaryCachePath(key string) string {
+	return filepath.Join(BinaryCacheDir, key+".bin")
+}
+
+// loadCachedBinary creates a program from the cached binary for key if
+// there is one and the driver accepts it
+func loadCachedBinary(key string, separable bool) (uint32, bool) {
+	data, err := ioutil.ReadFile(binaryCachePath(key))
+	// First 4 bytes are the binary format
+	if err != nil || len(data) <= 4 {
+		return 0, false
+	}
+	format := binary.LittleEndian.Uint32(data[:4])
+	programBinary := data[4:]
+
+	ID := gl.CreateProgram()
+	if separable {
+		gl.ProgramParameteri(ID, gl.PROGRAM_SEPARABLE, gl.TRUE)
+	}
+	gl.ProgramBinary(ID, format, gl.Ptr(programBinary),
+		int32(len(programBinary)))
+
+	var success int32
+	gl.GetProgramiv(ID, gl.LINK_STATUS, &success)
+	if success != gl.TRUE {
+		gl.DeleteProgram(ID)
+		return 0, false
+	}
+	return ID, true
+}
+
+// saveCachedBinary writes the binary of the linked program ID to the cache.
+// Failing to write the cache isn't fatal so errors are only logged in
+// Debug mode.
+func saveCachedBinary(key string, ID uint32) {
+	var length int32
+	gl.GetProgramiv(ID, gl.PROGRAM_BINARY_LENGTH, &length)
+	// Drivers without any binary formats report a zero length
+	if length == 0 {
+		return
+	}
+
+	data := make([]byte, 4+length)
+	var format uint32
+	gl.GetProgramBinary(ID, length, &length, &format, gl.Ptr(data[4:]))
+	binary.LittleEndian.PutUint32(data[:4], format)
+	data = data[:4+length]
+
+	if err := writeFileAtomic(binaryCachePath(key), data); err != nil && Debug {
+		log.Printf("shader: could not write program binary cache: %v", err)
+	}
+}
+
+// writeFileAtomic writes to a temporary file and renames it into place so
+// another process never sees a partly written binary
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,135 @@
+package shader
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Compute is a compute shader program. LocalSize is the work group size
+// declared in the shader with layout (local_size_x = ...) in.
+type Compute struct {
+	Shader
+	LocalSize [3]uint32
+}
+
+// MakeCompute is like NewCompute but panics on any error
+func MakeCompute(computePath string) Compute {
+	c, err := NewCompute(computePath)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewCompute builds a compute program. Compute shaders need an OpenGL 4.3
+// context (or ARB_compute_shader), the chapters only ask glfw for 4.1 so
+// an error is returned when the context is too old.
+func NewCompute(computePath string) (Compute, error) {
+	if err := RequireVersion(4, 3, "GL_ARB_compute_shader"); err != nil {
+		return Compute{}, err
+	}
+
+	ID, err := loadProgram(
+		stage{gl.COMPUTE_SHADER, "COMPUTE", computePath, ""})
+	if err != nil {
+		return Compute{}, err
+	}
+
+	c := Compute{Shader: FromProgram(ID)}
+	var localSize [3]int32
+	gl.GetProgramiv(ID, gl.COMPUTE_WORK_GROUP_SIZE, &localSize[0])
+	for i := range localSize {
+		c.LocalSize[i] = uint32(localSize[i])
+	}
+
+	return c, nil
+}
+
+// Dispatch binds the program and runs x * y * z work groups
+func (c Compute) Dispatch(x, y, z uint32) {
+	c.Use()
+	gl.DispatchCompute(x, y, z)
+}
+
+// DispatchSize runs enough work groups to cover width * height * depth
+// invocations, e.g. one per texel of an image
+func (c Compute) DispatchSize(width, height, depth uint32) {
+	c.Dispatch(groups(width, c.LocalSize[0]),
+		groups(height, c.LocalSize[1]),
+		groups(depth, c.LocalSize[2]))
+}
+
+// DispatchIndirect reads the work group counts from the buffer bound to
+// gl.DISPATCH_INDIRECT_BUFFER at offset
+func (c Compute) DispatchIndirect(offset int) {
+	c.Use()
+	gl.DispatchComputeIndirect(offset)
+}
+
+func groups(size, localSize uint32) uint32 {
+	if localSize == 0 {
+		return size
+	}
+	return (size + localSize - 1) / localSize
+}
+
+// Barrier makes writes from earlier dispatches visible to the kinds of
+// access in bits (gl.SHADER_STORAGE_BARRIER_BIT and so on)
+func Barrier(bits uint32) {
+	gl.MemoryBarrier(bits)
+}
+
+// BufferBarrier is a Barrier for reading buffers written by a compute
+// shader, whether as vertex attributes, indices, uniforms, storage buffers
+// or draw commands
+func BufferBarrier() {
+	gl.MemoryBarrier(gl.VERTEX_ATTRIB_ARRAY_BARRIER_BIT |
+		gl.ELEMENT_ARRAY_BARRIER_BIT |
+		gl.UNIFORM_BARRIER_BIT |
+		gl.SHADER_STORAGE_BARRIER_BIT |
+		gl.COMMAND_BARRIER_BIT |
+		gl.BUFFER_UPDATE_BARRIER_BIT)
+}
+
+// ImageBarrier is a Barrier for reading images written by a compute shader,
+// whether through samplers, image loads or framebuffer attachments
+func ImageBarrier() {
+	gl.MemoryBarrier(gl.SHADER_IMAGE_ACCESS_BARRIER_BIT |
+		gl.TEXTURE_FETCH_BARRIER_BIT |
+		gl.TEXTURE_UPDATE_BARRIER_BIT |
+		gl.FRAMEBUFFER_BARRIER_BIT)
+}
+
+// RequireVersion returns an error unless the current context is at least
+// major.minor or supports one of the given extensions
+func RequireVersion(major, minor int32, extensions ...string) error {
+	var haveMajor, haveMinor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &haveMajor)
+	gl.GetIntegerv(gl.MINOR_VERSION, &haveMinor)
+	if haveMajor > major || (haveMajor == major && haveMinor >= minor) {
+		return nil
+	}
+
+	for _, ext := range extensions {
+		if HasExtension(ext) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("shader: need an OpenGL %d.%d context but have %d.%d,"+
+		" raise the glfw.ContextVersionMajor/Minor hints",
+		major, minor, haveMajor, haveMinor)
+}
+
+// HasExtension reports if the current context supports the named extension
+func HasExtension(name string) bool {
+	var count int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := uint32(0); i < uint32(count); i++ {
+		if gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i)) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package shader
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CompileError is returned when a shader stage fails to compile or a
+// program fails to link. Stage is VERTEX, FRAGMENT, GEOMETRY, TESS_CONTROL,
+// TESS_EVALUATION, COMPUTE or PROGRAM.
+type CompileError struct {
+	Stage       string
+	Path        string
+	Log         string
+	Diagnostics []Diagnostic
+}
+
+// Diagnostic is a single message out of the driver's info log. Line and
+// Column are zero when the driver didn't report them.
+type Diagnostic struct {
+	File     string
+	Source   int
+	Line     int
+	Column   int
+	Severity string
+	Message  string
+}
+
+func (e *CompileError) Error() string {
+	var b strings.Builder
+	if e.Path == "" {
+		fmt.Fprintf(&b, "shader: %s error", e.Stage)
+	} else {
+		fmt.Fprintf(&b, "shader: %s error in %s", e.Stage, e.Path)
+	}
+	for _, d := range e.Diagnostics {
+		b.WriteString("\n\t")
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return d.Message
+	}
+	// Shaders built from source strings have no file to point at
+	file := d.File
+	if file == "" {
+		file = strconv.Itoa(d.Source)
+	}
+	if d.Column == 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", file, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s",
+		file, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// Info log formats differ between drivers
+var (
+	// Mesa: 0:12(5): error: message
+	mesaLine = regexp.MustCompile(`^(\d+):(\d+)\((\d+)\): (\w+): (.*)$`)
+	// NVIDIA: 0(12) : error C1008: message
+	nvidiaLine = regexp.MustCompile(`^(\d+)\((\d+)\) : (\w+) (?:\w+: )?(.*)$`)
+	// AMD, Intel and Apple: ERROR: 0:12: message
+	amdLine = regexp.MustCompile(`^(\w+): (\d+):(\d+): (.*)$`)
+)
+
+func newCompileError(stageName, path, infoLog string) *CompileError {
+	e := &CompileError{Stage: stageName, Path: path, Log: infoLog}
+	e.Diagnostics = parseInfoLog(infoLog, path)
+	return e
+}
+
+// parseInfoLog splits a driver info log into diagnostics. Lines it can't
+// make sense of are kept with only Message set.
+func parseInfoLog(infoLog, path string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(infoLog, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		d := Diagnostic{File: path, Message: line}
+		if m := mesaLine.FindStringSubmatch(line); m != nil {
+			d.Source, _ = strconv.Atoi(m[1])
+			d.Line, _ = strconv.Atoi(m[2])
+			d.Column, _ = strconv.Atoi(m[3])
+			d.Severity = strings.ToLower(m[4])
+			d.Message = m[5]
+		} else if m := nvidiaLine.FindStringSubmatch(line); m != nil {
+			d.Source, _ = strconv.Atoi(m[1])
+			d.Line, _ = strconv.Atoi(m[2])
+			d.Severity = strings.ToLower(m[3])
+			d.Message = m[4]
+		} else if m := amdLine.FindStringSubmatch(line); m != nil {
+			d.Severity = strings.ToLower(m[1])
+			d.Source, _ = strconv.Atoi(m[2])
+			d.Line, _ = strconv.Atoi(m[3])
+			d.Message = m[4]
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
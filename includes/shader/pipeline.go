@@ -0,0 +1,78 @@
+package shader
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// MakeSeparable is like NewSeparable but panics on any error
+func MakeSeparable(shaderType uint32, path string) Shader {
+	s, err := NewSeparable(shaderType, path)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewSeparable builds a program out of a single stage (gl.VERTEX_SHADER,
+// gl.FRAGMENT_SHADER, ...) that can be mixed with others in a Pipeline
+func NewSeparable(shaderType uint32, path string) (Shader, error) {
+	ID, _, err := program{
+		stages:    []stage{{shaderType, stageName(shaderType), path, ""}},
+		separable: true,
+	}.build()
+	if err != nil {
+		return Shader{}, err
+	}
+	return FromProgram(ID), nil
+}
+
+func stageName(shaderType uint32) string {
+	switch shaderType {
+	case gl.VERTEX_SHADER:
+		return "VERTEX"
+	case gl.FRAGMENT_SHADER:
+		return "FRAGMENT"
+	case gl.GEOMETRY_SHADER:
+		return "GEOMETRY"
+	case gl.TESS_CONTROL_SHADER:
+		return "TESS_CONTROL"
+	case gl.TESS_EVALUATION_SHADER:
+		return "TESS_EVALUATION"
+	case gl.COMPUTE_SHADER:
+		return "COMPUTE"
+	}
+	return "UNKNOWN"
+}
+
+// Pipeline is a program pipeline object made up of separable programs
+type Pipeline struct {
+	ID uint32
+}
+
+func NewPipeline() Pipeline {
+	var p Pipeline
+	gl.GenProgramPipelines(1, &p.ID)
+	return p
+}
+
+// UseStages uses s for the given stages, a mask of gl.VERTEX_SHADER_BIT,
+// gl.FRAGMENT_SHADER_BIT and so on
+func (p Pipeline) UseStages(stages uint32, s Shader) {
+	gl.UseProgramStages(p.ID, stages, s.ID)
+}
+
+// Bind makes the pipeline current. Any program bound with Use takes
+// precedence over a pipeline, so that is unbound.
+func (p Pipeline) Bind() {
+	gl.UseProgram(0)
+	gl.BindProgramPipeline(p.ID)
+}
+
+// SetActive makes the setters of s apply to s while the pipeline is bound
+func (p Pipeline) SetActive(s Shader) {
+	gl.ActiveShaderProgram(p.ID, s.ID)
+}
+
+func (p Pipeline) Delete() {
+	gl.DeleteProgramPipelines(1, &p.ID)
+}
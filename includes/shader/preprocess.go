@@ -0,0 +1,120 @@
+package shader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	includeDirective = regexp.MustCompile(`^#\s*include\s+"([^"]+)"\s*(//.*)?$`)
+	onceDirective    = regexp.MustCompile(`^#\s*pragma\s+once\s*(//.*)?$`)
+	versionDirective = regexp.MustCompile(`^#\s*version\s`)
+)
+
+// Preprocess reads the GLSL file at path and expands any
+// #include "file.glsl" directives, resolved relative to the including file.
+// Files marked with #pragma once are only included the first time.
+//
+// #line directives are added around every include so the driver reports
+// errors against the original file and line. The source string number in
+// those directives indexes into the returned files, files[0] being path.
+func Preprocess(path string) (source string, files []string, err error) {
+	return PreprocessFunc(path, ioutil.ReadFile)
+}
+
+// PreprocessFunc is Preprocess but reads files with readFile
+func PreprocessFunc(path string,
+	readFile func(string) ([]byte, error)) (string, []string, error) {
+
+	p := preprocessor{
+		readFile: readFile,
+		index:    make(map[string]int),
+		once:     make(map[string]bool),
+	}
+	if err := p.include(filepath.Clean(path)); err != nil {
+		return "", p.files, err
+	}
+	return p.out.String(), p.files, nil
+}
+
+type preprocessor struct {
+	readFile func(string) ([]byte, error)
+
+	files []string
+	index map[string]int
+	stack []string
+	once  map[string]bool
+	out   strings.Builder
+}
+
+func (p *preprocessor) include(path string) error {
+	for _, s := range p.stack {
+		if s == path {
+			return fmt.Errorf("shader: include cycle %s",
+				strings.Join(append(p.stack, path), " -> "))
+		}
+	}
+	if p.once[path] {
+		return nil
+	}
+
+	codeBytes, err := p.readFile(path)
+	if err != nil {
+		return err
+	}
+
+	num, ok := p.index[path]
+	if !ok {
+		num = len(p.files)
+		p.index[path] = num
+		p.files = append(p.files, path)
+	}
+
+	isRoot := len(p.stack) == 0
+	p.stack = append(p.stack, path)
+	defer func() { p.stack = p.stack[:len(p.stack)-1] }()
+
+	if !isRoot {
+		fmt.Fprintf(&p.out, "#line 1 %d\n", num)
+	}
+
+	lines := strings.Split(string(codeBytes), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if m := includeDirective.FindStringSubmatch(trimmed); m != nil {
+			child := filepath.Join(filepath.Dir(path), m[1])
+			if err := p.include(child); err != nil {
+				return fmt.Errorf("%s:%d: %w", path, i+1, err)
+			}
+			// Back to the line after the include in this file
+			fmt.Fprintf(&p.out, "#line %d %d\n", i+2, num)
+			continue
+		}
+
+		// Blank out directives that can't be passed on so line numbers
+		// stay the same
+		if onceDirective.MatchString(trimmed) {
+			p.once[path] = true
+			line = ""
+		} else if !isRoot && versionDirective.MatchString(trimmed) {
+			line = ""
+		}
+
+		p.out.WriteString(line)
+		if i < len(lines)-1 {
+			p.out.WriteString("\n")
+		}
+	}
+
+	// Included files without a trailing newline still need one before the
+	// #line that follows them
+	if !isRoot && lines[len(lines)-1] != "" {
+		p.out.WriteString("\n")
+	}
+
+	return nil
+}
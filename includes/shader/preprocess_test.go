@@ -0,0 +1,248 @@
+package shader
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// memFiles reads from a map so the preprocessor can be tested without
+// anything on disk
+func memFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		code, ok := files[filepath.ToSlash(path)]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: path,
+				Err: os.ErrNotExist}
+		}
+		return []byte(code), nil
+	}
+}
+
+var lineDirective = regexp.MustCompile(`^#line (\d+) (\d+)$`)
+
+// checkLines follows the #line directives in source the way a driver would
+// and checks every other line is the line of the file it claims to be
+func checkLines(t *testing.T, source string, files []string,
+	contents map[string]string) {
+
+	t.Helper()
+	srcNum, lineNum := 0, 1
+	for _, line := range strings.Split(source, "\n") {
+		if m := lineDirective.FindStringSubmatch(line); m != nil {
+			lineNum, _ = strconv.Atoi(m[1])
+			srcNum, _ = strconv.Atoi(m[2])
+			continue
+		}
+		if srcNum >= len(files) {
+			t.Fatalf("#line points at source %d, only %d files", srcNum,
+				len(files))
+		}
+		original := strings.Split(contents[filepath.ToSlash(files[srcNum])],
+			"\n")
+		if lineNum > len(original) {
+			t.Fatalf("%s has no line %d", files[srcNum], lineNum)
+		}
+		want := original[lineNum-1]
+		trimmed := strings.TrimSpace(want)
+		if onceDirective.MatchString(trimmed) ||
+			(srcNum != 0 && versionDirective.MatchString(trimmed)) {
+			want = ""
+		}
+		if line != want {
+			t.Errorf("%s:%d is %q, want %q", files[srcNum], lineNum, line,
+				want)
+		}
+		lineNum++
+	}
+}
+
+func TestPreprocessNested(t *testing.T) {
+	contents := map[string]string{
+		"shaders/main.frag": "#version 330 core\n" +
+			"#include \"lib/lighting.glsl\"\n" +
+			"out vec4 FragColor;\n" +
+			"void main() { FragColor = vec4(light(), 1.0); }\n",
+		"shaders/lib/lighting.glsl": "#version 330 core\n" +
+			"#include \"common.glsl\" // relative to lib\n" +
+			"vec3 light() { return vec3(PI); }",
+		"shaders/lib/common.glsl": "const float PI = 3.14159;\n",
+	}
+	source, files, err := PreprocessFunc("shaders/main.frag",
+		memFiles(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFiles := []string{"shaders/main.frag", "shaders/lib/lighting.glsl",
+		"shaders/lib/common.glsl"}
+	if len(files) != len(wantFiles) {
+		t.Fatalf("files %v, want %v", files, wantFiles)
+	}
+	for i := range files {
+		if filepath.ToSlash(files[i]) != wantFiles[i] {
+			t.Errorf("files[%d] = %s, want %s", i, files[i], wantFiles[i])
+		}
+	}
+
+	if !strings.HasPrefix(source, "#version 330 core\n") {
+		t.Errorf("source doesn't start with the root's #version:\n%s",
+			source)
+	}
+	if n := strings.Count(source, "#version"); n != 1 {
+		t.Errorf("source has %d #version lines, want 1", n)
+	}
+	if strings.Contains(source, "#include") {
+		t.Errorf("source still has an #include:\n%s", source)
+	}
+	checkLines(t, source, files, contents)
+}
+
+func TestPreprocessLineNumbers(t *testing.T) {
+	contents := map[string]string{
+		"a.vert": "#version 330 core\n" +
+			"// comment\n" +
+			"#include \"b.glsl\"\n" +
+			"int a;\n" +
+			"#include \"c.glsl\"\n" +
+			"int a2;\n",
+		"b.glsl": "int b;\nint b2;\n",
+		"c.glsl": "int c;",
+	}
+	source, files, err := PreprocessFunc("a.vert", memFiles(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "#version 330 core\n" +
+		"// comment\n" +
+		"#line 1 1\n" +
+		"int b;\nint b2;\n" +
+		"#line 4 0\n" +
+		"int a;\n" +
+		"#line 1 2\n" +
+		"int c;\n" +
+		"#line 6 0\n" +
+		"int a2;\n"
+	if source != want {
+		t.Errorf("source is\n%s\nwant\n%s", source, want)
+	}
+	checkLines(t, source, files, contents)
+}
+
+func TestPreprocessOnce(t *testing.T) {
+	contents := map[string]string{
+		"main.frag": "#include \"a.glsl\"\n" +
+			"#include \"b.glsl\"\n" +
+			"#include \"common.glsl\"\n",
+		"a.glsl":      "#include \"common.glsl\"\nint a;\n",
+		"b.glsl":      "#include \"common.glsl\"\nint b;\n",
+		"common.glsl": "#pragma once\nint common;\n",
+	}
+	source, files, err := PreprocessFunc("main.frag", memFiles(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(source, "int common;"); n != 1 {
+		t.Errorf("common.glsl included %d times, want 1:\n%s", n, source)
+	}
+	if strings.Contains(source, "#pragma") {
+		t.Errorf("#pragma once was passed on:\n%s", source)
+	}
+	if len(files) != 4 {
+		t.Errorf("files %v, want each file once", files)
+	}
+	checkLines(t, source, files, contents)
+}
+
+func TestPreprocessGuards(t *testing.T) {
+	// Preprocessor guards are left for the driver, the file is included
+	// each time
+	contents := map[string]string{
+		"main.frag": "#include \"common.glsl\"\n#include \"common.glsl\"\n",
+		"common.glsl": "#ifndef COMMON\n#define COMMON\nint common;\n" +
+			"#endif\n",
+	}
+	source, files, err := PreprocessFunc("main.frag", memFiles(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(source, "#ifndef COMMON"); n != 2 {
+		t.Errorf("guarded file included %d times, want 2", n)
+	}
+	if len(files) != 2 {
+		t.Errorf("files %v, want common.glsl listed once", files)
+	}
+	checkLines(t, source, files, contents)
+}
+
+func TestPreprocessCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents map[string]string
+		want     string
+	}{
+		{"self", map[string]string{
+			"a.glsl": "#include \"a.glsl\"\n",
+		}, "a.glsl -> a.glsl"},
+		{"indirect", map[string]string{
+			"a.glsl": "int a;\n#include \"b.glsl\"\n",
+			"b.glsl": "#include \"c.glsl\"\n",
+			"c.glsl": "#include \"a.glsl\"\n",
+		}, "a.glsl -> b.glsl -> c.glsl -> a.glsl"},
+		{"once doesn't hide a cycle", map[string]string{
+			"a.glsl": "#pragma once\n#include \"b.glsl\"\n",
+			"b.glsl": "#include \"a.glsl\"\n",
+		}, "a.glsl -> b.glsl -> a.glsl"},
+	}
+	for _, test := range tests {
+		_, _, err := PreprocessFunc("a.glsl", memFiles(test.contents))
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), "include cycle "+test.want) {
+			t.Errorf("%s: error %q doesn't show the cycle %s", test.name,
+				err, test.want)
+		}
+	}
+}
+
+func TestPreprocessMissing(t *testing.T) {
+	contents := map[string]string{
+		"main.frag":  "int a;\n#include \"lib/a.glsl\"\n",
+		"lib/a.glsl": "\n\n#include \"missing.glsl\"\n",
+	}
+	_, _, err := PreprocessFunc("main.frag", memFiles(contents))
+	if err == nil {
+		t.Fatal("no error for a missing include")
+	}
+	if !os.IsNotExist(unwrapAll(err)) {
+		t.Errorf("error %q isn't a not exist error", err)
+	}
+	// The error says where each include came from
+	for _, want := range []string{"main.frag:2", "a.glsl:3",
+		"missing.glsl"} {
+		if !strings.Contains(filepath.ToSlash(err.Error()), want) {
+			t.Errorf("error %q doesn't mention %s", err, want)
+		}
+	}
+
+	if _, _, err := PreprocessFunc("nothing.frag",
+		memFiles(contents)); !os.IsNotExist(unwrapAll(err)) {
+		t.Errorf("missing root file gave %v", err)
+	}
+}
+
+func unwrapAll(err error) error {
+	for {
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return err
+		}
+		err = u.Unwrap()
+	}
+}
@@ -0,0 +1,148 @@
+package shader
+
+import (
+	"os"
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// How often Poll looks at the source files by default
+const DefaultPollInterval = 500 * time.Millisecond
+
+// Reloadable is a shader whose program is rebuilt whenever one of its
+// source files changes on disk. Call Poll once a frame from the GL thread.
+// Use the Reloadable itself rather than copying out its Shader, since the
+// program ID changes on every successful reload.
+type Reloadable struct {
+	Shader
+
+	// Minimum time between checking the files, zero checks every call
+	PollInterval time.Duration
+
+	prog     program
+	files    []string
+	modTimes map[string]time.Time
+	lastPoll time.Time
+}
+
+// NewReloadable builds a vertex + fragment program that can be hot reloaded
+func NewReloadable(vertexPath, fragmentPath string) (*Reloadable, error) {
+	return newReloadable(
+		stage{gl.VERTEX_SHADER, "VERTEX", vertexPath, ""},
+		stage{gl.FRAGMENT_SHADER, "FRAGMENT", fragmentPath, ""})
+}
+
+// NewReloadableGeom builds a vertex + fragment + geometry program that can
+// be hot reloaded
+func NewReloadableGeom(vertexPath, fragmentPath,
+	geoPath string) (*Reloadable, error) {
+
+	return newReloadable(
+		stage{gl.VERTEX_SHADER, "VERTEX", vertexPath, ""},
+		stage{gl.FRAGMENT_SHADER, "FRAGMENT", fragmentPath, ""},
+		stage{gl.GEOMETRY_SHADER, "GEOMETRY", geoPath, ""})
+}
+
+func newReloadable(stages ...stage) (*Reloadable, error) {
+	r := &Reloadable{PollInterval: DefaultPollInterval,
+		prog: program{stages: stages}}
+
+	ID, files, err := r.prog.build()
+	if err != nil {
+		return nil, err
+	}
+	r.Shader = FromProgram(ID)
+	r.files = files
+	r.modTimes = r.statFiles()
+	r.lastPoll = time.Now()
+
+	return r, nil
+}
+
+// Poll checks the source files for changes and reloads the program if any
+// changed. If the new source fails to build the last good program is kept
+// and the error is returned. Uniforms only set once at start up (such as
+// sampler units) need setting again when reloaded is true.
+func (r *Reloadable) Poll() (reloaded bool, err error) {
+	now := time.Now()
+	if now.Sub(r.lastPoll) < r.PollInterval {
+		return false, nil
+	}
+	r.lastPoll = now
+
+	modTimes := r.statFiles()
+	changed := false
+	for path, t := range modTimes {
+		if !t.Equal(r.modTimes[path]) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	// Record the new times even on failure so a broken file is only
+	// reported once per save
+	r.modTimes = modTimes
+
+	if err := r.Reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reload rebuilds the program from disk. The old program is only replaced
+// and deleted once the new one has linked.
+func (r *Reloadable) Reload() error {
+	ID, files, err := r.prog.build()
+	// Includes may have been added or removed even if the build failed
+	if len(files) > 0 {
+		r.files = files
+		r.modTimes = r.statFiles()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Keep the newly built program bound if the old one was in use
+	var current int32
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &current)
+
+	old := r.ID
+	autoUse := r.AutoUse
+	r.Shader = FromProgram(ID)
+	r.AutoUse = autoUse
+	if uint32(current) == old {
+		r.Use()
+	}
+	gl.DeleteProgram(old)
+
+	return nil
+}
+
+// Delete frees the current program
+func (r *Reloadable) Delete() {
+	gl.DeleteProgram(r.ID)
+	r.Shader = Shader{}
+}
+
+// statFiles gets the modification time of every source file, including
+// any #include'd ones. A file that can't be stat'd (e.g. mid-save) gets the
+// zero time.
+func (r *Reloadable) statFiles() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, st := range r.prog.stages {
+		modTimes[st.path] = time.Time{}
+	}
+	for _, path := range r.files {
+		modTimes[path] = time.Time{}
+	}
+
+	for path := range modTimes {
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		}
+	}
+	return modTimes
+}
@@ -4,177 +4,293 @@
 package shader
 
 import (
-	"io/ioutil"
-	"log"
-	"unsafe"
+	"strings"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/go-gl/mathgl/mgl32"
 )
 
 type Shader struct {
 	ID uint32
+
+	// AutoUse binds the program before every uniform is set so it doesn't
+	// need to be bound with Use first
+	AutoUse bool
+
+	// Active uniforms of the program by name, filled in after linking
+	uniforms map[string]uniform
+}
+
+// stage is a single shader object that makes up part of a program. The
+// code is read from path unless source is set.
+type stage struct {
+	shaderType uint32
+	name       string
+	path       string
+	source     string
 }
 
+// MakeShaders is like NewShader but panics on any error
 func MakeShaders(vertexPath string, fragmentPath string) Shader {
-	// Read the source code into strings
-	vertexCodeBytes, err := ioutil.ReadFile(vertexPath)
+	s, err := NewShader(vertexPath, fragmentPath)
 	if err != nil {
 		panic(err)
 	}
-	vertexCode := string(vertexCodeBytes)
+	return s
+}
 
-	fragmentCodeBytes, err := ioutil.ReadFile(fragmentPath)
+// MakeGeomShaders is like NewGeomShader but panics on any error
+func MakeGeomShaders(vertexPath, fragmentPath, geoPath string) Shader {
+	s, err := NewGeomShader(vertexPath, fragmentPath, geoPath)
 	if err != nil {
 		panic(err)
 	}
-	fragmentCode := string(fragmentCodeBytes)
+	return s
+}
 
-	// Compile the shaders
-	vertexShader := gl.CreateShader(gl.VERTEX_SHADER)
-	shaderSource, freeVertex := gl.Strs(vertexCode + "\x00")
-	defer freeVertex()
-	gl.ShaderSource(vertexShader, 1, shaderSource, nil)
-	gl.CompileShader(vertexShader)
-	checkCompileErrors(vertexShader, "VERTEX")
+// NewShader builds a vertex + fragment program. Compile and link failures
+// are returned as a *CompileError.
+func NewShader(vertexPath, fragmentPath string) (Shader, error) {
+	ID, err := loadProgram(
+		stage{gl.VERTEX_SHADER, "VERTEX", vertexPath, ""},
+		stage{gl.FRAGMENT_SHADER, "FRAGMENT", fragmentPath, ""})
+	if err != nil {
+		return Shader{}, err
+	}
+	return FromProgram(ID), nil
+}
 
-	fragmentShader := gl.CreateShader(gl.FRAGMENT_SHADER)
-	shaderSource, freeFragment := gl.Strs(fragmentCode + "\x00")
-	defer freeFragment()
-	gl.ShaderSource(fragmentShader, 1, shaderSource, nil)
-	gl.CompileShader(fragmentShader)
-	checkCompileErrors(fragmentShader, "FRAGMENT")
+// NewGeomShader builds a vertex + fragment + geometry program. Compile and
+// link failures are returned as a *CompileError.
+func NewGeomShader(vertexPath, fragmentPath, geoPath string) (Shader, error) {
+	ID, err := loadProgram(
+		stage{gl.VERTEX_SHADER, "VERTEX", vertexPath, ""},
+		stage{gl.FRAGMENT_SHADER, "FRAGMENT", fragmentPath, ""},
+		stage{gl.GEOMETRY_SHADER, "GEOMETRY", geoPath, ""})
+	if err != nil {
+		return Shader{}, err
+	}
+	return FromProgram(ID), nil
+}
 
-	// Create a shader program
-	ID := gl.CreateProgram()
-	gl.AttachShader(ID, vertexShader)
-	gl.AttachShader(ID, fragmentShader)
-	gl.LinkProgram(ID)
+// MakeTessShaders is like NewTessShader but panics on any error
+func MakeTessShaders(vertexPath, tessControlPath, tessEvalPath,
+	fragmentPath string) Shader {
 
-	checkCompileErrors(ID, "PROGRAM")
+	s, err := NewTessShader(vertexPath, tessControlPath, tessEvalPath,
+		fragmentPath)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
 
-	// Delete shaders
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
+// NewTessShader builds a vertex + tessellation control + tessellation
+// evaluation + fragment program. Draw with gl.PATCHES after setting the
+// patch size with SetPatchVertices.
+func NewTessShader(vertexPath, tessControlPath, tessEvalPath,
+	fragmentPath string) (Shader, error) {
+
+	ID, err := loadProgram(
+		stage{gl.VERTEX_SHADER, "VERTEX", vertexPath, ""},
+		stage{gl.TESS_CONTROL_SHADER, "TESS_CONTROL", tessControlPath, ""},
+		stage{gl.TESS_EVALUATION_SHADER, "TESS_EVALUATION", tessEvalPath, ""},
+		stage{gl.FRAGMENT_SHADER, "FRAGMENT", fragmentPath, ""})
+	if err != nil {
+		return Shader{}, err
+	}
+	return FromProgram(ID), nil
+}
 
-	return Shader{ID: ID}
+// SetPatchVertices sets how many vertices make up each patch drawn with
+// gl.PATCHES
+func SetPatchVertices(n int32) {
+	gl.PatchParameteri(gl.PATCH_VERTICES, n)
 }
 
-func MakeGeomShaders(vertexPath, fragmentPath, geoPath string) Shader {
-	// Read the source code into strings
-	vertexCodeBytes, err := ioutil.ReadFile(vertexPath)
+// MakeShadersFromSource is like NewShaderFromSource but panics on any error
+func MakeShadersFromSource(vertexCode, fragmentCode string) Shader {
+	s, err := NewShaderFromSource(vertexCode, fragmentCode)
 	if err != nil {
 		panic(err)
 	}
-	vertexCode := string(vertexCodeBytes)
+	return s
+}
+
+// MakeGeomShadersFromSource is like NewGeomShaderFromSource but panics on
+// any error
+func MakeGeomShadersFromSource(vertexCode, fragmentCode,
+	geoCode string) Shader {
 
-	fragmentCodeBytes, err := ioutil.ReadFile(fragmentPath)
+	s, err := NewGeomShaderFromSource(vertexCode, fragmentCode, geoCode)
 	if err != nil {
 		panic(err)
 	}
-	fragmentCode := string(fragmentCodeBytes)
+	return s
+}
 
-	geoCodeBytes, err := ioutil.ReadFile(geoPath)
+// NewShaderFromSource builds a vertex + fragment program from GLSL code
+// rather than files. #include isn't supported in code given this way.
+func NewShaderFromSource(vertexCode, fragmentCode string) (Shader, error) {
+	ID, err := loadProgram(
+		stage{gl.VERTEX_SHADER, "VERTEX", "", vertexCode},
+		stage{gl.FRAGMENT_SHADER, "FRAGMENT", "", fragmentCode})
 	if err != nil {
-		panic(err)
+		return Shader{}, err
 	}
-	geoCode := string(geoCodeBytes)
-
-	// Compile the shaders
-	vertexShader := gl.CreateShader(gl.VERTEX_SHADER)
-	shaderSource, freeVertex := gl.Strs(vertexCode + "\x00")
-	defer freeVertex()
-	gl.ShaderSource(vertexShader, 1, shaderSource, nil)
-	gl.CompileShader(vertexShader)
-	checkCompileErrors(vertexShader, "VERTEX")
-
-	fragmentShader := gl.CreateShader(gl.FRAGMENT_SHADER)
-	shaderSource, freeFragment := gl.Strs(fragmentCode + "\x00")
-	defer freeFragment()
-	gl.ShaderSource(fragmentShader, 1, shaderSource, nil)
-	gl.CompileShader(fragmentShader)
-	checkCompileErrors(fragmentShader, "FRAGMENT")
-
-	geoShader := gl.CreateShader(gl.GEOMETRY_SHADER)
-	shaderSource, freeGeo := gl.Strs(geoCode + "\x00")
-	defer freeGeo()
-	gl.ShaderSource(geoShader, 1, shaderSource, nil)
-	gl.CompileShader(geoShader)
-	checkCompileErrors(geoShader, "GEOMETRY")
-
-	// Create a shader program
-	ID := gl.CreateProgram()
-	gl.AttachShader(ID, vertexShader)
-	gl.AttachShader(ID, fragmentShader)
-	gl.AttachShader(ID, geoShader)
-	gl.LinkProgram(ID)
+	return FromProgram(ID), nil
+}
 
-	checkCompileErrors(ID, "PROGRAM")
+// NewGeomShaderFromSource builds a vertex + fragment + geometry program
+// from GLSL code rather than files
+func NewGeomShaderFromSource(vertexCode, fragmentCode,
+	geoCode string) (Shader, error) {
 
-	// Delete shaders
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-	gl.DeleteShader(geoShader)
+	ID, err := loadProgram(
+		stage{gl.VERTEX_SHADER, "VERTEX", "", vertexCode},
+		stage{gl.FRAGMENT_SHADER, "FRAGMENT", "", fragmentCode},
+		stage{gl.GEOMETRY_SHADER, "GEOMETRY", "", geoCode})
+	if err != nil {
+		return Shader{}, err
+	}
+	return FromProgram(ID), nil
+}
 
-	return Shader{ID: ID}
+// program is everything needed to build a program object
+type program struct {
+	stages []stage
+	// Link so the program can be used in a Pipeline
+	separable bool
 }
 
-func (s Shader) Use() {
-	gl.UseProgram(s.ID)
+func loadProgram(stages ...stage) (uint32, error) {
+	ID, _, err := program{stages: stages}.build()
+	return ID, err
 }
 
-func (s Shader) SetBool(name string, value bool) {
-	var intValue int32 = 0
-	if value {
-		intValue = 1
+// build compiles and links the program. It also returns every file that
+// was read, includes and all, even if building failed.
+func (p program) build() (uint32, []string, error) {
+	// Read each stage
+	var paths, allFiles []string
+	codes := make([]string, len(p.stages))
+	stageFiles := make([][]string, len(p.stages))
+	for i, st := range p.stages {
+		codes[i] = st.source
+		if st.source == "" {
+			var err error
+			codes[i], stageFiles[i], err = Preprocess(st.path)
+			allFiles = append(allFiles, stageFiles[i]...)
+			if err != nil {
+				return 0, allFiles, err
+			}
+			paths = append(paths, st.path)
+		}
 	}
 
-	gl.Uniform1i(gl.GetUniformLocation(s.ID, gl.Str(name+"\x00")),
-		intValue)
-}
+	// Skip compiling entirely if the driver takes a cached binary
+	var cacheKey string
+	if BinaryCacheDir != "" {
+		cacheKey = binaryCacheKey(p, codes)
+		if ID, ok := loadCachedBinary(cacheKey, p.separable); ok {
+			return ID, allFiles, nil
+		}
+	}
 
-func (s Shader) SetInt(name string, value int32) {
-	gl.Uniform1i(gl.GetUniformLocation(s.ID, gl.Str(name+"\x00")), value)
-}
+	var shaders []uint32
+	// Delete shaders once they are linked (or on failure)
+	defer func() {
+		for _, s := range shaders {
+			gl.DeleteShader(s)
+		}
+	}()
+
+	// Compile each stage
+	for i, st := range p.stages {
+		s, err := compileStage(st, codes[i], stageFiles[i])
+		if err != nil {
+			return 0, allFiles, err
+		}
+		shaders = append(shaders, s)
+	}
 
-func (s Shader) SetFloat(name string, value float32) {
-	gl.Uniform1f(gl.GetUniformLocation(s.ID, gl.Str(name+"\x00")), value)
-}
+	// Create a shader program
+	ID := gl.CreateProgram()
+	if p.separable {
+		gl.ProgramParameteri(ID, gl.PROGRAM_SEPARABLE, gl.TRUE)
+	}
+	if cacheKey != "" {
+		gl.ProgramParameteri(ID, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	}
+	for _, s := range shaders {
+		gl.AttachShader(ID, s)
+	}
+	gl.LinkProgram(ID)
+
+	if err := checkLinkErrors(ID, strings.Join(paths, ", ")); err != nil {
+		gl.DeleteProgram(ID)
+		return 0, allFiles, err
+	}
+
+	if cacheKey != "" {
+		saveCachedBinary(cacheKey, ID)
+	}
 
-func (s Shader) SetVec2(name string, value mgl32.Vec2) {
-	gl.Uniform2fv(gl.GetUniformLocation(s.ID, gl.Str(name+"\x00")),
-		1, &value[0])
+	return ID, allFiles, nil
 }
 
-func (s Shader) SetVec3(name string, value mgl32.Vec3) {
-	gl.Uniform3fv(gl.GetUniformLocation(s.ID, gl.Str(name+"\x00")),
-		1, &value[0])
+// compileStage compiles code for a single stage. files are the source
+// files the code came from, indexed by GLSL source string number.
+func compileStage(st stage, code string, files []string) (uint32, error) {
+	s := gl.CreateShader(st.shaderType)
+	shaderSource, free := gl.Strs(code + "\x00")
+	defer free()
+	gl.ShaderSource(s, 1, shaderSource, nil)
+	gl.CompileShader(s)
+
+	if err := checkCompileErrors(s, st, files); err != nil {
+		gl.DeleteShader(s)
+		return 0, err
+	}
+	return s, nil
 }
 
-func (s Shader) SetMat4(name string, value mgl32.Mat4) {
-	gl.UniformMatrix4fv(gl.GetUniformLocation(s.ID, gl.Str(name+"\x00")),
-		1, false, &value[0])
+func (s Shader) Use() {
+	gl.UseProgram(s.ID)
 }
 
-func checkCompileErrors(shader uint32, shaderType string) {
+func checkCompileErrors(shader uint32, st stage, files []string) error {
 	var success int32
-	var infoLog [1024]byte
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &success)
+	if success == gl.TRUE {
+		return nil
+	}
 
-	var status uint32 = gl.COMPILE_STATUS
-	stageMessage := "Shader_Compilation_error"
-	errorFunc := gl.GetShaderInfoLog
-	getIV := gl.GetShaderiv
-	if shaderType == "PROGRAM" {
-		status = gl.LINK_STATUS
-		stageMessage = "Program_link_error"
-		errorFunc = gl.GetProgramInfoLog
-		getIV = gl.GetProgramiv
+	var logLength int32
+	gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+	infoLog := make([]byte, logLength+1)
+	gl.GetShaderInfoLog(shader, logLength, nil, &infoLog[0])
+
+	e := newCompileError(st.name, st.path, gl.GoStr(&infoLog[0]))
+	// Point diagnostics at the included file they came from
+	for i, d := range e.Diagnostics {
+		if d.Line != 0 && d.Source < len(files) {
+			e.Diagnostics[i].File = files[d.Source]
+		}
 	}
+	return e
+}
 
-	getIV(shader, status, &success)
-	if success != 1 {
-		test := &success
-		errorFunc(shader, 1024, test, (*uint8)(unsafe.Pointer(&infoLog)))
-		log.Fatalln(stageMessage + shaderType + "|" + string(infoLog[:1024]) + "|")
+func checkLinkErrors(program uint32, paths string) error {
+	var success int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &success)
+	if success == gl.TRUE {
+		return nil
 	}
+
+	var logLength int32
+	gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+	infoLog := make([]byte, logLength+1)
+	gl.GetProgramInfoLog(program, logLength, nil, &infoLog[0])
+
+	return newCompileError("PROGRAM", paths, gl.GoStr(&infoLog[0]))
 }
@@ -0,0 +1,246 @@
+package shader
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Debug makes the setters log a warning (once per program and name) when
+// a uniform doesn't exist in the program or is set with the wrong type
+var Debug bool = false
+
+// uniform is an active uniform as reported by the driver. size is the
+// array length, 1 for non arrays.
+type uniform struct {
+	location int32
+	glType   uint32
+	size     int32
+	warned   bool
+}
+
+// FromProgram wraps an already linked program, caching the locations and
+// types of its active uniforms
+func FromProgram(ID uint32) Shader {
+	s := Shader{ID: ID, uniforms: make(map[string]uniform)}
+
+	var count, maxLength int32
+	gl.GetProgramiv(ID, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(ID, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	nameBuf := make([]byte, maxLength+1)
+
+	for i := uint32(0); i < uint32(count); i++ {
+		var length, size int32
+		var glType uint32
+		gl.GetActiveUniform(ID, i, maxLength+1, &length, &size, &glType,
+			&nameBuf[0])
+		name := string(nameBuf[:length])
+
+		location := gl.GetUniformLocation(ID, gl.Str(name+"\x00"))
+		// Members of uniform blocks don't have a location
+		if location < 0 {
+			continue
+		}
+		s.uniforms[name] = uniform{location: location, glType: glType,
+			size: size}
+
+		// Arrays are reported as name[0], so make every element and the
+		// bare name work too
+		if strings.HasSuffix(name, "[0]") {
+			base := strings.TrimSuffix(name, "[0]")
+			s.uniforms[base] = s.uniforms[name]
+			for j := int32(1); j < size; j++ {
+				element := base + "[" + strconv.Itoa(int(j)) + "]"
+				location := gl.GetUniformLocation(ID, gl.Str(element+"\x00"))
+				s.uniforms[element] = uniform{location: location,
+					glType: glType, size: size - j}
+			}
+		}
+	}
+
+	return s
+}
+
+// location looks up name, checking its type against the accepted ones
+// when Debug is set. Unknown names give -1 which GL silently ignores.
+func (s Shader) location(name string, count int, accepted ...uint32) int32 {
+	if s.AutoUse {
+		gl.UseProgram(s.ID)
+	}
+
+	// Not built by this package so there is no cache to use
+	if s.uniforms == nil {
+		return gl.GetUniformLocation(s.ID, gl.Str(name+"\x00"))
+	}
+
+	u, ok := s.uniforms[name]
+	if !ok {
+		if Debug {
+			// Remember the warning with a placeholder entry
+			s.uniforms[name] = uniform{location: -1, warned: true}
+			log.Printf("shader: program %d has no active uniform %q",
+				s.ID, name)
+		}
+		return -1
+	}
+	if !Debug || u.warned {
+		return u.location
+	}
+
+	typeOk := false
+	for _, t := range accepted {
+		if t == u.glType || (t == gl.SAMPLER_2D && isSampler(u.glType)) {
+			typeOk = true
+		}
+	}
+	if !typeOk {
+		u.warned = true
+		log.Printf("shader: uniform %q in program %d has type 0x%X not 0x%X",
+			name, s.ID, u.glType, accepted[0])
+	} else if int32(count) > u.size {
+		u.warned = true
+		log.Printf("shader: setting %d values of uniform %q in program %d"+
+			" but it only has %d", count, name, s.ID, u.size)
+	}
+	s.uniforms[name] = u
+
+	return u.location
+}
+
+func isSampler(glType uint32) bool {
+	switch glType {
+	case gl.SAMPLER_1D, gl.SAMPLER_2D, gl.SAMPLER_3D, gl.SAMPLER_CUBE,
+		gl.SAMPLER_1D_SHADOW, gl.SAMPLER_2D_SHADOW, gl.SAMPLER_CUBE_SHADOW,
+		gl.SAMPLER_1D_ARRAY, gl.SAMPLER_2D_ARRAY, gl.SAMPLER_1D_ARRAY_SHADOW,
+		gl.SAMPLER_2D_ARRAY_SHADOW, gl.SAMPLER_2D_MULTISAMPLE,
+		gl.SAMPLER_2D_MULTISAMPLE_ARRAY, gl.SAMPLER_BUFFER,
+		gl.SAMPLER_2D_RECT, gl.SAMPLER_2D_RECT_SHADOW,
+		gl.SAMPLER_CUBE_MAP_ARRAY, gl.SAMPLER_CUBE_MAP_ARRAY_SHADOW,
+		gl.INT_SAMPLER_2D, gl.INT_SAMPLER_3D, gl.INT_SAMPLER_CUBE,
+		gl.INT_SAMPLER_2D_ARRAY, gl.UNSIGNED_INT_SAMPLER_2D,
+		gl.UNSIGNED_INT_SAMPLER_3D, gl.UNSIGNED_INT_SAMPLER_CUBE,
+		gl.UNSIGNED_INT_SAMPLER_2D_ARRAY:
+		return true
+	}
+	return false
+}
+
+func (s Shader) SetBool(name string, value bool) {
+	var intValue int32 = 0
+	if value {
+		intValue = 1
+	}
+
+	gl.Uniform1i(s.location(name, 1, gl.BOOL, gl.INT), intValue)
+}
+
+// SetInt also sets sampler units
+func (s Shader) SetInt(name string, value int32) {
+	gl.Uniform1i(s.location(name, 1, gl.INT, gl.BOOL, gl.SAMPLER_2D), value)
+}
+
+func (s Shader) SetUint(name string, value uint32) {
+	gl.Uniform1ui(s.location(name, 1, gl.UNSIGNED_INT, gl.BOOL), value)
+}
+
+func (s Shader) SetFloat(name string, value float32) {
+	gl.Uniform1f(s.location(name, 1, gl.FLOAT), value)
+}
+
+func (s Shader) SetVec2(name string, value mgl32.Vec2) {
+	gl.Uniform2fv(s.location(name, 1, gl.FLOAT_VEC2), 1, &value[0])
+}
+
+func (s Shader) SetVec3(name string, value mgl32.Vec3) {
+	gl.Uniform3fv(s.location(name, 1, gl.FLOAT_VEC3), 1, &value[0])
+}
+
+func (s Shader) SetVec4(name string, value mgl32.Vec4) {
+	gl.Uniform4fv(s.location(name, 1, gl.FLOAT_VEC4), 1, &value[0])
+}
+
+func (s Shader) SetMat2(name string, value mgl32.Mat2) {
+	gl.UniformMatrix2fv(s.location(name, 1, gl.FLOAT_MAT2),
+		1, false, &value[0])
+}
+
+func (s Shader) SetMat3(name string, value mgl32.Mat3) {
+	gl.UniformMatrix3fv(s.location(name, 1, gl.FLOAT_MAT3),
+		1, false, &value[0])
+}
+
+func (s Shader) SetMat4(name string, value mgl32.Mat4) {
+	gl.UniformMatrix4fv(s.location(name, 1, gl.FLOAT_MAT4),
+		1, false, &value[0])
+}
+
+// The array setters start at name, which can be either the array itself or
+// one of its elements, and set len(values) elements. Empty slices are
+// ignored.
+
+func (s Shader) SetIntArray(name string, values []int32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.Uniform1iv(s.location(name, len(values), gl.INT, gl.BOOL,
+		gl.SAMPLER_2D), int32(len(values)), &values[0])
+}
+
+func (s Shader) SetUintArray(name string, values []uint32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.Uniform1uiv(s.location(name, len(values), gl.UNSIGNED_INT, gl.BOOL),
+		int32(len(values)), &values[0])
+}
+
+func (s Shader) SetFloatArray(name string, values []float32) {
+	if len(values) == 0 {
+		return
+	}
+	gl.Uniform1fv(s.location(name, len(values), gl.FLOAT),
+		int32(len(values)), &values[0])
+}
+
+func (s Shader) SetVec2Array(name string, values []mgl32.Vec2) {
+	if len(values) == 0 {
+		return
+	}
+	gl.Uniform2fv(s.location(name, len(values), gl.FLOAT_VEC2),
+		int32(len(values)), &values[0][0])
+}
+
+func (s Shader) SetVec3Array(name string, values []mgl32.Vec3) {
+	if len(values) == 0 {
+		return
+	}
+	gl.Uniform3fv(s.location(name, len(values), gl.FLOAT_VEC3),
+		int32(len(values)), &values[0][0])
+}
+
+func (s Shader) SetVec4Array(name string, values []mgl32.Vec4) {
+	if len(values) == 0 {
+		return
+	}
+	gl.Uniform4fv(s.location(name, len(values), gl.FLOAT_VEC4),
+		int32(len(values)), &values[0][0])
+}
+
+func (s Shader) SetMat3Array(name string, values []mgl32.Mat3) {
+	if len(values) == 0 {
+		return
+	}
+	gl.UniformMatrix3fv(s.location(name, len(values), gl.FLOAT_MAT3),
+		int32(len(values)), false, &values[0][0])
+}
+
+func (s Shader) SetMat4Array(name string, values []mgl32.Mat4) {
+	if len(values) == 0 {
+		return
+	}
+	gl.UniformMatrix4fv(s.location(name, len(values), gl.FLOAT_MAT4),
+		int32(len(values)), false, &values[0][0])
+}
@@ -0,0 +1,376 @@
+package texture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/bits"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// The sRGB S3TC formats come from EXT_texture_sRGB which the gl package
+// doesn't have
+const (
+	COMPRESSED_SRGB_S3TC_DXT1_EXT       = 0x8C4C
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT = 0x8C4D
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT = 0x8C4E
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT = 0x8C4F
+)
+
+// Format is how a container's texels are stored
+type Format struct {
+	InternalFormat uint32
+	// Format and Type are what uncompressed texels are uploaded as
+	Format uint32
+	Type   uint32
+	// Size is the bytes in a texel, or in a 4x4 block of compressed texels
+	Size       int
+	Compressed bool
+}
+
+func compressedFormat(internalFormat uint32, blockSize int) Format {
+	return Format{InternalFormat: internalFormat, Size: blockSize,
+		Compressed: true}
+}
+
+func pixelFormat(internalFormat, format, glType uint32, size int) Format {
+	return Format{InternalFormat: internalFormat, Format: format,
+		Type: glType, Size: size}
+}
+
+// The largest containers accepted, well past what drivers allow for
+// GL_MAX_TEXTURE_SIZE and GL_MAX_ARRAY_TEXTURE_LAYERS. Headers claiming
+// more are broken or hostile.
+const MAX_SIZE = 1 << 16
+const MAX_LAYERS = 1 << 16
+
+// ImageSize is the bytes in an image of width by height texels
+func (f Format) ImageSize(width, height int) int {
+	if f.Compressed {
+		return ((width + 3) / 4) * ((height + 3) / 4) * f.Size
+	}
+	return width * height * f.Size
+}
+
+// Container is a texture read from a KTX, KTX2 or DDS file, its whole mip
+// chain for every array layer and cubemap face, compressed or not
+type Container struct {
+	Width  int
+	Height int
+	// Layers is 0 for textures that aren't arrays
+	Layers int
+	// Faces is 6 for cubemaps and 1 otherwise
+	Faces  int
+	Format Format
+	// Levels[level][layer*Faces+face] are the images, largest level
+	// first. Rows are tightly packed from the top.
+	Levels [][][]byte
+}
+
+// LevelSize is the width and height of a mip level
+func (c *Container) LevelSize(level int) (int, int) {
+	return max(1, c.Width>>uint(level)), max(1, c.Height>>uint(level))
+}
+
+// Target is the texture target the container uploads to
+func (c *Container) Target() uint32 {
+	switch {
+	case c.Layers > 0 && c.Faces == 6:
+		return gl.TEXTURE_CUBE_MAP_ARRAY
+	case c.Layers > 0:
+		return gl.TEXTURE_2D_ARRAY
+	case c.Faces == 6:
+		return gl.TEXTURE_CUBE_MAP
+	}
+	return gl.TEXTURE_2D
+}
+
+// images is how many images each level has
+func (c *Container) images() int {
+	return max(1, c.Layers) * c.Faces
+}
+
+// checkHeader rejects sizes, layers and levels that can't be right before
+// anything is allocated for them. Every image of every level takes at least
+// a byte of data, which bounds how many there can be.
+func checkHeader(kind string, width, height, layers, faces, levels,
+	dataSize int) error {
+
+	if width < 1 || height < 1 || width > MAX_SIZE || height > MAX_SIZE {
+		return fmt.Errorf("texture: %s is %dx%d, at most %dx%d is supported",
+			kind, width, height, MAX_SIZE, MAX_SIZE)
+	}
+	if levels > bits.Len(uint(max(width, height))) {
+		return fmt.Errorf("texture: %s has %d mip levels, more than a %dx%d "+
+			"image has", kind, levels, width, height)
+	}
+	if layers < 0 || layers > MAX_LAYERS {
+		return fmt.Errorf("texture: %s has %d array layers", kind, layers)
+	}
+	if max(1, layers)*faces*max(1, levels) > dataSize {
+		return fmt.Errorf("texture: %s has more images than data", kind)
+	}
+	return nil
+}
+
+// IsContainer is whether a path is a KTX, KTX2 or DDS file going by its
+// extension
+func IsContainer(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ktx", ".ktx2", ".dds":
+		return true
+	}
+	return false
+}
+
+// DecodeContainerFile reads a KTX, KTX2 or DDS file
+func DecodeContainerFile(path string) (*Container, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeContainer(data)
+}
+
+// DecodeContainer reads a KTX, KTX2 or DDS file going by its magic bytes
+func DecodeContainer(data []byte) (*Container, error) {
+	var c *Container
+	var err error
+	switch {
+	case bytes.HasPrefix(data, ktxIdentifier):
+		c, err = decodeKTX(data)
+	case bytes.HasPrefix(data, ktx2Identifier):
+		c, err = decodeKTX2(data)
+	case bytes.HasPrefix(data, []byte("DDS ")):
+		c, err = decodeDDS(data)
+	default:
+		return nil, errors.New("texture: not a KTX, KTX2 or DDS file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Width <= 0 || c.Height <= 0 || len(c.Levels) == 0 {
+		return nil, errors.New("texture: container has no images")
+	}
+	return c, nil
+}
+
+// LoadContainer reads a KTX, KTX2 or DDS file into a texture, see
+// UploadContainer
+func LoadContainer(path string, opts Options) (Texture, error) {
+	c, err := DecodeContainerFile(path)
+	if err != nil {
+		return Texture{}, fmt.Errorf("texture: %s: %v", path, err)
+	}
+	t, err := UploadContainer(c, opts)
+	if err != nil {
+		return Texture{}, fmt.Errorf("texture: %s: %v", path, err)
+	}
+	return t, nil
+}
+
+// UploadContainer makes a texture from a container with the mip chain it
+// has. Compressed blocks go straight to the GPU when the driver has the
+// format, otherwise they're decompressed first. Flip is ignored, the
+// images are used as stored. Mipmaps are only generated for single level
+// uncompressed containers.
+func UploadContainer(c *Container, opts Options) (Texture, error) {
+	internalFormat := c.Format.InternalFormat
+	if opts.SRGB {
+		srgb, ok := srgbFormats[internalFormat]
+		if !ok && !isSRGB(internalFormat) {
+			return Texture{}, fmt.Errorf("no sRGB version of format 0x%x",
+				internalFormat)
+		}
+		if ok {
+			internalFormat = srgb
+		}
+	}
+	if c.Format.Compressed && !CompressedSupported(internalFormat) {
+		decompressed, err := Decompress(c)
+		if err != nil {
+			return Texture{}, err
+		}
+		c = decompressed
+		internalFormat = c.Format.InternalFormat
+		if opts.SRGB && !isSRGB(internalFormat) {
+			internalFormat = srgbFormats[internalFormat]
+		}
+	}
+	if !c.Format.Compressed && opts.InternalFormat != 0 {
+		internalFormat = uint32(opts.InternalFormat)
+	}
+
+	target := c.Target()
+	t := Texture{Width: c.Width, Height: c.Height,
+		InternalFormat: int32(internalFormat), Target: target}
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(target, t.ID)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	for level := range c.Levels {
+		w, h := c.LevelSize(level)
+		c.texImage(target, level, w, h, internalFormat)
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	mipmapped := len(c.Levels) > 1
+	if !mipmapped && !opts.NoMipmaps && !c.Format.Compressed {
+		gl.GenerateMipmap(target)
+		mipmapped = true
+	} else {
+		// Chains can stop short of 1x1
+		gl.TexParameteri(target, gl.TEXTURE_MAX_LEVEL,
+			int32(len(c.Levels)-1))
+	}
+
+	wrap := int32(gl.REPEAT)
+	if c.Faces == 6 {
+		wrap = gl.CLAMP_TO_EDGE
+	}
+	minFilter := opts.MinFilter
+	if minFilter == 0 {
+		minFilter = gl.LINEAR
+		if mipmapped && !opts.NoMipmaps {
+			minFilter = gl.LINEAR_MIPMAP_LINEAR
+		}
+	}
+	SetParameters(target, orDefault(opts.WrapS, wrap),
+		orDefault(opts.WrapT, wrap), minFilter,
+		orDefault(opts.MagFilter, gl.LINEAR), opts.Anisotropy)
+	if c.Faces == 6 {
+		gl.TexParameteri(target, gl.TEXTURE_WRAP_R, orDefault(opts.WrapS,
+			wrap))
+	}
+	return t, nil
+}
+
+// texImage uploads one level of every image
+func (c *Container) texImage(target uint32, level, w, h int,
+	internalFormat uint32) {
+
+	images := c.Levels[level]
+	switch target {
+	case gl.TEXTURE_2D:
+		c.texImage2D(gl.TEXTURE_2D, level, w, h, internalFormat, images[0])
+	case gl.TEXTURE_CUBE_MAP:
+		for face, data := range images {
+			c.texImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), level,
+				w, h, internalFormat, data)
+		}
+	default:
+		// Array layers, and the faces of each for cubemap arrays, are one
+		// 3D image
+		data := bytes.Join(images, nil)
+		if c.Format.Compressed {
+			gl.CompressedTexImage3D(target, int32(level), internalFormat,
+				int32(w), int32(h), int32(len(images)), 0, int32(len(data)),
+				gl.Ptr(data))
+		} else {
+			gl.TexImage3D(target, int32(level), int32(internalFormat),
+				int32(w), int32(h), int32(len(images)), 0, c.Format.Format,
+				c.Format.Type, gl.Ptr(data))
+		}
+	}
+}
+
+func (c *Container) texImage2D(target uint32, level, w, h int,
+	internalFormat uint32, data []byte) {
+
+	if c.Format.Compressed {
+		gl.CompressedTexImage2D(target, int32(level), internalFormat,
+			int32(w), int32(h), 0, int32(len(data)), gl.Ptr(data))
+	} else {
+		gl.TexImage2D(target, int32(level), int32(internalFormat), int32(w),
+			int32(h), 0, c.Format.Format, c.Format.Type, gl.Ptr(data))
+	}
+}
+
+// srgbFormats are the sRGB versions of formats that have one
+var srgbFormats = map[uint32]uint32{
+	gl.RGB8:                                     gl.SRGB8,
+	gl.RGBA8:                                    gl.SRGB8_ALPHA8,
+	gl.COMPRESSED_RGB_S3TC_DXT1_EXT:             COMPRESSED_SRGB_S3TC_DXT1_EXT,
+	gl.COMPRESSED_RGBA_S3TC_DXT1_EXT:            COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT,
+	gl.COMPRESSED_RGBA_S3TC_DXT3_EXT:            COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT,
+	gl.COMPRESSED_RGBA_S3TC_DXT5_EXT:            COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT,
+	gl.COMPRESSED_RGBA_BPTC_UNORM_ARB:           gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB,
+	gl.COMPRESSED_RGB8_ETC2:                     gl.COMPRESSED_SRGB8_ETC2,
+	gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2: gl.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+	gl.COMPRESSED_RGBA8_ETC2_EAC:                gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC,
+}
+
+func isSRGB(internalFormat uint32) bool {
+	for _, srgb := range srgbFormats {
+		if srgb == internalFormat {
+			return true
+		}
+	}
+	return false
+}
+
+// extensionFormats are the compressed formats each extension adds, as
+// drivers don't always list them all in COMPRESSED_TEXTURE_FORMATS
+var extensionFormats = map[string][]uint32{
+	"GL_EXT_texture_compression_s3tc": {gl.COMPRESSED_RGB_S3TC_DXT1_EXT,
+		gl.COMPRESSED_RGBA_S3TC_DXT1_EXT, gl.COMPRESSED_RGBA_S3TC_DXT3_EXT,
+		gl.COMPRESSED_RGBA_S3TC_DXT5_EXT},
+	"GL_EXT_texture_sRGB": {COMPRESSED_SRGB_S3TC_DXT1_EXT,
+		COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT,
+		COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT,
+		COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT},
+	"GL_ARB_texture_compression_bptc": {gl.COMPRESSED_RGBA_BPTC_UNORM_ARB,
+		gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB,
+		gl.COMPRESSED_RGB_BPTC_SIGNED_FLOAT_ARB,
+		gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT_ARB},
+	"GL_ARB_ES3_compatibility": {gl.COMPRESSED_RGB8_ETC2,
+		gl.COMPRESSED_SRGB8_ETC2, gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+		gl.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+		gl.COMPRESSED_RGBA8_ETC2_EAC, gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC,
+		gl.COMPRESSED_R11_EAC, gl.COMPRESSED_SIGNED_R11_EAC,
+		gl.COMPRESSED_RG11_EAC, gl.COMPRESSED_SIGNED_RG11_EAC},
+}
+
+var compressedFormats map[uint32]bool
+
+// CompressedSupported is whether the driver takes a compressed format as
+// it is. The current context is asked the first time.
+func CompressedSupported(internalFormat uint32) bool {
+	if compressedFormats == nil {
+		// RGTC is core since OpenGL 3.0
+		compressedFormats = map[uint32]bool{
+			gl.COMPRESSED_RED_RGTC1: true, gl.COMPRESSED_SIGNED_RED_RGTC1: true,
+			gl.COMPRESSED_RG_RGTC2: true, gl.COMPRESSED_SIGNED_RG_RGTC2: true,
+		}
+
+		var n int32
+		gl.GetIntegerv(gl.NUM_COMPRESSED_TEXTURE_FORMATS, &n)
+		if n > 0 {
+			listed := make([]int32, n)
+			gl.GetIntegerv(gl.COMPRESSED_TEXTURE_FORMATS, &listed[0])
+			for _, format := range listed {
+				compressedFormats[uint32(format)] = true
+			}
+		}
+
+		gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+		for i := uint32(0); i < uint32(n); i++ {
+			name := gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i))
+			for _, format := range extensionFormats[name] {
+				compressedFormats[format] = true
+			}
+		}
+	}
+	return compressedFormats[internalFormat]
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
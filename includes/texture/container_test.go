@@ -0,0 +1,264 @@
+package texture
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// The test containers are 4x4 RGBA8 with a full mip chain, their images
+// filled with the level number
+
+func levelData(w, h, size int, level int) []byte {
+	data := make([]byte, w*h*size)
+	for i := range data {
+		data[i] = byte(level)
+	}
+	return data
+}
+
+func makeKTX(levels int) []byte {
+	data := append([]byte(nil), ktxIdentifier...)
+	header := make([]byte, 52)
+	put := func(i int, v uint32) {
+		binary.LittleEndian.PutUint32(header[i*4:], v)
+	}
+	put(0, 0x04030201)
+	put(1, gl.UNSIGNED_BYTE)
+	put(2, 1)
+	put(3, gl.RGBA)
+	put(4, gl.RGBA8)
+	put(5, gl.RGBA)
+	put(6, 4)
+	put(7, 4)
+	put(10, 1)
+	put(11, uint32(levels))
+	data = append(data, header...)
+
+	for level := 0; level < levels; level++ {
+		w := max(1, 4>>uint(level))
+		image := levelData(w, w, 4, level)
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], uint32(len(image)))
+		data = append(data, size[:]...)
+		data = append(data, image...)
+	}
+	return data
+}
+
+func makeKTX2(levels int) []byte {
+	data := append([]byte(nil), ktx2Identifier...)
+	header := make([]byte, 68+levels*24)
+	put := func(i int, v uint32) {
+		binary.LittleEndian.PutUint32(header[i*4:], v)
+	}
+	put(0, 37) // VK_FORMAT_R8G8B8A8_UNORM
+	put(1, 1)
+	put(2, 4)
+	put(3, 4)
+	put(6, 1)
+	put(7, uint32(levels))
+
+	var images []byte
+	offset := len(data) + len(header)
+	for level := 0; level < levels; level++ {
+		w := max(1, 4>>uint(level))
+		image := levelData(w, w, 4, level)
+		index := header[68+level*24:]
+		binary.LittleEndian.PutUint64(index, uint64(offset+len(images)))
+		binary.LittleEndian.PutUint64(index[8:], uint64(len(image)))
+		binary.LittleEndian.PutUint64(index[16:], uint64(len(image)))
+		images = append(images, image...)
+	}
+	return append(append(data, header...), images...)
+}
+
+func makeDDS(fourCC string, levels int) []byte {
+	data := make([]byte, ddsHeaderSize)
+	copy(data, "DDS ")
+	put := func(i int, v uint32) {
+		binary.LittleEndian.PutUint32(data[4+i*4:], v)
+	}
+	put(0, 124)
+	put(1, ddsMipmapCount)
+	put(2, 4)
+	put(3, 4)
+	put(6, uint32(levels))
+	put(18, 32)
+	if fourCC == "" {
+		put(19, ddsRGB|ddsAlphaPixels)
+		put(21, 32)
+		put(22, 0xff)
+	} else {
+		put(19, ddsFourCC)
+		copy(data[ddsFourCCOffset:], fourCC)
+	}
+	if fourCC == "DX10" {
+		dx10 := make([]byte, ddsDX10Size)
+		binary.LittleEndian.PutUint32(dx10, 28) // R8G8B8A8_UNORM
+		binary.LittleEndian.PutUint32(dx10[4:], ddsTexture2D)
+		data = append(data, dx10...)
+	}
+
+	for level := 0; level < levels; level++ {
+		w := max(1, 4>>uint(level))
+		if fourCC == "DXT5" {
+			data = append(data, levelData(1, 1, 16, level)...)
+		} else {
+			data = append(data, levelData(w, w, 4, level)...)
+		}
+	}
+	return data
+}
+
+func TestDecodeContainers(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"KTX", makeKTX(3)},
+		{"KTX2", makeKTX2(3)},
+		{"DDS", makeDDS("", 3)},
+		{"DDS DX10", makeDDS("DX10", 3)},
+		{"DDS DXT5", makeDDS("DXT5", 3)},
+	}
+	for _, test := range tests {
+		c, err := DecodeContainer(test.data)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if c.Width != 4 || c.Height != 4 || len(c.Levels) != 3 {
+			t.Errorf("%s: %dx%d with %d levels", test.name, c.Width,
+				c.Height, len(c.Levels))
+			continue
+		}
+		for level, images := range c.Levels {
+			w, h := c.LevelSize(level)
+			if len(images) != 1 ||
+				len(images[0]) != c.Format.ImageSize(w, h) {
+				t.Errorf("%s: level %d is wrong", test.name, level)
+				continue
+			}
+			if images[0][0] != byte(level) {
+				t.Errorf("%s: level %d has level %d's data", test.name,
+					level, images[0][0])
+			}
+		}
+	}
+}
+
+// edit changes a copy of data
+func edit(data []byte, change func(data []byte)) []byte {
+	data = append([]byte(nil), data...)
+	change(data)
+	return data
+}
+
+func put32(offset int, v uint32) func([]byte) {
+	return func(data []byte) {
+		binary.LittleEndian.PutUint32(data[offset:], v)
+	}
+}
+
+func put64(offset int, v uint64) func([]byte) {
+	return func(data []byte) {
+		binary.LittleEndian.PutUint64(data[offset:], v)
+	}
+}
+
+func TestDecodeBrokenContainers(t *testing.T) {
+	ktx, ktx2 := makeKTX(3), makeKTX2(3)
+	dds, dx10, dxt5 := makeDDS("", 3), makeDDS("DX10", 3), makeDDS("DXT5", 3)
+
+	// Offsets of the header fields
+	ktxField := func(i int) int { return 16 + i*4 }
+	ktx2Field := func(i int) int { return 12 + i*4 }
+	ddsField := func(i int) int { return 4 + i*4 }
+	const ktx2Index = 80
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"KTX header cut short", ktx[:40], "cut short"},
+		{"KTX data cut short", ktx[:len(ktx)-1], "cut short"},
+		{"KTX huge width", edit(ktx, put32(ktxField(5), 0xffffffff)),
+			"supported"},
+		{"KTX huge height", edit(ktx, put32(ktxField(6), 1<<20)),
+			"supported"},
+		{"KTX too many levels", edit(ktx, put32(ktxField(10), 4)),
+			"mip levels"},
+		{"KTX huge levels", edit(ktx, put32(ktxField(10), 0xffffffff)),
+			"mip levels"},
+		{"KTX huge layers", edit(ktx, put32(ktxField(8), 0xffffffff)),
+			"layers"},
+		{"KTX more layers than data", edit(ktx, put32(ktxField(8), 1000)),
+			"more images than data"},
+		{"KTX huge key values", edit(ktx, put32(ktxField(11), 0xfffffff0)),
+			"more images than data"},
+
+		{"KTX2 header cut short", ktx2[:60], "cut short"},
+		{"KTX2 level index cut short", ktx2[:ktx2Index+30], "cut short"},
+		{"KTX2 data cut short", ktx2[:len(ktx2)-1], "cut short"},
+		{"KTX2 offset wraps", edit(ktx2, func(data []byte) {
+			put64(ktx2Index, 0xffffffffffffffff)(data)
+			put64(ktx2Index+8, 2)(data)
+		}), "cut short"},
+		{"KTX2 offset past the end", edit(ktx2,
+			put64(ktx2Index, uint64(len(ktx2)+1))), "cut short"},
+		{"KTX2 huge length", edit(ktx2,
+			put64(ktx2Index+8, 0xffffffffffffffff)), "cut short"},
+		{"KTX2 level too small", edit(ktx2, put64(ktx2Index+8, 63)),
+			"cut short"},
+		{"KTX2 huge size", edit(ktx2, func(data []byte) {
+			put32(ktx2Field(2), 0xffffffff)(data)
+			put32(ktx2Field(3), 0xffffffff)(data)
+		}), "supported"},
+		{"KTX2 too many levels", edit(ktx2, put32(ktx2Field(7), 4)),
+			"mip levels"},
+		{"KTX2 huge layers", edit(ktx2, put32(ktx2Field(5), 0xffffffff)),
+			"layers"},
+		{"KTX2 more layers than data", edit(ktx2,
+			put32(ktx2Field(5), 1000)), "more images than data"},
+
+		{"DDS header cut short", dds[:100], "cut short"},
+		{"DDS data cut short", dds[:len(dds)-1], "cut short"},
+		{"DX10 header cut short", dx10[:ddsHeaderSize+10], "cut short"},
+		{"DDS huge DXT5", edit(dxt5, func(data []byte) {
+			put32(ddsField(2), 0xffffffff)(data)
+			put32(ddsField(3), 0xffffffff)(data)
+		}), "supported"},
+		{"DDS huge width", edit(dds, put32(ddsField(3), 1<<17)),
+			"supported"},
+		{"DDS too many levels", edit(dds, put32(ddsField(6), 4)),
+			"mip levels"},
+		{"DDS huge levels", edit(dds, put32(ddsField(6), 0xffffffff)),
+			"mip levels"},
+		{"DX10 huge layers", edit(dx10,
+			put32(ddsHeaderSize+12, 0xffffffff)), "layers"},
+		{"DX10 more layers than data", edit(dx10,
+			put32(ddsHeaderSize+12, 1000)), "more images than data"},
+		{"DDS cubemap cut short", edit(dds,
+			put32(ddsField(27), ddsCubemap|ddsAllFaces)), "cut short"},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panicked: %v", test.name, r)
+				}
+			}()
+			_, err := DecodeContainer(test.data)
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			} else if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("%s: error %q, want it to say %q", test.name, err,
+					test.want)
+			}
+		}()
+	}
+}
@@ -0,0 +1,211 @@
+package texture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Header flags and caps
+const (
+	ddsMipmapCount  = 0x20000
+	ddsFourCC       = 0x4
+	ddsRGB          = 0x40
+	ddsAlphaPixels  = 0x1
+	ddsCubemap      = 0x200
+	ddsAllFaces     = 0xfc00
+	ddsVolume       = 0x200000
+	ddsTexture2D    = 3
+	ddsMiscCubemap  = 0x4
+	ddsHeaderSize   = 128
+	ddsDX10Size     = 20
+	ddsFourCCOffset = 84
+)
+
+// decodeDDS reads a DirectDraw Surface, with or without the DX10 header.
+// Its images are stored a layer or face at a time, each with its mip
+// chain.
+func decodeDDS(data []byte) (*Container, error) {
+	if len(data) < ddsHeaderSize {
+		return nil, errors.New("texture: DDS header is cut short")
+	}
+	// The header's fields after the magic
+	field := func(i int) uint32 {
+		return binary.LittleEndian.Uint32(data[4+i*4:])
+	}
+	flags, height, width := field(1), int(field(2)), int(field(3))
+	levels := 1
+	if flags&ddsMipmapCount != 0 && field(6) > 0 {
+		levels = int(field(6))
+	}
+	pixelFlags := field(19)
+	caps2 := field(27)
+
+	c := &Container{Width: width, Height: height, Faces: 1}
+	if caps2&ddsVolume != 0 {
+		return nil, errors.New("texture: volume DDS textures aren't " +
+			"supported")
+	}
+	if caps2&ddsCubemap != 0 {
+		if caps2&ddsAllFaces != ddsAllFaces {
+			return nil, errors.New("texture: DDS cubemap is missing faces")
+		}
+		c.Faces = 6
+	}
+
+	offset := ddsHeaderSize
+	var ok bool
+	switch {
+	case pixelFlags&ddsFourCC != 0 &&
+		string(data[ddsFourCCOffset:ddsFourCCOffset+4]) == "DX10":
+
+		if len(data) < ddsHeaderSize+ddsDX10Size {
+			return nil, errors.New("texture: DDS header is cut short")
+		}
+		dx10 := func(i int) uint32 {
+			return binary.LittleEndian.Uint32(data[ddsHeaderSize+i*4:])
+		}
+		if dx10(1) != ddsTexture2D {
+			return nil, errors.New("texture: only 2D DDS textures are " +
+				"supported")
+		}
+		if dx10(2)&ddsMiscCubemap != 0 {
+			c.Faces = 6
+		}
+		if dx10(3) > 1 {
+			c.Layers = int(dx10(3))
+		}
+		c.Format, ok = dxgiFormats[dx10(0)]
+		if !ok {
+			return nil, fmt.Errorf("texture: unsupported DXGI format %d",
+				dx10(0))
+		}
+		offset += ddsDX10Size
+	case pixelFlags&ddsFourCC != 0:
+		fourCC := field(20)
+		c.Format, ok = fourCCFormats[fourCC]
+		if !ok {
+			return nil, fmt.Errorf("texture: unsupported DDS format %q",
+				data[ddsFourCCOffset:ddsFourCCOffset+4])
+		}
+	case pixelFlags&ddsRGB != 0:
+		// The bit count and red mask
+		c.Format, ok = maskFormat(field(21), field(22),
+			pixelFlags&ddsAlphaPixels != 0)
+		if !ok {
+			return nil, fmt.Errorf("texture: unsupported %d bit DDS format",
+				field(21))
+		}
+	default:
+		return nil, errors.New("texture: unsupported DDS pixel format")
+	}
+
+	if err := checkHeader("DDS", width, height, c.Layers, c.Faces, levels,
+		len(data)-offset); err != nil {
+		return nil, err
+	}
+	c.Levels = make([][][]byte, levels)
+	for level := range c.Levels {
+		c.Levels[level] = make([][]byte, c.images())
+	}
+	for i := 0; i < c.images(); i++ {
+		for level := range c.Levels {
+			w, h := c.LevelSize(level)
+			size := c.Format.ImageSize(w, h)
+			if size > len(data)-offset {
+				return nil, fmt.Errorf("texture: DDS level %d is cut short",
+					level)
+			}
+			c.Levels[level][i] = data[offset : offset+size]
+			offset += size
+		}
+	}
+	return c, nil
+}
+
+// maskFormat is the format of 8 bit RGB(A) texels from the bit count and
+// where red is
+func maskFormat(bitCount, redMask uint32, alpha bool) (Format, bool) {
+	switch {
+	case bitCount == 32 && redMask == 0xff && alpha:
+		return pixelFormat(gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, 4), true
+	case bitCount == 32 && redMask == 0xff:
+		return pixelFormat(gl.RGB8, gl.RGBA, gl.UNSIGNED_BYTE, 4), true
+	case bitCount == 32 && redMask == 0xff0000 && alpha:
+		return pixelFormat(gl.RGBA8, gl.BGRA, gl.UNSIGNED_BYTE, 4), true
+	case bitCount == 32 && redMask == 0xff0000:
+		return pixelFormat(gl.RGB8, gl.BGRA, gl.UNSIGNED_BYTE, 4), true
+	case bitCount == 24 && redMask == 0xff:
+		return pixelFormat(gl.RGB8, gl.RGB, gl.UNSIGNED_BYTE, 3), true
+	case bitCount == 24 && redMask == 0xff0000:
+		return pixelFormat(gl.RGB8, gl.BGR, gl.UNSIGNED_BYTE, 3), true
+	}
+	return Format{}, false
+}
+
+func fourCC(code string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(code))
+}
+
+// fourCCFormats are the formats of DDS files without the DX10 header, the
+// float ones are D3DFORMAT numbers rather than letters
+var fourCCFormats = map[uint32]Format{
+	fourCC("DXT1"): compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT1_EXT, 8),
+	fourCC("DXT2"): compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT3_EXT, 16),
+	fourCC("DXT3"): compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT3_EXT, 16),
+	fourCC("DXT4"): compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, 16),
+	fourCC("DXT5"): compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, 16),
+	fourCC("ATI1"): compressedFormat(gl.COMPRESSED_RED_RGTC1, 8),
+	fourCC("BC4U"): compressedFormat(gl.COMPRESSED_RED_RGTC1, 8),
+	fourCC("BC4S"): compressedFormat(gl.COMPRESSED_SIGNED_RED_RGTC1, 8),
+	fourCC("ATI2"): compressedFormat(gl.COMPRESSED_RG_RGTC2, 16),
+	fourCC("BC5U"): compressedFormat(gl.COMPRESSED_RG_RGTC2, 16),
+	fourCC("BC5S"): compressedFormat(gl.COMPRESSED_SIGNED_RG_RGTC2, 16),
+	36:             pixelFormat(gl.RGBA16, gl.RGBA, gl.UNSIGNED_SHORT, 8),
+	111:            pixelFormat(gl.R16F, gl.RED, gl.HALF_FLOAT, 2),
+	112:            pixelFormat(gl.RG16F, gl.RG, gl.HALF_FLOAT, 4),
+	113:            pixelFormat(gl.RGBA16F, gl.RGBA, gl.HALF_FLOAT, 8),
+	114:            pixelFormat(gl.R32F, gl.RED, gl.FLOAT, 4),
+	115:            pixelFormat(gl.RG32F, gl.RG, gl.FLOAT, 8),
+	116:            pixelFormat(gl.RGBA32F, gl.RGBA, gl.FLOAT, 16),
+}
+
+// dxgiFormats are the DXGI_FORMAT numbers of DX10 headers that GL has
+var dxgiFormats = map[uint32]Format{
+	2:  pixelFormat(gl.RGBA32F, gl.RGBA, gl.FLOAT, 16),
+	6:  pixelFormat(gl.RGB32F, gl.RGB, gl.FLOAT, 12),
+	10: pixelFormat(gl.RGBA16F, gl.RGBA, gl.HALF_FLOAT, 8),
+	11: pixelFormat(gl.RGBA16, gl.RGBA, gl.UNSIGNED_SHORT, 8),
+	16: pixelFormat(gl.RG32F, gl.RG, gl.FLOAT, 8),
+	26: pixelFormat(gl.R11F_G11F_B10F, gl.RGB,
+		gl.UNSIGNED_INT_10F_11F_11F_REV, 4),
+	28: pixelFormat(gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, 4),
+	29: pixelFormat(gl.SRGB8_ALPHA8, gl.RGBA, gl.UNSIGNED_BYTE, 4),
+	34: pixelFormat(gl.RG16F, gl.RG, gl.HALF_FLOAT, 4),
+	35: pixelFormat(gl.RG16, gl.RG, gl.UNSIGNED_SHORT, 4),
+	41: pixelFormat(gl.R32F, gl.RED, gl.FLOAT, 4),
+	49: pixelFormat(gl.RG8, gl.RG, gl.UNSIGNED_BYTE, 2),
+	54: pixelFormat(gl.R16F, gl.RED, gl.HALF_FLOAT, 2),
+	56: pixelFormat(gl.R16, gl.RED, gl.UNSIGNED_SHORT, 2),
+	61: pixelFormat(gl.R8, gl.RED, gl.UNSIGNED_BYTE, 1),
+	67: pixelFormat(gl.RGB9_E5, gl.RGB, gl.UNSIGNED_INT_5_9_9_9_REV, 4),
+	87: pixelFormat(gl.RGBA8, gl.BGRA, gl.UNSIGNED_BYTE, 4),
+	91: pixelFormat(gl.SRGB8_ALPHA8, gl.BGRA, gl.UNSIGNED_BYTE, 4),
+
+	71: compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT1_EXT, 8),
+	72: compressedFormat(COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT, 8),
+	74: compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT3_EXT, 16),
+	75: compressedFormat(COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT, 16),
+	77: compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, 16),
+	78: compressedFormat(COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT, 16),
+	80: compressedFormat(gl.COMPRESSED_RED_RGTC1, 8),
+	81: compressedFormat(gl.COMPRESSED_SIGNED_RED_RGTC1, 8),
+	83: compressedFormat(gl.COMPRESSED_RG_RGTC2, 16),
+	84: compressedFormat(gl.COMPRESSED_SIGNED_RG_RGTC2, 16),
+	95: compressedFormat(gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT_ARB, 16),
+	96: compressedFormat(gl.COMPRESSED_RGB_BPTC_SIGNED_FLOAT_ARB, 16),
+	98: compressedFormat(gl.COMPRESSED_RGBA_BPTC_UNORM_ARB, 16),
+	99: compressedFormat(gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB, 16),
+}
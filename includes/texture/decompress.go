@@ -0,0 +1,242 @@
+package texture
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// A blockDecoder decodes a 4x4 block into texels in rows from the top, 8
+// bit RGBA or signed RGBA for the signed formats
+type blockDecoder func(block []byte, texels *[16][4]byte)
+
+var blockDecoders = map[uint32]blockDecoder{
+	gl.COMPRESSED_RGB_S3TC_DXT1_EXT:              decodeBC1RGB,
+	COMPRESSED_SRGB_S3TC_DXT1_EXT:                decodeBC1RGB,
+	gl.COMPRESSED_RGBA_S3TC_DXT1_EXT:             decodeBC1,
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT:          decodeBC1,
+	gl.COMPRESSED_RGBA_S3TC_DXT3_EXT:             decodeBC2,
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT:          decodeBC2,
+	gl.COMPRESSED_RGBA_S3TC_DXT5_EXT:             decodeBC3,
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT:          decodeBC3,
+	gl.COMPRESSED_RED_RGTC1:                      decodeBC4,
+	gl.COMPRESSED_SIGNED_RED_RGTC1:               decodeSignedBC4,
+	gl.COMPRESSED_RG_RGTC2:                       decodeBC5,
+	gl.COMPRESSED_SIGNED_RG_RGTC2:                decodeSignedBC5,
+	gl.COMPRESSED_RGB8_ETC2:                      decodeETC2,
+	gl.COMPRESSED_SRGB8_ETC2:                     decodeETC2,
+	gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2:  decodeETC2Punchthrough,
+	gl.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2: decodeETC2Punchthrough,
+	gl.COMPRESSED_RGBA8_ETC2_EAC:                 decodeETC2EAC,
+	gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC:          decodeETC2EAC,
+	gl.COMPRESSED_R11_EAC:                        decodeR11,
+	gl.COMPRESSED_SIGNED_R11_EAC:                 decodeSignedR11,
+	gl.COMPRESSED_RG11_EAC:                       decodeRG11,
+	gl.COMPRESSED_SIGNED_RG11_EAC:                decodeSignedRG11,
+}
+
+var signedFormats = map[uint32]bool{
+	gl.COMPRESSED_SIGNED_RED_RGTC1: true,
+	gl.COMPRESSED_SIGNED_RG_RGTC2:  true,
+	gl.COMPRESSED_SIGNED_R11_EAC:   true,
+	gl.COMPRESSED_SIGNED_RG11_EAC:  true,
+}
+
+// Decompress decodes a compressed container on the CPU into 8 bit RGBA,
+// sRGB if it was and signed if it was. EAC's 11 bits are cut to 8. BC6H
+// and BC7 can't be decompressed, drivers without them are too old for
+// most of the chapters anyway.
+func Decompress(c *Container) (*Container, error) {
+	if !c.Format.Compressed {
+		return c, nil
+	}
+	decode, ok := blockDecoders[c.Format.InternalFormat]
+	if !ok {
+		return nil, fmt.Errorf("the driver doesn't support compressed "+
+			"format 0x%x and it can't be decompressed",
+			c.Format.InternalFormat)
+	}
+
+	format := pixelFormat(gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, 4)
+	switch {
+	case isSRGB(c.Format.InternalFormat):
+		format.InternalFormat = gl.SRGB8_ALPHA8
+	case signedFormats[c.Format.InternalFormat]:
+		format.InternalFormat = gl.RGBA8_SNORM
+		format.Type = gl.BYTE
+	}
+
+	out := &Container{Width: c.Width, Height: c.Height, Layers: c.Layers,
+		Faces: c.Faces, Format: format, Levels: make([][][]byte,
+			len(c.Levels))}
+	var texels [16][4]byte
+	for level, images := range c.Levels {
+		w, h := c.LevelSize(level)
+		out.Levels[level] = make([][]byte, len(images))
+		for i, blocks := range images {
+			pix := make([]byte, w*h*4)
+			block := 0
+			for by := 0; by < h; by += 4 {
+				for bx := 0; bx < w; bx += 4 {
+					decode(blocks[block:block+c.Format.Size], &texels)
+					block += c.Format.Size
+
+					// Blocks hang off the edges of levels that aren't a
+					// multiple of 4
+					for y := 0; y < 4 && by+y < h; y++ {
+						for x := 0; x < 4 && bx+x < w; x++ {
+							copy(pix[((by+y)*w+bx+x)*4:], texels[y*4+x][:])
+						}
+					}
+				}
+			}
+			out.Levels[level][i] = pix
+		}
+	}
+	return out, nil
+}
+
+// BC1 to 3 share a block of two RGB565 endpoints and 2 bit indices. With
+// threeColors, when the first endpoint isn't the greater, index 3 is black
+// and transparent if punchthrough is set.
+func decodeColorBlock(block []byte, texels *[16][4]byte, threeColors,
+	punchthrough bool) {
+
+	c0 := uint16(block[0]) | uint16(block[1])<<8
+	c1 := uint16(block[2]) | uint16(block[3])<<8
+	var colors [4][4]int
+	colors[0] = rgb565(c0)
+	colors[1] = rgb565(c1)
+	if c0 > c1 || !threeColors {
+		for c := 0; c < 3; c++ {
+			colors[2][c] = (2*colors[0][c] + colors[1][c]) / 3
+			colors[3][c] = (colors[0][c] + 2*colors[1][c]) / 3
+		}
+		colors[2][3], colors[3][3] = 255, 255
+	} else {
+		for c := 0; c < 3; c++ {
+			colors[2][c] = (colors[0][c] + colors[1][c]) / 2
+		}
+		colors[2][3], colors[3][3] = 255, 255
+		if punchthrough {
+			colors[3][3] = 0
+		}
+	}
+
+	indices := uint32(block[4]) | uint32(block[5])<<8 | uint32(block[6])<<16 |
+		uint32(block[7])<<24
+	for i := range texels {
+		color := colors[indices>>(uint(i)*2)&3]
+		for c := 0; c < 4; c++ {
+			texels[i][c] = byte(color[c])
+		}
+	}
+}
+
+func rgb565(c uint16) [4]int {
+	r, g, b := int(c>>11&31), int(c>>5&63), int(c&31)
+	return [4]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 255}
+}
+
+func decodeBC1RGB(block []byte, texels *[16][4]byte) {
+	decodeColorBlock(block, texels, true, false)
+}
+
+func decodeBC1(block []byte, texels *[16][4]byte) {
+	decodeColorBlock(block, texels, true, true)
+}
+
+// decodeBC2 has 4 bit alphas before the colors
+func decodeBC2(block []byte, texels *[16][4]byte) {
+	decodeColorBlock(block[8:], texels, false, false)
+	for i := range texels {
+		alpha := block[i/2] >> (uint(i%2) * 4) & 0xf
+		texels[i][3] = alpha * 17
+	}
+}
+
+// decodeBC3 has a BC4 block of alphas before the colors
+func decodeBC3(block []byte, texels *[16][4]byte) {
+	decodeColorBlock(block[8:], texels, false, false)
+	var alphas [16]int
+	decodeBC4Values(block, &alphas, false)
+	for i := range texels {
+		texels[i][3] = byte(alphas[i])
+	}
+}
+
+// decodeBC4Values decodes a block of two 8 bit endpoints and 3 bit
+// indices, as bytes or signed bytes
+func decodeBC4Values(block []byte, values *[16]int, signed bool) {
+	var palette [8]int
+	if signed {
+		palette[0], palette[1] = int(int8(block[0])), int(int8(block[1]))
+		// -128 is the same as -127
+		for i := 0; i < 2; i++ {
+			if palette[i] == -128 {
+				palette[i] = -127
+			}
+		}
+	} else {
+		palette[0], palette[1] = int(block[0]), int(block[1])
+	}
+
+	if palette[0] > palette[1] {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = ((7-i)*palette[0] + i*palette[1]) / 7
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			palette[i+1] = ((5-i)*palette[0] + i*palette[1]) / 5
+		}
+		palette[6], palette[7] = 0, 255
+		if signed {
+			palette[6], palette[7] = -127, 127
+		}
+	}
+
+	var indices uint64
+	for i := 7; i >= 2; i-- {
+		indices = indices<<8 | uint64(block[i])
+	}
+	for i := range values {
+		values[i] = palette[indices>>(uint(i)*3)&7]
+	}
+}
+
+// decodeChannels decodes blocks into channels with decodeChannel,
+// leaving the rest black and opaque
+func decodeChannels(block []byte, texels *[16][4]byte, channels int,
+	signed bool, decodeChannel func([]byte, *[16]int, bool)) {
+
+	opaque := byte(255)
+	if signed {
+		opaque = 127
+	}
+	for i := range texels {
+		texels[i] = [4]byte{0, 0, 0, opaque}
+	}
+	var values [16]int
+	for c := 0; c < channels; c++ {
+		decodeChannel(block[c*8:], &values, signed)
+		for i := range texels {
+			texels[i][c] = byte(values[i])
+		}
+	}
+}
+
+func decodeBC4(block []byte, texels *[16][4]byte) {
+	decodeChannels(block, texels, 1, false, decodeBC4Values)
+}
+
+func decodeSignedBC4(block []byte, texels *[16][4]byte) {
+	decodeChannels(block, texels, 1, true, decodeBC4Values)
+}
+
+func decodeBC5(block []byte, texels *[16][4]byte) {
+	decodeChannels(block, texels, 2, false, decodeBC4Values)
+}
+
+func decodeSignedBC5(block []byte, texels *[16][4]byte) {
+	decodeChannels(block, texels, 2, true, decodeBC4Values)
+}
@@ -0,0 +1,375 @@
+package texture
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// texelsOf fills a block's texels from a function of where they are
+func texelsOf(f func(x, y int) [4]byte) [16][4]byte {
+	var texels [16][4]byte
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			texels[y*4+x] = f(x, y)
+		}
+	}
+	return texels
+}
+
+// byRow is texels that are the same along each row
+func byRow(rows [4][4]byte) [16][4]byte {
+	return texelsOf(func(x, y int) [4]byte { return rows[y] })
+}
+
+// byColumn is texels that are the same down each column
+func byColumn(columns [4][4]byte) [16][4]byte {
+	return texelsOf(func(x, y int) [4]byte { return columns[x] })
+}
+
+func snorm(v int) byte {
+	return byte(int8(v))
+}
+
+// decompressBlock decompresses a 4x4 image of one block
+func decompressBlock(t *testing.T, internalFormat uint32,
+	block []byte) (*Container, error) {
+
+	t.Helper()
+	c := &Container{Width: 4, Height: 4, Faces: 1,
+		Format: compressedFormat(internalFormat, len(block)),
+		Levels: [][][]byte{{block}}}
+	return Decompress(c)
+}
+
+// The bytes of 3 bit indices counting up then down texel by texel, BC
+// blocks are little endian and EAC ones big endian
+var upBC = []byte{0x88, 0xc6, 0xfa, 0x88, 0xc6, 0xfa}
+var downBC = []byte{0x77, 0x39, 0x05, 0x77, 0x39, 0x05}
+var upEAC = []byte{0x05, 0x39, 0x77, 0x05, 0x39, 0x77}
+var downEAC = []byte{0xfa, 0xc6, 0x88, 0xfa, 0xc6, 0x88}
+
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestDecompressBC(t *testing.T) {
+	red, blue := [4]byte{255, 0, 0, 255}, [4]byte{0, 0, 255, 255}
+	// Indices 0, 1, 2 then 3 along every row
+	rowIndices := []byte{0xe4, 0xe4, 0xe4, 0xe4}
+	// Red then blue, red first makes it four colors
+	redBlue := join([]byte{0x00, 0xf8, 0x1f, 0x00}, rowIndices)
+	// Black then gray, black first makes it three colors and black
+	blackGray := join([]byte{0x00, 0x00, 0x10, 0x84}, rowIndices)
+	// Black then white
+	blackWhite := join([]byte{0x00, 0x00, 0xff, 0xff}, rowIndices)
+
+	// Eight alphas between 200 and 60, then six between 40 and 240 and
+	// the ends
+	eight := [8]byte{200, 60, 180, 160, 140, 120, 100, 80}
+	six := [8]byte{40, 240, 80, 120, 160, 200, 0, 255}
+	signedSix := [8]int{-100, 100, -60, -20, 20, 60, -127, 127}
+	// -128 is read as -127
+	signedEight := [8]int{83, -127, 53, 23, -7, -37, -67, -97}
+	up := func(x, y int) int { return (y*4 + x) % 8 }
+	down := func(x, y int) int { return 7 - up(x, y) }
+
+	tests := []struct {
+		name   string
+		format uint32
+		block  []byte
+		want   [16][4]byte
+	}{
+		{"BC1 four colors", gl.COMPRESSED_RGB_S3TC_DXT1_EXT, redBlue,
+			byColumn([4][4]byte{red, blue, {170, 0, 85, 255},
+				{85, 0, 170, 255}})},
+		{"BC1 three colors", gl.COMPRESSED_RGB_S3TC_DXT1_EXT, blackGray,
+			byColumn([4][4]byte{{0, 0, 0, 255}, {132, 130, 132, 255},
+				{66, 65, 66, 255}, {0, 0, 0, 255}})},
+		{"BC1 punchthrough", gl.COMPRESSED_RGBA_S3TC_DXT1_EXT, blackGray,
+			byColumn([4][4]byte{{0, 0, 0, 255}, {132, 130, 132, 255},
+				{66, 65, 66, 255}, {0, 0, 0, 0}})},
+		{"BC1 punchthrough four colors", gl.COMPRESSED_RGBA_S3TC_DXT1_EXT,
+			redBlue, byColumn([4][4]byte{red, blue, {170, 0, 85, 255},
+				{85, 0, 170, 255}})},
+		// BC2 and 3 always have four colors, alphas count up by 17
+		{"BC2", gl.COMPRESSED_RGBA_S3TC_DXT3_EXT, join([]byte{0x10, 0x32,
+			0x54, 0x76, 0x98, 0xba, 0xdc, 0xfe}, blackWhite),
+			texelsOf(func(x, y int) [4]byte {
+				gray := []byte{0, 255, 85, 170}[x]
+				return [4]byte{gray, gray, gray, byte((y*4 + x) * 17)}
+			})},
+		{"BC3", gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, join([]byte{200, 60},
+			upBC, redBlue), texelsOf(func(x, y int) [4]byte {
+			c := [][4]byte{red, blue, {170, 0, 85, 255},
+				{85, 0, 170, 255}}[x]
+			c[3] = eight[up(x, y)]
+			return c
+		})},
+		{"BC4", gl.COMPRESSED_RED_RGTC1, join([]byte{40, 240}, upBC),
+			texelsOf(func(x, y int) [4]byte {
+				return [4]byte{six[up(x, y)], 0, 0, 255}
+			})},
+		{"BC4 signed", gl.COMPRESSED_SIGNED_RED_RGTC1, join([]byte{
+			snorm(-100), 100}, upBC), texelsOf(func(x, y int) [4]byte {
+			return [4]byte{snorm(signedSix[up(x, y)]), 0, 0, 127}
+		})},
+		{"BC5", gl.COMPRESSED_RG_RGTC2, join([]byte{40, 240}, upBC,
+			[]byte{200, 60}, downBC), texelsOf(func(x, y int) [4]byte {
+			return [4]byte{six[up(x, y)], eight[down(x, y)], 0, 255}
+		})},
+		{"BC5 signed", gl.COMPRESSED_SIGNED_RG_RGTC2, join([]byte{
+			snorm(-100), 100}, upBC, []byte{83, snorm(-128)}, downBC),
+			texelsOf(func(x, y int) [4]byte {
+				return [4]byte{snorm(signedSix[up(x, y)]),
+					snorm(signedEight[down(x, y)]), 0, 127}
+			})},
+	}
+	checkBlocks(t, tests)
+}
+
+func checkBlocks(t *testing.T, tests []struct {
+	name   string
+	format uint32
+	block  []byte
+	want   [16][4]byte
+}) {
+	t.Helper()
+	for _, test := range tests {
+		out, err := decompressBlock(t, test.format, test.block)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		pix := out.Levels[0][0]
+		for i, want := range test.want {
+			if got := pix[i*4 : i*4+4]; !bytes.Equal(got, want[:]) {
+				t.Errorf("%s: texel %d, %d is %v, want %v", test.name, i%4,
+					i/4, got, want)
+			}
+		}
+	}
+}
+
+func TestDecompressETC(t *testing.T) {
+	// Each texel's index is its row, or its column
+	byY := []byte{0xcc, 0xcc, 0xaa, 0xaa}
+	byX := []byte{0xff, 0x00, 0xf0, 0xf0}
+
+	// 8,4,2 and 1,2,3 with tables 0 and 7, left and right halves
+	individual := join([]byte{0x81, 0x42, 0x23, 0x1c}, byY)
+	individualTexels := texelsOf(func(x, y int) [4]byte {
+		if x < 2 {
+			return [][4]byte{{138, 70, 36, 255}, {144, 76, 42, 255},
+				{134, 66, 32, 255}, {128, 60, 26, 255}}[y]
+		}
+		return [][4]byte{{64, 81, 98, 255}, {200, 217, 234, 255},
+			{0, 0, 4, 255}, {0, 0, 0, 255}}[y]
+	})
+
+	// 16,8,31 then 3,-4,0 more with tables 1 and 2, flipped to top and
+	// bottom halves. The last byte of the header is the opaque bit for
+	// punchthrough.
+	differential := func(last byte) []byte {
+		return join([]byte{0x83, 0x44, 0xf8, last}, byX)
+	}
+	differentialTexels := func(opaque bool) [16][4]byte {
+		return texelsOf(func(x, y int) [4]byte {
+			if !opaque && x == 2 {
+				return [4]byte{}
+			}
+			if y < 2 {
+				if !opaque && x == 0 {
+					return [4]byte{132, 66, 255, 255}
+				}
+				return [][4]byte{{137, 71, 255, 255}, {149, 83, 255, 255},
+					{127, 61, 250, 255}, {115, 49, 238, 255}}[x]
+			}
+			if !opaque && x == 0 {
+				return [4]byte{156, 33, 255, 255}
+			}
+			return [][4]byte{{165, 42, 255, 255}, {185, 62, 255, 255},
+				{147, 24, 246, 255}, {127, 4, 226, 255}}[x]
+		})
+	}
+
+	// Red overflowing makes it T, 187,68,34 then 102,238,170 moved 32
+	tMode := func(last byte) []byte {
+		return join([]byte{0xf3, 0x42, 0x6e, last}, byY)
+	}
+	tColors := [4][4]byte{{187, 68, 34, 255}, {134, 255, 202, 255},
+		{102, 238, 170, 255}, {70, 206, 138, 255}}
+	tTransparent := tColors
+	tTransparent[2] = [4]byte{}
+
+	// Green overflowing makes it H, 51,170,153 and 85,102,119 moved 23
+	h := join([]byte{0x1d, 0x0c, 0xab, 0x3e}, byY)
+	hColors := [4][4]byte{{74, 193, 176, 255}, {28, 147, 130, 255},
+		{108, 125, 142, 255}, {62, 79, 96, 255}}
+
+	// Blue overflowing makes it planar, red goes up to the right and
+	// blue down the bottom
+	planar := []byte{0x41, 0x01, 0x04, 0x7f, 0x81, 0x04, 0x10, 0x00}
+	transparentPlanar := append([]byte(nil), planar...)
+	transparentPlanar[3] &^= 2
+	planarTexels := texelsOf(func(x, y int) [4]byte {
+		return [4]byte{[]byte{130, 161, 193, 224}[x], 129,
+			[]byte{130, 98, 65, 33}[y], 255}
+	})
+
+	// Alphas around 250 times 3, going past 255
+	alphas := [8]byte{247, 244, 241, 220, 250, 253, 255, 255}
+	alphaBlock := join([]byte{250, 0x3d}, upEAC)
+	// EAC goes down columns too
+	up := func(x, y int) int { return (x*4 + y) % 8 }
+
+	tests := []struct {
+		name   string
+		format uint32
+		block  []byte
+		want   [16][4]byte
+	}{
+		{"ETC1 individual", gl.COMPRESSED_RGB8_ETC2, individual,
+			individualTexels},
+		{"ETC1 differential", gl.COMPRESSED_RGB8_ETC2, differential(0x2b),
+			differentialTexels(true)},
+		{"ETC2 T", gl.COMPRESSED_RGB8_ETC2, tMode(0xab), byRow(tColors)},
+		{"ETC2 H", gl.COMPRESSED_RGB8_ETC2, h, byRow(hColors)},
+		{"ETC2 planar", gl.COMPRESSED_RGB8_ETC2, planar, planarTexels},
+		{"punchthrough opaque",
+			gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, differential(0x2b),
+			differentialTexels(true)},
+		{"punchthrough differential",
+			gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, differential(0x29),
+			differentialTexels(false)},
+		{"punchthrough T", gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+			tMode(0xa9), byRow(tTransparent)},
+		// Planar blocks are opaque whatever the bit says
+		{"punchthrough planar", gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+			transparentPlanar, planarTexels},
+		{"ETC2 EAC", gl.COMPRESSED_RGBA8_ETC2_EAC, join(alphaBlock,
+			individual), texelsOf(func(x, y int) [4]byte {
+			c := individualTexels[y*4+x]
+			c[3] = alphas[up(x, y)]
+			return c
+		})},
+	}
+	checkBlocks(t, tests)
+}
+
+func TestDecompressEAC(t *testing.T) {
+	// 128 with table 13 times 2, then 255 with table 0 times 15 past the
+	// top. Values are 11 bit cut to 8.
+	r := join([]byte{128, 0x2d}, upEAC)
+	reds := [8]byte{126, 124, 122, 108, 128, 130, 132, 146}
+	g := join([]byte{255, 0xf0}, downEAC)
+	greens := [8]byte{210, 165, 120, 30, 255, 255, 255, 255}
+
+	// -128 is read as -127 and goes past the bottom with table 14, then
+	// 100 with table 0 and a multiplier of 0 moves 11 bit steps
+	signedR := join([]byte{snorm(-128), 0x1e}, upEAC)
+	signedReds := [8]int{-127, -127, -127, -127, -123, -121, -119, -118}
+	signedG := join([]byte{100, 0x00}, downEAC)
+	signedGreens := [8]int{98, 98, 98, 97, 99, 99, 100, 101}
+
+	up := func(x, y int) int { return (x*4 + y) % 8 }
+	down := func(x, y int) int { return 7 - up(x, y) }
+
+	tests := []struct {
+		name   string
+		format uint32
+		block  []byte
+		want   [16][4]byte
+	}{
+		{"R11", gl.COMPRESSED_R11_EAC, r, texelsOf(func(x, y int) [4]byte {
+			return [4]byte{reds[up(x, y)], 0, 0, 255}
+		})},
+		{"RG11", gl.COMPRESSED_RG11_EAC, join(r, g),
+			texelsOf(func(x, y int) [4]byte {
+				return [4]byte{reds[up(x, y)], greens[down(x, y)], 0, 255}
+			})},
+		{"signed R11", gl.COMPRESSED_SIGNED_R11_EAC, signedR,
+			texelsOf(func(x, y int) [4]byte {
+				return [4]byte{snorm(signedReds[up(x, y)]), 0, 0, 127}
+			})},
+		{"signed RG11", gl.COMPRESSED_SIGNED_RG11_EAC, join(signedR,
+			signedG), texelsOf(func(x, y int) [4]byte {
+			return [4]byte{snorm(signedReds[up(x, y)]),
+				snorm(signedGreens[down(x, y)]), 0, 127}
+		})},
+	}
+	checkBlocks(t, tests)
+}
+
+func TestDecompress(t *testing.T) {
+	// Formats follow sRGB and signed formats
+	formats := []struct {
+		compressed     uint32
+		internalFormat uint32
+		glType         uint32
+	}{
+		{gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, gl.RGBA8, gl.UNSIGNED_BYTE},
+		{COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT, gl.SRGB8_ALPHA8,
+			gl.UNSIGNED_BYTE},
+		{gl.COMPRESSED_SRGB8_ETC2, gl.SRGB8_ALPHA8, gl.UNSIGNED_BYTE},
+		{gl.COMPRESSED_SIGNED_RG_RGTC2, gl.RGBA8_SNORM, gl.BYTE},
+		{gl.COMPRESSED_SIGNED_R11_EAC, gl.RGBA8_SNORM, gl.BYTE},
+	}
+	for _, test := range formats {
+		out, err := decompressBlock(t, test.compressed, make([]byte, 16))
+		if err != nil {
+			t.Errorf("0x%x: %v", test.compressed, err)
+			continue
+		}
+		if out.Format.InternalFormat != test.internalFormat ||
+			out.Format.Type != test.glType || out.Format.Compressed {
+			t.Errorf("0x%x: decompressed to %+v", test.compressed,
+				out.Format)
+		}
+	}
+
+	// A 6x6 image's blocks hang off the edges, each BC4 block is all one
+	// value
+	flat := func(v byte) []byte {
+		return []byte{v, v, 0, 0, 0, 0, 0, 0}
+	}
+	c := &Container{Width: 6, Height: 6, Faces: 1,
+		Format: compressedFormat(gl.COMPRESSED_RED_RGTC1, 8),
+		Levels: [][][]byte{
+			{join(flat(10), flat(20), flat(30), flat(40))},
+			{flat(50)},
+		}}
+	out, err := Decompress(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level0, level1 := out.Levels[0][0], out.Levels[1][0]
+	if len(level0) != 6*6*4 || len(level1) != 3*3*4 {
+		t.Fatalf("levels are %d and %d bytes", len(level0), len(level1))
+	}
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			want := byte(10 + 10*(y/4*2+x/4))
+			if got := level0[(y*6+x)*4]; got != want {
+				t.Errorf("%d, %d is %d, want %d", x, y, got, want)
+			}
+		}
+	}
+	for i := 0; i < 9; i++ {
+		if level1[i*4] != 50 {
+			t.Errorf("level 1 texel %d is %d, want 50", i, level1[i*4])
+		}
+	}
+
+	// Uncompressed containers are left alone and BC7 can't be done
+	plain := &Container{Format: pixelFormat(gl.RGBA8, gl.RGBA,
+		gl.UNSIGNED_BYTE, 4)}
+	if out, err := Decompress(plain); out != plain || err != nil {
+		t.Errorf("uncompressed container gave %v", err)
+	}
+	if _, err := decompressBlock(t, gl.COMPRESSED_RGBA_BPTC_UNORM_ARB,
+		make([]byte, 16)); err == nil {
+		t.Errorf("BC7 didn't error")
+	}
+}
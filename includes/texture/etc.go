@@ -0,0 +1,291 @@
+package texture
+
+import (
+	"encoding/binary"
+)
+
+// ETC blocks are big endian and their texels go down columns
+
+var etc1Modifiers = [8][2]int{{2, 8}, {5, 17}, {9, 29}, {13, 42}, {18, 60},
+	{24, 80}, {33, 106}, {47, 183}}
+
+var etc2Distances = [8]int{3, 6, 11, 16, 23, 32, 41, 64}
+
+var eacModifiers = [16][8]int{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+func decodeETC2(block []byte, texels *[16][4]byte) {
+	decodeETC2Block(block, texels, false)
+}
+
+func decodeETC2Punchthrough(block []byte, texels *[16][4]byte) {
+	decodeETC2Block(block, texels, true)
+}
+
+// decodeETC2EAC has an EAC block of alphas before the colors
+func decodeETC2EAC(block []byte, texels *[16][4]byte) {
+	decodeETC2Block(block[8:], texels, false)
+	var alphas [16]int
+	decodeEACValues(block, &alphas, false, false)
+	for i := range texels {
+		texels[i][3] = byte(alphas[i])
+	}
+}
+
+// decodeETC2Block decodes the ETC1 individual and differential modes and
+// the T, H and planar modes ETC2 hides in differential blocks that
+// overflow. With punchthrough the differential bit says whether the
+// block is opaque instead.
+func decodeETC2Block(block []byte, texels *[16][4]byte, punchthrough bool) {
+	bits := binary.BigEndian.Uint64(block)
+	differential := bits>>33&1 == 1
+	opaque := true
+	if punchthrough {
+		opaque, differential = differential, true
+	}
+
+	if !differential {
+		c1 := [3]int{extend(bits>>60, 4), extend(bits>>52, 4),
+			extend(bits>>44, 4)}
+		c2 := [3]int{extend(bits>>56, 4), extend(bits>>48, 4),
+			extend(bits>>40, 4)}
+		decodeETC1(bits, texels, c1, c2, opaque)
+		return
+	}
+
+	r, g, b := int(bits>>59&31), int(bits>>51&31), int(bits>>43&31)
+	dr, dg, db := signed3(bits>>56), signed3(bits>>48), signed3(bits>>40)
+	switch {
+	case r+dr < 0 || r+dr > 31:
+		decodeETC2T(bits, texels, opaque)
+	case g+dg < 0 || g+dg > 31:
+		decodeETC2H(bits, texels, opaque)
+	case b+db < 0 || b+db > 31:
+		decodeETC2Planar(bits, texels)
+	default:
+		c1 := [3]int{extend5(r), extend5(g), extend5(b)}
+		c2 := [3]int{extend5(r + dr), extend5(g + dg), extend5(b + db)}
+		decodeETC1(bits, texels, c1, c2, opaque)
+	}
+}
+
+// decodeETC1 modifies two base colors, one for each half of the block
+func decodeETC1(bits uint64, texels *[16][4]byte, c1, c2 [3]int,
+	opaque bool) {
+
+	tables := [2]int{int(bits >> 37 & 7), int(bits >> 34 & 7)}
+	flip := bits>>32&1 == 1
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			half := x / 2
+			if flip {
+				half = y / 2
+			}
+			base := c1
+			if half == 1 {
+				base = c2
+			}
+
+			modifiers := etc1Modifiers[tables[half]]
+			index := texelIndex(bits, x, y)
+			if !opaque && index == 2 {
+				texels[y*4+x] = [4]byte{}
+				continue
+			}
+			modifier := [4]int{modifiers[0], modifiers[1], -modifiers[0],
+				-modifiers[1]}[index]
+			if !opaque && index == 0 {
+				modifier = 0
+			}
+			texels[y*4+x] = [4]byte{clampByte(base[0] + modifier),
+				clampByte(base[1] + modifier), clampByte(base[2] + modifier),
+				255}
+		}
+	}
+}
+
+// decodeETC2T paints the first color and the second moved each way
+func decodeETC2T(bits uint64, texels *[16][4]byte, opaque bool) {
+	c1 := [3]int{extend(bits>>57&0xc|bits>>56&3, 4), extend(bits>>52, 4),
+		extend(bits>>48, 4)}
+	c2 := [3]int{extend(bits>>44, 4), extend(bits>>40, 4),
+		extend(bits>>36, 4)}
+	d := etc2Distances[bits>>34&3<<1|bits>>32&1]
+	paint(bits, texels, opaque, [4][3]int{c1, offset(c2, d), c2,
+		offset(c2, -d)})
+}
+
+// decodeETC2H paints both colors moved each way
+func decodeETC2H(bits uint64, texels *[16][4]byte, opaque bool) {
+	r1, g1 := bits>>59&0xf, bits>>55&0xe|bits>>52&1
+	b1 := bits>>48&8 | bits>>47&7
+	r2, g2, b2 := bits>>43&0xf, bits>>39&0xf, bits>>35&0xf
+
+	// The last bit of the distance is which color is greater
+	distance := bits>>34&1<<2 | bits>>32&1<<1
+	if r1<<8|g1<<4|b1 >= r2<<8|g2<<4|b2 {
+		distance |= 1
+	}
+	d := etc2Distances[distance]
+	c1 := [3]int{extend(r1, 4), extend(g1, 4), extend(b1, 4)}
+	c2 := [3]int{extend(r2, 4), extend(g2, 4), extend(b2, 4)}
+	paint(bits, texels, opaque, [4][3]int{offset(c1, d), offset(c1, -d),
+		offset(c2, d), offset(c2, -d)})
+}
+
+func paint(bits uint64, texels *[16][4]byte, opaque bool,
+	colors [4][3]int) {
+
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			index := texelIndex(bits, x, y)
+			if !opaque && index == 2 {
+				texels[y*4+x] = [4]byte{}
+				continue
+			}
+			c := colors[index]
+			texels[y*4+x] = [4]byte{clampByte(c[0]), clampByte(c[1]),
+				clampByte(c[2]), 255}
+		}
+	}
+}
+
+// decodeETC2Planar blends three colors across the block, at the origin,
+// the right and the bottom
+func decodeETC2Planar(bits uint64, texels *[16][4]byte) {
+	o := [3]int{extend(bits>>57, 6), extend(bits>>50&0x40|bits>>49&0x3f, 7),
+		extend(bits>>43&0x20|bits>>40&0x18|bits>>39&7, 6)}
+	h := [3]int{extend(bits>>33&0x3e|bits>>32&1, 6), extend(bits>>25, 7),
+		extend(bits>>19, 6)}
+	v := [3]int{extend(bits>>13, 6), extend(bits>>6, 7), extend(bits, 6)}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			for c := 0; c < 3; c++ {
+				texels[y*4+x][c] = clampByte((x*(h[c]-o[c]) +
+					y*(v[c]-o[c]) + 4*o[c] + 2) >> 2)
+			}
+			texels[y*4+x][3] = 255
+		}
+	}
+}
+
+// texelIndex is the 2 bit index of a texel, its high bit is 16 bits above
+// the low one
+func texelIndex(bits uint64, x, y int) int {
+	i := uint(x*4 + y)
+	return int(bits>>(i+16)&1<<1 | bits>>i&1)
+}
+
+// decodeEACValues decodes an EAC block of a base, a multiplier and 3 bit
+// modifier indices. Alpha blocks are 8 bit, R11 ones are 11 bit cut to 8
+// here, signed or not.
+func decodeEACValues(block []byte, values *[16]int, eleven, signed bool) {
+	base := int(block[0])
+	if signed {
+		base = int(int8(block[0]))
+		if base == -128 {
+			base = -127
+		}
+	}
+	multiplier := int(block[1] >> 4)
+	modifiers := eacModifiers[block[1]&0xf]
+	indices := binary.BigEndian.Uint64(block) & (1<<48 - 1)
+
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			modifier := modifiers[indices>>(45-uint(x*4+y)*3)&7]
+			switch {
+			case !eleven:
+				values[y*4+x] = int(clampByte(base + modifier*multiplier))
+			case signed:
+				v := clamp(base*8+scaleEAC(modifier, multiplier), -1023, 1023)
+				values[y*4+x] = int(int8(v * 127 / 1023))
+			default:
+				v := clamp(base*8+4+scaleEAC(modifier, multiplier), 0, 2047)
+				values[y*4+x] = (v*255 + 1023) / 2047
+			}
+		}
+	}
+}
+
+// scaleEAC scales a modifier for 11 bit values, a multiplier of 0 leaves
+// it as it is
+func scaleEAC(modifier, multiplier int) int {
+	if multiplier == 0 {
+		return modifier
+	}
+	return modifier * multiplier * 8
+}
+
+func decodeR11Values(block []byte, values *[16]int, signed bool) {
+	decodeEACValues(block, values, true, signed)
+}
+
+func decodeR11(block []byte, texels *[16][4]byte) {
+	decodeChannels(block, texels, 1, false, decodeR11Values)
+}
+
+func decodeSignedR11(block []byte, texels *[16][4]byte) {
+	decodeChannels(block, texels, 1, true, decodeR11Values)
+}
+
+func decodeRG11(block []byte, texels *[16][4]byte) {
+	decodeChannels(block, texels, 2, false, decodeR11Values)
+}
+
+func decodeSignedRG11(block []byte, texels *[16][4]byte) {
+	decodeChannels(block, texels, 2, true, decodeR11Values)
+}
+
+// extend repeats the top bits of an n bit value to make it 8 bit
+func extend(value uint64, n uint) int {
+	v := int(value & (1<<n - 1))
+	return v<<(8-n) | v>>(2*n-8)
+}
+
+func extend5(v int) int {
+	return extend(uint64(v), 5)
+}
+
+// signed3 is a 3 bit two's complement value
+func signed3(value uint64) int {
+	v := int(value & 7)
+	if v >= 4 {
+		v -= 8
+	}
+	return v
+}
+
+func offset(c [3]int, d int) [3]int {
+	return [3]int{c[0] + d, c[1] + d, c[2] + d}
+}
+
+func clamp(v, low, high int) int {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
+
+func clampByte(v int) byte {
+	return byte(clamp(v, 0, 255))
+}
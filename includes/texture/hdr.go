@@ -0,0 +1,217 @@
+package texture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// DecodeHDR decodes a Radiance RGBE .hdr image into 3 channel floats
+func DecodeHDR(r io.Reader) (*Image, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.ReadString('\n')
+	if err != nil || !(strings.HasPrefix(magic, "#?RADIANCE") ||
+		strings.HasPrefix(magic, "#?RGBE")) {
+		return nil, errors.New("texture: not a Radiance hdr file")
+	}
+	// Header lines until a blank one
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("texture: hdr header: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") &&
+			line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("texture: unsupported hdr %s", line)
+		}
+	}
+
+	resolution, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("texture: hdr resolution: %v", err)
+	}
+	var width, height int
+	if _, err := fmt.Sscanf(resolution, "-Y %d +X %d", &height,
+		&width); err != nil || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("texture: unsupported hdr resolution %q",
+			strings.TrimSpace(resolution))
+	}
+	if width > MAX_SIZE || height > MAX_SIZE {
+		return nil, fmt.Errorf("texture: hdr is %dx%d, at most %dx%d is "+
+			"supported", width, height, MAX_SIZE, MAX_SIZE)
+	}
+
+	// Rows are added as they're read so a file cut short doesn't allocate
+	// the whole image it claims to be
+	img := &Image{Width: width, Height: height, Channels: 3, Type: gl.FLOAT}
+	scanline := make([]byte, width*4)
+	row := make([]byte, width*12)
+	for y := 0; y < height; y++ {
+		if err := readScanline(br, scanline); err != nil {
+			return nil, fmt.Errorf("texture: hdr row %d: %v", y, err)
+		}
+		for x := 0; x < width; x++ {
+			rgbe := scanline[x*4 : x*4+4]
+			for c, v := range rgbeToFloat(rgbe) {
+				binary.LittleEndian.PutUint32(row[x*12+c*4:],
+					math.Float32bits(v))
+			}
+		}
+		img.Pix = append(img.Pix, row...)
+	}
+	return img, nil
+}
+
+// readScanline reads a row of RGBE pixels, run length encoded a channel
+// at a time or flat
+func readScanline(br *bufio.Reader, scanline []byte) error {
+	width := len(scanline) / 4
+
+	start, err := br.Peek(4)
+	if err != nil {
+		return err
+	}
+	if width < 8 || width > 0x7fff || start[0] != 2 || start[1] != 2 ||
+		start[2]&0x80 != 0 {
+
+		_, err := io.ReadFull(br, scanline)
+		return err
+	}
+	if int(start[2])<<8|int(start[3]) != width {
+		return errors.New("scanline width doesn't match the image")
+	}
+	br.Discard(4)
+
+	channel := make([]byte, width)
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			count, err := br.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				// A run of one value
+				n := int(count - 128)
+				value, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+n > width {
+					return errors.New("run past the end of the scanline")
+				}
+				for i := 0; i < n; i++ {
+					channel[x+i] = value
+				}
+				x += n
+			} else {
+				n := int(count)
+				if n == 0 || x+n > width {
+					return errors.New("bad scanline run")
+				}
+				if _, err := io.ReadFull(br, channel[x:x+n]); err != nil {
+					return err
+				}
+				x += n
+			}
+		}
+		for x := 0; x < width; x++ {
+			scanline[x*4+c] = channel[x]
+		}
+	}
+	return nil
+}
+
+func rgbeToFloat(rgbe []byte) [3]float32 {
+	if rgbe[3] == 0 {
+		return [3]float32{}
+	}
+	f := float32(math.Ldexp(1.0, int(rgbe[3])-(128+8)))
+	return [3]float32{float32(rgbe[0]) * f, float32(rgbe[1]) * f,
+		float32(rgbe[2]) * f}
+}
+
+// EncodeHDR writes a float image as a Radiance .hdr file, channels past
+// the third are dropped
+func EncodeHDR(w io.Writer, img *Image) error {
+	if img.Type != gl.FLOAT {
+		return errors.New("texture: hdr files need a float image")
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n",
+		img.Height, img.Width)
+
+	scanline := make([]byte, img.Width*4)
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			var rgb [3]float32
+			p := y*img.Width + x
+			for c := 0; c < img.Channels && c < 3; c++ {
+				rgb[c] = math.Float32frombits(binary.LittleEndian.Uint32(
+					img.Pix[(p*img.Channels+c)*4:]))
+			}
+			if img.Channels <= 2 {
+				rgb[1], rgb[2] = rgb[0], rgb[0]
+			}
+			rgbe := floatToRGBE(rgb)
+			copy(scanline[x*4:], rgbe[:])
+		}
+		writeScanline(bw, scanline)
+	}
+	return bw.Flush()
+}
+
+// writeScanline writes a row a channel at a time when readers expect it,
+// otherwise flat rows could be mistaken for encoded ones. Every byte goes
+// in a literal run, it isn't compressed.
+func writeScanline(bw *bufio.Writer, scanline []byte) {
+	width := len(scanline) / 4
+	if width < 8 || width > 0x7fff {
+		bw.Write(scanline)
+		return
+	}
+
+	bw.Write([]byte{2, 2, byte(width >> 8), byte(width)})
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; x += 128 {
+			n := min(128, width-x)
+			bw.WriteByte(byte(n))
+			for i := x; i < x+n; i++ {
+				bw.WriteByte(scanline[i*4+c])
+			}
+		}
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func floatToRGBE(rgb [3]float32) [4]byte {
+	max := math.Max(float64(rgb[0]), math.Max(float64(rgb[1]),
+		float64(rgb[2])))
+	if max < 1e-32 {
+		return [4]byte{}
+	}
+	frac, exp := math.Frexp(max)
+	scale := frac * 256.0 / max
+	var rgbe [4]byte
+	for c, v := range rgb {
+		rgbe[c] = byte(math.Max(0.0, float64(v)*scale))
+	}
+	rgbe[3] = byte(exp + 128)
+	return rgbe
+}
@@ -0,0 +1,197 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// floatPix is values as little endian floats like Image.Pix holds them
+func floatPix(values ...float32) []byte {
+	pix := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(pix[i*4:], math.Float32bits(v))
+	}
+	return pix
+}
+
+func floats(pix []byte) []float32 {
+	values := make([]float32, len(pix)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(
+			pix[i*4:]))
+	}
+	return values
+}
+
+func hdrFile(width, height int, data ...byte) []byte {
+	header := fmt.Sprintf("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n"+
+		"-Y %d +X %d\n", height, width)
+	return append([]byte(header), data...)
+}
+
+func TestDecodeHDR(t *testing.T) {
+	// 8 pixels wide is the narrowest that's run length encoded. Red is a
+	// run of 1.0, green literal eighths and blue a run of 0.5 then of 0.
+	var green []byte
+	var want []float32
+	for x := 0; x < 8; x++ {
+		green = append(green, byte(x*16))
+		blue := float32(0.5)
+		if x >= 4 {
+			blue = 0
+		}
+		want = append(want, 1, float32(x)/8, blue)
+	}
+	rle := []byte{2, 2, 0, 8, 128 + 8, 128, 8}
+	rle = append(rle, green...)
+	rle = append(rle, 128+4, 64, 128+4, 0, 128+8, 129)
+
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+		want          []float32
+	}{
+		{"flat", hdrFile(2, 2,
+			128, 64, 32, 129, 0, 0, 0, 0,
+			128, 128, 128, 130, 16, 32, 64, 128),
+			2, 2, []float32{1, 0.5, 0.25, 0, 0, 0, 2, 2, 2,
+				1.0 / 16, 1.0 / 8, 1.0 / 4}},
+		{"run length", hdrFile(8, 1, rle...), 8, 1, want},
+		// Other header lines are skipped
+		{"header lines", append([]byte("#?RGBE\nGAMMA=1\n"+
+			"FORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 1\n"), 128, 0, 0, 129),
+			1, 1, []float32{1, 0, 0}},
+	}
+	for _, test := range tests {
+		img, err := DecodeHDR(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if img.Width != test.width || img.Height != test.height ||
+			img.Channels != 3 || img.Type != gl.FLOAT {
+			t.Errorf("%s: %dx%d with %d channels of %d", test.name, img.Width,
+				img.Height, img.Channels, img.Type)
+			continue
+		}
+		if got := floats(img.Pix); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDecodeBrokenHDR(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not hdr", []byte("P6\n1 1\n255\n"), "not a Radiance hdr"},
+		{"other format", []byte("#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n"),
+			"unsupported hdr FORMAT"},
+		{"no blank line", []byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n"),
+			"hdr header"},
+		{"flipped", []byte("#?RADIANCE\n\n+Y 1 +X 1\n"),
+			"unsupported hdr resolution"},
+		{"empty", hdrFile(0, 1), "unsupported hdr resolution"},
+		{"huge", hdrFile(100000, 100000), "at most 65536x65536"},
+		// Claiming the largest size with no data fails on the first row
+		{"cut short", hdrFile(MAX_SIZE, MAX_SIZE), "row 0"},
+		{"flat cut short", hdrFile(2, 1, 128, 0, 0, 129), "row 0"},
+		{"wrong width", hdrFile(8, 1, 2, 2, 0, 9), "doesn't match"},
+		{"run past the end", hdrFile(8, 1, 2, 2, 0, 8, 128+9, 0),
+			"past the end"},
+		{"empty run", hdrFile(8, 1, 2, 2, 0, 8, 0), "bad scanline run"},
+		{"second row missing", hdrFile(1, 2, 128, 0, 0, 129), "row 1"},
+	}
+	for _, test := range tests {
+		_, err := DecodeHDR(bytes.NewReader(test.data))
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+		} else if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: error %q, want it to say %q", test.name, err,
+				test.want)
+		}
+	}
+}
+
+func TestEncodeHDR(t *testing.T) {
+	// Powers of two a few apart share an exponent and survive exactly
+	pixels := func(n int) []float32 {
+		var values []float32
+		for i := 0; i < n; i++ {
+			v := float32(math.Ldexp(1, i%8-4))
+			values = append(values, v, v/2, v/4)
+		}
+		return values
+	}
+
+	tests := []struct {
+		name    string
+		img     *Image
+		want    []float32
+		encoded bool
+	}{
+		{"flat", &Image{Width: 3, Height: 2, Channels: 3, Type: gl.FLOAT,
+			Pix: floatPix(pixels(6)...)}, pixels(6), false},
+		{"run length", &Image{Width: 9, Height: 2, Channels: 3,
+			Type: gl.FLOAT, Pix: floatPix(pixels(18)...)}, pixels(18), true},
+		// Wider than a literal run
+		{"long runs", &Image{Width: 200, Height: 1, Channels: 3,
+			Type: gl.FLOAT, Pix: floatPix(pixels(200)...)}, pixels(200),
+			true},
+		// Gray goes in every channel and alpha is dropped
+		{"gray alpha", &Image{Width: 2, Height: 1, Channels: 2,
+			Type: gl.FLOAT, Pix: floatPix(2, 0.5, 0.25, 1)},
+			[]float32{2, 2, 2, 0.25, 0.25, 0.25}, false},
+		{"rgba", &Image{Width: 1, Height: 1, Channels: 4, Type: gl.FLOAT,
+			Pix: floatPix(1, 0.5, 0.25, 0.125)},
+			[]float32{1, 0.5, 0.25}, false},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := EncodeHDR(&b, test.img); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		header := fmt.Sprintf("-Y %d +X %d\n", test.img.Height,
+			test.img.Width)
+		i := bytes.Index(b.Bytes(), []byte(header))
+		if i < 0 {
+			t.Errorf("%s: no %q in the header", test.name, header)
+			continue
+		}
+		first := b.Bytes()[i+len(header):]
+		encoded := first[0] == 2 && first[1] == 2
+		if encoded != test.encoded {
+			t.Errorf("%s: run length encoded is %t, want %t", test.name,
+				encoded, test.encoded)
+		}
+
+		img, err := DecodeHDR(&b)
+		if err != nil {
+			t.Errorf("%s: decoding: %v", test.name, err)
+			continue
+		}
+		if img.Width != test.img.Width || img.Height != test.img.Height {
+			t.Errorf("%s: decoded %dx%d", test.name, img.Width, img.Height)
+		}
+		if got := floats(img.Pix); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	img := &Image{Width: 1, Height: 1, Channels: 1, Type: gl.UNSIGNED_BYTE,
+		Pix: []byte{1}}
+	if err := EncodeHDR(&bytes.Buffer{}, img); err == nil {
+		t.Errorf("encoding bytes didn't error")
+	}
+}
@@ -0,0 +1,304 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Image is decoded pixels kept in as few channels and bits as the file
+// had. Pix holds rows from the top, each pixel's channels are Type, 8 or
+// 16 bit unsigned or 32 bit float, little endian like the GPU expects.
+type Image struct {
+	Width    int
+	Height   int
+	Channels int
+	// Type is UNSIGNED_BYTE, UNSIGNED_SHORT or FLOAT
+	Type uint32
+	Pix  []byte
+}
+
+// DecodeFile decodes an image file, .hdr files as Radiance float images
+// and everything else with the image package
+func DecodeFile(path string) (*Image, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".hdr" {
+		return DecodeHDR(bytes.NewReader(data))
+	}
+	return Decode(bytes.NewReader(data))
+}
+
+// Decode decodes a PNG, JPEG or any other registered format. Gray images
+// keep 1 channel, gray with alpha 2, RGB 3 and 16 bit images stay 16 bit.
+func Decode(r io.Reader) (*Image, error) {
+	// The PNG header says whether gray images have alpha and RGB images
+	// don't, which is lost once the image package decodes them
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return fromImage(img, pngChannels(data)), nil
+}
+
+// pngChannels is how many channels a PNG's pixels have, or 0 if data
+// isn't a PNG
+func pngChannels(data []byte) int {
+	if len(data) < 26 || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		return 0
+	}
+	switch data[25] {
+	case 0:
+		// A tRNS chunk makes one gray value transparent, so there's alpha
+		if pngHasTransparency(data) {
+			return 2
+		}
+		return 1
+	case 2:
+		// Same for one RGB colour
+		if pngHasTransparency(data) {
+			return 4
+		}
+		return 3
+	case 4:
+		return 2
+	}
+	return 4
+}
+
+// pngHasTransparency looks for a tRNS chunk, which has to come before the
+// image data
+func pngHasTransparency(data []byte) bool {
+	// Chunks are a 4 byte length and type, the data then a 4 byte CRC
+	for i := 8; i+8 <= len(data); {
+		length := int64(binary.BigEndian.Uint32(data[i:]))
+		switch string(data[i+4 : i+8]) {
+		case "tRNS":
+			return true
+		case "IDAT", "IEND":
+			return false
+		}
+		next := int64(i) + 12 + length
+		if next > int64(len(data)) {
+			return false
+		}
+		i = int(next)
+	}
+	return false
+}
+
+// fromImage converts an image, channels is 0 to go by its colour model
+func fromImage(img image.Image, channels int) *Image {
+	sixteen := false
+	switch img.(type) {
+	case *image.Gray16, *image.RGBA64, *image.NRGBA64:
+		sixteen = true
+	}
+	if channels == 0 {
+		switch img.ColorModel() {
+		case color.GrayModel, color.Gray16Model:
+			channels = 1
+		case color.YCbCrModel, color.CMYKModel:
+			channels = 3
+		default:
+			channels = 4
+		}
+	}
+
+	bounds := img.Bounds()
+	out := &Image{Width: bounds.Dx(), Height: bounds.Dy(), Channels: channels,
+		Type: gl.UNSIGNED_BYTE}
+	if sixteen {
+		out.Type = gl.UNSIGNED_SHORT
+	}
+	size := typeSize(out.Type)
+	out.Pix = make([]byte, out.Width*out.Height*channels*size)
+
+	i := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !sixteen {
+				// Skip the generic conversion for the common formats
+				if rgba, ok := pixel8(img, x, y); ok {
+					if channels == 2 {
+						rgba[1] = rgba[3]
+					}
+					i += copy(out.Pix[i:], rgba[:channels])
+					continue
+				}
+			}
+
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			values := [4]uint16{c.R, c.G, c.B, c.A}
+			if channels == 2 {
+				values[1] = c.A
+			}
+			for _, v := range values[:channels] {
+				if sixteen {
+					binary.LittleEndian.PutUint16(out.Pix[i:], v)
+					i += 2
+				} else {
+					out.Pix[i] = uint8(v >> 8)
+					i++
+				}
+			}
+		}
+	}
+	return out
+}
+
+// pixel8 reads an unpremultiplied 8 bit pixel straight from the images
+// PNGs and JPEGs usually decode to
+func pixel8(img image.Image, x, y int) ([4]uint8, bool) {
+	switch img := img.(type) {
+	case *image.NRGBA:
+		p := img.Pix[img.PixOffset(x, y):]
+		return [4]uint8{p[0], p[1], p[2], p[3]}, true
+	case *image.RGBA:
+		p := img.Pix[img.PixOffset(x, y):]
+		if p[3] == 0xff || p[3] == 0 {
+			return [4]uint8{p[0], p[1], p[2], p[3]}, true
+		}
+	case *image.Gray:
+		g := img.Pix[img.PixOffset(x, y)]
+		return [4]uint8{g, g, g, 0xff}, true
+	case *image.YCbCr:
+		c := img.YCbCrAt(x, y)
+		r, g, b := color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+		return [4]uint8{r, g, b, 0xff}, true
+	}
+	return [4]uint8{}, false
+}
+
+// ToImage converts an 8 or 16 bit image back to the image package's types
+// for encoding, gray images stay gray
+func (img *Image) ToImage() (image.Image, error) {
+	if img.Type == gl.FLOAT {
+		return nil, errors.New("texture: float images can't convert, " +
+			"see EncodeHDR")
+	}
+	size := typeSize(img.Type)
+	rect := image.Rect(0, 0, img.Width, img.Height)
+
+	var out draw.Image
+	switch {
+	case img.Channels == 1 && size == 1:
+		out = image.NewGray(rect)
+	case img.Channels == 1:
+		out = image.NewGray16(rect)
+	case size == 1:
+		out = image.NewNRGBA(rect)
+	default:
+		out = image.NewNRGBA64(rect)
+	}
+
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			p := img.Pix[(y*img.Width+x)*img.Channels*size:]
+			var values [4]uint16
+			for c := 0; c < img.Channels; c++ {
+				if size == 1 {
+					values[c] = uint16(p[c]) * 0x101
+				} else {
+					values[c] = binary.LittleEndian.Uint16(p[c*2:])
+				}
+			}
+			var c color.NRGBA64
+			switch img.Channels {
+			case 1:
+				c = color.NRGBA64{values[0], values[0], values[0], 0xffff}
+			case 2:
+				c = color.NRGBA64{values[0], values[0], values[0], values[1]}
+			case 3:
+				c = color.NRGBA64{values[0], values[1], values[2], 0xffff}
+			default:
+				c = color.NRGBA64{values[0], values[1], values[2], values[3]}
+			}
+			out.Set(x, y, c)
+		}
+	}
+	return out, nil
+}
+
+// Flip turns the image upside down, OpenGL wants the bottom row first
+func (img *Image) Flip() {
+	stride := img.Width * img.Channels * typeSize(img.Type)
+	row := make([]byte, stride)
+	for top, bottom := 0, img.Height-1; top < bottom; top, bottom =
+		top+1, bottom-1 {
+
+		a := img.Pix[top*stride : (top+1)*stride]
+		b := img.Pix[bottom*stride : (bottom+1)*stride]
+		copy(row, a)
+		copy(a, b)
+		copy(b, row)
+	}
+}
+
+// Expand adds channels up to channels, gray is copied into green and blue
+// and alpha is opaque
+func (img *Image) Expand(channels int) {
+	if channels <= img.Channels {
+		return
+	}
+	size := typeSize(img.Type)
+	pix := make([]byte, img.Width*img.Height*channels*size)
+	opaque := make([]byte, size)
+	switch img.Type {
+	case gl.UNSIGNED_BYTE:
+		opaque[0] = 0xff
+	case gl.UNSIGNED_SHORT:
+		binary.LittleEndian.PutUint16(opaque, 0xffff)
+	case gl.FLOAT:
+		binary.LittleEndian.PutUint32(opaque, math.Float32bits(1.0))
+	}
+
+	for p := 0; p < img.Width*img.Height; p++ {
+		src := img.Pix[p*img.Channels*size:]
+		dst := pix[p*channels*size:]
+		gray := img.Channels <= 2
+		for c := 0; c < channels; c++ {
+			var from []byte
+			switch {
+			case gray && c < 3:
+				from = src[:size]
+			case gray && c == 3 && img.Channels == 2:
+				from = src[size : 2*size]
+			case !gray && c < img.Channels:
+				from = src[c*size : (c+1)*size]
+			default:
+				from = opaque
+			}
+			copy(dst[c*size:], from)
+		}
+	}
+	img.Pix = pix
+	img.Channels = channels
+}
+
+func typeSize(glType uint32) int {
+	switch glType {
+	case gl.UNSIGNED_SHORT:
+		return 2
+	case gl.FLOAT:
+		return 4
+	}
+	return 1
+}
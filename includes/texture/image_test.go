@@ -0,0 +1,190 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// withTRNS adds a tRNS chunk after the IHDR of a PNG
+func withTRNS(data []byte, trns []byte) []byte {
+	chunk := make([]byte, 12+len(trns))
+	binary.BigEndian.PutUint32(chunk, uint32(len(trns)))
+	copy(chunk[4:], "tRNS")
+	copy(chunk[8:], trns)
+	binary.BigEndian.PutUint32(chunk[8+len(trns):],
+		crc32.ChecksumIEEE(chunk[4:8+len(trns)]))
+
+	// The signature then IHDR's length, type, 13 bytes and CRC
+	ihdrEnd := 8 + 12 + 13
+	out := append([]byte(nil), data[:ihdrEnd]...)
+	out = append(out, chunk...)
+	return append(out, data[ihdrEnd:]...)
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestDecodePNGChannels(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 2, 1))
+	gray.Pix = []uint8{10, 200}
+	gray16 := image.NewGray16(image.Rect(0, 0, 2, 1))
+	gray16.SetGray16(0, 0, color.Gray16{Y: 10})
+	gray16.SetGray16(1, 0, color.Gray16{Y: 0x1234})
+	rgb := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	rgb.Pix = []uint8{1, 2, 3, 255, 40, 50, 60, 255}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		channels int
+		// The transparent first pixel's alpha, -1 when there's no alpha
+		alpha int
+	}{
+		{"gray", encodePNG(t, gray), 1, -1},
+		{"gray tRNS", withTRNS(encodePNG(t, gray), []byte{0, 10}), 2, 0},
+		{"gray16 tRNS", withTRNS(encodePNG(t, gray16), []byte{0, 10}), 2,
+			0},
+		{"rgb", encodePNG(t, rgb), 3, -1},
+		{"rgb tRNS", withTRNS(encodePNG(t, rgb),
+			[]byte{0, 1, 0, 2, 0, 3}), 4, 0},
+	}
+	for _, test := range tests {
+		img, err := Decode(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if img.Channels != test.channels {
+			t.Errorf("%s: %d channels, want %d", test.name, img.Channels,
+				test.channels)
+			continue
+		}
+		if test.alpha < 0 {
+			continue
+		}
+
+		size := typeSize(img.Type)
+		pixel := img.Channels * size
+		// Alpha is the last channel, the high byte of 16 bit ones
+		first, second := img.Pix[pixel-1], img.Pix[2*pixel-1]
+		if int(first) != test.alpha || second != 0xff {
+			t.Errorf("%s: alphas %d and %d, want %d and 255", test.name,
+				first, second, test.alpha)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	short := func(values ...uint16) []byte {
+		pix := make([]byte, len(values)*2)
+		for i, v := range values {
+			binary.LittleEndian.PutUint16(pix[i*2:], v)
+		}
+		return pix
+	}
+
+	tests := []struct {
+		name     string
+		img      Image
+		channels int
+		want     []byte
+	}{
+		{"gray", Image{Width: 2, Height: 1, Channels: 1,
+			Type: gl.UNSIGNED_BYTE, Pix: []byte{10, 20}}, 4,
+			[]byte{10, 10, 10, 255, 20, 20, 20, 255}},
+		{"gray to rgb", Image{Width: 1, Height: 1, Channels: 1,
+			Type: gl.UNSIGNED_BYTE, Pix: []byte{10}}, 3, []byte{10, 10, 10}},
+		{"gray alpha", Image{Width: 2, Height: 1, Channels: 2,
+			Type: gl.UNSIGNED_BYTE, Pix: []byte{10, 1, 20, 2}}, 4,
+			[]byte{10, 10, 10, 1, 20, 20, 20, 2}},
+		{"rgb", Image{Width: 1, Height: 1, Channels: 3,
+			Type: gl.UNSIGNED_BYTE, Pix: []byte{1, 2, 3}}, 4,
+			[]byte{1, 2, 3, 255}},
+		// Without alpha to keep the second channel is dropped
+		{"rg", Image{Width: 1, Height: 1, Channels: 2,
+			Type: gl.UNSIGNED_BYTE, Pix: []byte{1, 2}}, 3, []byte{1, 1, 1}},
+		{"16 bit", Image{Width: 1, Height: 1, Channels: 1,
+			Type: gl.UNSIGNED_SHORT, Pix: short(0x1234)}, 4,
+			short(0x1234, 0x1234, 0x1234, 0xffff)},
+		{"float", Image{Width: 1, Height: 1, Channels: 3, Type: gl.FLOAT,
+			Pix: floatPix(0.5, 2, 4)}, 4, floatPix(0.5, 2, 4, 1)},
+		// Images never lose channels
+		{"fewer", Image{Width: 1, Height: 1, Channels: 3,
+			Type: gl.UNSIGNED_BYTE, Pix: []byte{1, 2, 3}}, 1,
+			[]byte{1, 2, 3}},
+	}
+	for _, test := range tests {
+		img := test.img
+		img.Expand(test.channels)
+		want := max(test.channels, test.img.Channels)
+		if img.Channels != want || !bytes.Equal(img.Pix, test.want) {
+			t.Errorf("%s: got %d channels %v, want %d %v", test.name,
+				img.Channels, img.Pix, want, test.want)
+		}
+	}
+}
+
+func TestFlip(t *testing.T) {
+	tests := []struct {
+		name string
+		img  Image
+		want []byte
+	}{
+		{"odd rows", Image{Width: 2, Height: 3, Channels: 1,
+			Type: gl.UNSIGNED_BYTE, Pix: []byte{1, 2, 3, 4, 5, 6}},
+			[]byte{5, 6, 3, 4, 1, 2}},
+		{"16 bit", Image{Width: 1, Height: 2, Channels: 2,
+			Type: gl.UNSIGNED_SHORT, Pix: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+			[]byte{5, 6, 7, 8, 1, 2, 3, 4}},
+		{"one row", Image{Width: 3, Height: 1, Channels: 1,
+			Type: gl.UNSIGNED_BYTE, Pix: []byte{1, 2, 3}},
+			[]byte{1, 2, 3}},
+	}
+	for _, test := range tests {
+		img := test.img
+		img.Flip()
+		if !bytes.Equal(img.Pix, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, img.Pix, test.want)
+		}
+	}
+}
+
+func TestPickFormat(t *testing.T) {
+	tests := []struct {
+		glType   uint32
+		channels int
+		srgb     bool
+		want     int32
+	}{
+		{gl.UNSIGNED_BYTE, 1, false, gl.R8},
+		{gl.UNSIGNED_BYTE, 2, false, gl.RG8},
+		{gl.UNSIGNED_BYTE, 3, false, gl.RGB8},
+		{gl.UNSIGNED_BYTE, 4, false, gl.RGBA8},
+		{gl.UNSIGNED_SHORT, 1, false, gl.R16},
+		{gl.UNSIGNED_SHORT, 4, false, gl.RGBA16},
+		{gl.FLOAT, 2, false, gl.RG16F},
+		{gl.FLOAT, 3, false, gl.RGB16F},
+		{gl.UNSIGNED_BYTE, 3, true, gl.SRGB8},
+		{gl.UNSIGNED_BYTE, 4, true, gl.SRGB8_ALPHA8},
+	}
+	for _, test := range tests {
+		img := &Image{Channels: test.channels, Type: test.glType}
+		if got := PickFormat(img, test.srgb); got != test.want {
+			t.Errorf("%d channels of %d, srgb %t: got %d, want %d",
+				test.channels, test.glType, test.srgb, got, test.want)
+		}
+	}
+}
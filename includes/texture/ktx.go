@@ -0,0 +1,318 @@
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+var ktxIdentifier = []byte("\xabKTX 11\xbb\r\n\x1a\n")
+var ktx2Identifier = []byte("\xabKTX 20\xbb\r\n\x1a\n")
+
+// blockSizes are the bytes in a 4x4 block of each compressed format
+var blockSizes = map[uint32]int{
+	gl.COMPRESSED_RGB_S3TC_DXT1_EXT:              8,
+	gl.COMPRESSED_RGBA_S3TC_DXT1_EXT:             8,
+	COMPRESSED_SRGB_S3TC_DXT1_EXT:                8,
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT:          8,
+	gl.COMPRESSED_RGBA_S3TC_DXT3_EXT:             16,
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT:          16,
+	gl.COMPRESSED_RGBA_S3TC_DXT5_EXT:             16,
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT:          16,
+	gl.COMPRESSED_RED_RGTC1:                      8,
+	gl.COMPRESSED_SIGNED_RED_RGTC1:               8,
+	gl.COMPRESSED_RG_RGTC2:                       16,
+	gl.COMPRESSED_SIGNED_RG_RGTC2:                16,
+	gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT_ARB:    16,
+	gl.COMPRESSED_RGB_BPTC_SIGNED_FLOAT_ARB:      16,
+	gl.COMPRESSED_RGBA_BPTC_UNORM_ARB:            16,
+	gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB:      16,
+	gl.COMPRESSED_RGB8_ETC2:                      8,
+	gl.COMPRESSED_SRGB8_ETC2:                     8,
+	gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2:  8,
+	gl.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2: 8,
+	gl.COMPRESSED_RGBA8_ETC2_EAC:                 16,
+	gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC:          16,
+	gl.COMPRESSED_R11_EAC:                        8,
+	gl.COMPRESSED_SIGNED_R11_EAC:                 8,
+	gl.COMPRESSED_RG11_EAC:                       16,
+	gl.COMPRESSED_SIGNED_RG11_EAC:                16,
+}
+
+// decodeKTX reads a KTX 1 file. Its images are in GL's terms already, rows
+// of uncompressed ones are padded to 4 bytes.
+func decodeKTX(data []byte) (*Container, error) {
+	if len(data) < 64 {
+		return nil, errors.New("texture: KTX header is cut short")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	switch binary.LittleEndian.Uint32(data[12:]) {
+	case 0x04030201:
+	case 0x01020304:
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("texture: KTX endianness is broken")
+	}
+	field := func(i int) uint32 {
+		return order.Uint32(data[16+i*4:])
+	}
+	glType, glTypeSize, glFormat := field(0), field(1), field(2)
+	internalFormat := field(3)
+	width, height, depth := int(field(5)), int(field(6)), int(field(7))
+	layers, faces, levels := int(field(8)), int(field(9)), int(field(10))
+
+	if height == 0 || depth > 1 {
+		return nil, errors.New("texture: only 2D KTX textures are supported")
+	}
+	if faces != 1 && faces != 6 {
+		return nil, fmt.Errorf("texture: KTX has %d faces", faces)
+	}
+
+	var format Format
+	if glType == 0 {
+		size, ok := blockSizes[internalFormat]
+		if !ok {
+			return nil, fmt.Errorf("texture: unsupported KTX compressed "+
+				"format 0x%x", internalFormat)
+		}
+		format = compressedFormat(internalFormat, size)
+	} else {
+		size := pixelSize(glFormat, glType, int(glTypeSize))
+		if size == 0 {
+			return nil, fmt.Errorf("texture: unsupported KTX format 0x%x "+
+				"type 0x%x", glFormat, glType)
+		}
+		format = pixelFormat(internalFormat, glFormat, glType, size)
+	}
+
+	offset := 64 + int(field(11))
+	if err := checkHeader("KTX", width, height, layers, faces, levels,
+		len(data)-offset); err != nil {
+		return nil, err
+	}
+
+	// 0 levels asks for mipmaps to be generated
+	c := &Container{Width: width, Height: height, Layers: layers,
+		Faces: faces, Format: format, Levels: make([][][]byte, max(1, levels))}
+	for level := range c.Levels {
+		// Skip imageSize, it's worked out instead as it means different
+		// things for cubemaps
+		offset += 4
+
+		w, h := c.LevelSize(level)
+		row := w * format.Size
+		paddedRow := align4(row)
+		size := paddedRow * h
+		if format.Compressed {
+			size = format.ImageSize(w, h)
+		}
+
+		c.Levels[level] = make([][]byte, c.images())
+		for i := range c.Levels[level] {
+			if size > len(data)-offset {
+				return nil, fmt.Errorf("texture: KTX level %d is cut short",
+					level)
+			}
+			image := data[offset : offset+size]
+			if !format.Compressed && paddedRow != row {
+				image = unpad(image, row, paddedRow, h)
+			}
+			if order == binary.BigEndian && glTypeSize > 1 {
+				image = swapBytes(image, int(glTypeSize))
+			}
+			c.Levels[level][i] = image
+			// Faces of cubemaps are padded to 4 bytes too
+			offset = align4(offset + size)
+		}
+	}
+	return c, nil
+}
+
+// pixelSize is the bytes in a texel of an uncompressed format
+func pixelSize(format, glType uint32, typeSize int) int {
+	switch glType {
+	case gl.UNSIGNED_SHORT_5_6_5, gl.UNSIGNED_SHORT_4_4_4_4,
+		gl.UNSIGNED_SHORT_5_5_5_1:
+		return 2
+	case gl.UNSIGNED_INT_10F_11F_11F_REV, gl.UNSIGNED_INT_5_9_9_9_REV,
+		gl.UNSIGNED_INT_2_10_10_10_REV:
+		return 4
+	}
+	channels := map[uint32]int{gl.RED: 1, gl.RG: 2, gl.RGB: 3, gl.BGR: 3,
+		gl.RGBA: 4, gl.BGRA: 4}[format]
+	return channels * typeSize
+}
+
+func unpad(image []byte, row, paddedRow, height int) []byte {
+	tight := make([]byte, row*height)
+	for y := 0; y < height; y++ {
+		copy(tight[y*row:], image[y*paddedRow:y*paddedRow+row])
+	}
+	return tight
+}
+
+// swapBytes makes big endian values of size bytes little endian
+func swapBytes(image []byte, size int) []byte {
+	swapped := make([]byte, len(image))
+	for i := 0; i+size <= len(image); i += size {
+		for j := 0; j < size; j++ {
+			swapped[i+j] = image[i+size-1-j]
+		}
+	}
+	return swapped
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
+
+// decodeKTX2 reads a KTX 2 file. Levels can be zlib supercompressed,
+// Basis Universal and Zstandard aren't supported.
+func decodeKTX2(data []byte) (*Container, error) {
+	if len(data) < 80 {
+		return nil, errors.New("texture: KTX2 header is cut short")
+	}
+	field := func(i int) uint32 {
+		return binary.LittleEndian.Uint32(data[12+i*4:])
+	}
+	vkFormat := field(0)
+	width, height, depth := int(field(2)), int(field(3)), int(field(4))
+	layers, faces, levels := int(field(5)), int(field(6)), int(field(7))
+	supercompression := field(8)
+
+	if vkFormat == 0 {
+		return nil, errors.New("texture: Basis Universal KTX2 textures " +
+			"need transcoding, which isn't supported")
+	}
+	format, ok := vkFormats[vkFormat]
+	if !ok {
+		return nil, fmt.Errorf("texture: unsupported KTX2 format %d",
+			vkFormat)
+	}
+	if height == 0 || depth > 1 {
+		return nil, errors.New("texture: only 2D KTX2 textures are supported")
+	}
+	if faces != 1 && faces != 6 {
+		return nil, fmt.Errorf("texture: KTX2 has %d faces", faces)
+	}
+	switch supercompression {
+	case 0, 3:
+	case 1:
+		return nil, errors.New("texture: BasisLZ KTX2 textures aren't " +
+			"supported")
+	case 2:
+		return nil, errors.New("texture: Zstandard KTX2 textures aren't " +
+			"supported")
+	default:
+		return nil, fmt.Errorf("texture: unknown KTX2 supercompression %d",
+			supercompression)
+	}
+
+	if err := checkHeader("KTX2", width, height, layers, faces, levels,
+		len(data)-80); err != nil {
+		return nil, err
+	}
+
+	c := &Container{Width: width, Height: height, Layers: layers,
+		Faces: faces, Format: format, Levels: make([][][]byte, max(1, levels))}
+	if 80+len(c.Levels)*24 > len(data) {
+		return nil, errors.New("texture: KTX2 level index is cut short")
+	}
+	for level := range c.Levels {
+		index := data[80+level*24:]
+		offset := binary.LittleEndian.Uint64(index)
+		length := binary.LittleEndian.Uint64(index[8:])
+		if offset > uint64(len(data)) ||
+			length > uint64(len(data))-offset {
+			return nil, fmt.Errorf("texture: KTX2 level %d is cut short",
+				level)
+		}
+
+		w, h := c.LevelSize(level)
+		size := format.ImageSize(w, h)
+		levelData := data[offset : offset+length]
+		if supercompression == 3 {
+			r, err := zlib.NewReader(bytes.NewReader(levelData))
+			if err != nil {
+				return nil, fmt.Errorf("texture: KTX2 level %d: %v", level,
+					err)
+			}
+			// Only inflate as much as the level needs
+			levelData, err = ioutil.ReadAll(io.LimitReader(r,
+				int64(size)*int64(c.images())))
+			if err != nil {
+				return nil, fmt.Errorf("texture: KTX2 level %d: %v", level,
+					err)
+			}
+		}
+
+		if size > len(levelData)/c.images() {
+			return nil, fmt.Errorf("texture: KTX2 level %d is cut short",
+				level)
+		}
+		c.Levels[level] = make([][]byte, c.images())
+		for i := range c.Levels[level] {
+			c.Levels[level][i] = levelData[i*size : (i+1)*size]
+		}
+	}
+	return c, nil
+}
+
+// vkFormats are the Vulkan formats KTX2 files use that GL has
+var vkFormats = map[uint32]Format{
+	9:   pixelFormat(gl.R8, gl.RED, gl.UNSIGNED_BYTE, 1),
+	16:  pixelFormat(gl.RG8, gl.RG, gl.UNSIGNED_BYTE, 2),
+	23:  pixelFormat(gl.RGB8, gl.RGB, gl.UNSIGNED_BYTE, 3),
+	29:  pixelFormat(gl.SRGB8, gl.RGB, gl.UNSIGNED_BYTE, 3),
+	37:  pixelFormat(gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, 4),
+	43:  pixelFormat(gl.SRGB8_ALPHA8, gl.RGBA, gl.UNSIGNED_BYTE, 4),
+	44:  pixelFormat(gl.RGBA8, gl.BGRA, gl.UNSIGNED_BYTE, 4),
+	50:  pixelFormat(gl.SRGB8_ALPHA8, gl.BGRA, gl.UNSIGNED_BYTE, 4),
+	70:  pixelFormat(gl.R16, gl.RED, gl.UNSIGNED_SHORT, 2),
+	76:  pixelFormat(gl.R16F, gl.RED, gl.HALF_FLOAT, 2),
+	77:  pixelFormat(gl.RG16, gl.RG, gl.UNSIGNED_SHORT, 4),
+	83:  pixelFormat(gl.RG16F, gl.RG, gl.HALF_FLOAT, 4),
+	90:  pixelFormat(gl.RGB16F, gl.RGB, gl.HALF_FLOAT, 6),
+	91:  pixelFormat(gl.RGBA16, gl.RGBA, gl.UNSIGNED_SHORT, 8),
+	97:  pixelFormat(gl.RGBA16F, gl.RGBA, gl.HALF_FLOAT, 8),
+	100: pixelFormat(gl.R32F, gl.RED, gl.FLOAT, 4),
+	103: pixelFormat(gl.RG32F, gl.RG, gl.FLOAT, 8),
+	106: pixelFormat(gl.RGB32F, gl.RGB, gl.FLOAT, 12),
+	109: pixelFormat(gl.RGBA32F, gl.RGBA, gl.FLOAT, 16),
+	122: pixelFormat(gl.R11F_G11F_B10F, gl.RGB,
+		gl.UNSIGNED_INT_10F_11F_11F_REV, 4),
+	123: pixelFormat(gl.RGB9_E5, gl.RGB, gl.UNSIGNED_INT_5_9_9_9_REV, 4),
+
+	131: compressedFormat(gl.COMPRESSED_RGB_S3TC_DXT1_EXT, 8),
+	132: compressedFormat(COMPRESSED_SRGB_S3TC_DXT1_EXT, 8),
+	133: compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT1_EXT, 8),
+	134: compressedFormat(COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT, 8),
+	135: compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT3_EXT, 16),
+	136: compressedFormat(COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT, 16),
+	137: compressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, 16),
+	138: compressedFormat(COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT, 16),
+	139: compressedFormat(gl.COMPRESSED_RED_RGTC1, 8),
+	140: compressedFormat(gl.COMPRESSED_SIGNED_RED_RGTC1, 8),
+	141: compressedFormat(gl.COMPRESSED_RG_RGTC2, 16),
+	142: compressedFormat(gl.COMPRESSED_SIGNED_RG_RGTC2, 16),
+	143: compressedFormat(gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT_ARB, 16),
+	144: compressedFormat(gl.COMPRESSED_RGB_BPTC_SIGNED_FLOAT_ARB, 16),
+	145: compressedFormat(gl.COMPRESSED_RGBA_BPTC_UNORM_ARB, 16),
+	146: compressedFormat(gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB, 16),
+	147: compressedFormat(gl.COMPRESSED_RGB8_ETC2, 8),
+	148: compressedFormat(gl.COMPRESSED_SRGB8_ETC2, 8),
+	149: compressedFormat(gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, 8),
+	150: compressedFormat(gl.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2, 8),
+	151: compressedFormat(gl.COMPRESSED_RGBA8_ETC2_EAC, 16),
+	152: compressedFormat(gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC, 16),
+	153: compressedFormat(gl.COMPRESSED_R11_EAC, 8),
+	154: compressedFormat(gl.COMPRESSED_SIGNED_R11_EAC, 8),
+	155: compressedFormat(gl.COMPRESSED_RG11_EAC, 16),
+	156: compressedFormat(gl.COMPRESSED_SIGNED_RG11_EAC, 16),
+}
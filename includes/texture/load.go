@@ -0,0 +1,192 @@
+package texture
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Options changes how a texture is made. The zero value is a repeating,
+// mipmapped texture in the image's own format.
+type Options struct {
+	// InternalFormat overrides the format picked from the image
+	InternalFormat int32
+	// SRGB colour textures are linearized when sampled. Gray images are
+	// expanded to RGB for it as there are no 1 or 2 channel sRGB formats.
+	SRGB bool
+	// Flip puts the bottom row first, for texture coordinates starting at
+	// the bottom left
+	Flip bool
+	// Wrap modes, 0 is REPEAT
+	WrapS int32
+	WrapT int32
+	// Filters, 0 is LINEAR_MIPMAP_LINEAR or LINEAR without mipmaps for
+	// minifying and LINEAR for magnifying
+	MinFilter int32
+	MagFilter int32
+	// Anisotropy is the most samples anisotropic filtering takes, clamped
+	// to what the driver supports. 0 or 1 turns it off.
+	Anisotropy float32
+	NoMipmaps  bool
+}
+
+// Texture is a texture and what it was made with
+type Texture struct {
+	ID             uint32
+	Width          int
+	Height         int
+	InternalFormat int32
+	// Target is TEXTURE_2D unless a container had faces or layers
+	Target uint32
+}
+
+// Load reads an image file into a texture, see DecodeFile. KTX, KTX2 and
+// DDS files go to LoadContainer.
+func Load(path string, opts Options) (Texture, error) {
+	if IsContainer(path) {
+		return LoadContainer(path, opts)
+	}
+	img, err := DecodeFile(path)
+	if err != nil {
+		return Texture{}, fmt.Errorf("texture: %s: %v", path, err)
+	}
+	t, err := Upload(img, opts)
+	if err != nil {
+		return Texture{}, fmt.Errorf("texture: %s: %v", path, err)
+	}
+	return t, nil
+}
+
+// MakeTexture is Load that panics on errors
+func MakeTexture(path string, opts Options) Texture {
+	t, err := Load(path, opts)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Upload makes a texture from a decoded image. Flipping and expanding for
+// sRGB change img.
+func Upload(img *Image, opts Options) (Texture, error) {
+	internalFormat, err := Prepare(img, opts)
+	if err != nil {
+		return Texture{}, err
+	}
+
+	t := Texture{Width: img.Width, Height: img.Height,
+		InternalFormat: internalFormat, Target: gl.TEXTURE_2D}
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	TexImage(gl.TEXTURE_2D, img, internalFormat)
+	Swizzle(gl.TEXTURE_2D, img.Channels)
+
+	minFilter := opts.MinFilter
+	if minFilter == 0 {
+		minFilter = gl.LINEAR_MIPMAP_LINEAR
+		if opts.NoMipmaps {
+			minFilter = gl.LINEAR
+		}
+	}
+	if !opts.NoMipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+	SetParameters(gl.TEXTURE_2D, orDefault(opts.WrapS, gl.REPEAT),
+		orDefault(opts.WrapT, gl.REPEAT), minFilter,
+		orDefault(opts.MagFilter, gl.LINEAR), opts.Anisotropy)
+
+	return t, nil
+}
+
+// Prepare flips and expands an image as opts asks and picks its internal
+// format, for uploading with TexImage
+func Prepare(img *Image, opts Options) (int32, error) {
+	if opts.SRGB {
+		if img.Type != gl.UNSIGNED_BYTE {
+			return 0, fmt.Errorf("sRGB needs 8 bit channels")
+		}
+		if img.Channels <= 2 {
+			img.Expand(img.Channels + 2)
+		}
+	}
+	if opts.Flip {
+		img.Flip()
+	}
+
+	if opts.InternalFormat != 0 {
+		return opts.InternalFormat, nil
+	}
+	return PickFormat(img, opts.SRGB), nil
+}
+
+// TexImage uploads img as level 0 of target, which can be a cubemap face
+func TexImage(target uint32, img *Image, internalFormat int32) {
+	format := []uint32{gl.RED, gl.RG, gl.RGB, gl.RGBA}[img.Channels-1]
+
+	// Rows of compact images aren't always 4 byte aligned
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(target, 0, internalFormat, int32(img.Width),
+		int32(img.Height), 0, format, img.Type, gl.Ptr(img.Pix))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+}
+
+// Swizzle makes the texture bound to target sample 1 and 2 channel images
+// as gray and gray with alpha like they would expanded
+func Swizzle(target uint32, channels int) {
+	switch channels {
+	case 1:
+		swizzle := []int32{gl.RED, gl.RED, gl.RED, gl.ONE}
+		gl.TexParameteriv(target, gl.TEXTURE_SWIZZLE_RGBA, &swizzle[0])
+	case 2:
+		swizzle := []int32{gl.RED, gl.RED, gl.RED, gl.GREEN}
+		gl.TexParameteriv(target, gl.TEXTURE_SWIZZLE_RGBA, &swizzle[0])
+	}
+}
+
+// SetParameters sets the wrap modes, filters and anisotropy of the texture
+// bound to target
+func SetParameters(target uint32, wrapS, wrapT, minFilter, magFilter int32,
+	anisotropy float32) {
+
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_S, wrapS)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_T, wrapT)
+	gl.TexParameteri(target, gl.TEXTURE_MIN_FILTER, minFilter)
+	gl.TexParameteri(target, gl.TEXTURE_MAG_FILTER, magFilter)
+
+	if anisotropy > 1.0 {
+		// An extension before OpenGL 4.6 but supported everywhere
+		var max float32
+		gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &max)
+		if max < 1.0 {
+			return
+		}
+		if anisotropy > max {
+			anisotropy = max
+		}
+		gl.TexParameterf(target, gl.TEXTURE_MAX_ANISOTROPY, anisotropy)
+	}
+}
+
+// PickFormat is the sized internal format holding an image without loss,
+// floats are half floats like the HDR chapters use
+func PickFormat(img *Image, srgb bool) int32 {
+	formats := map[uint32][4]int32{
+		gl.UNSIGNED_BYTE:  {gl.R8, gl.RG8, gl.RGB8, gl.RGBA8},
+		gl.UNSIGNED_SHORT: {gl.R16, gl.RG16, gl.RGB16, gl.RGBA16},
+		gl.FLOAT:          {gl.R16F, gl.RG16F, gl.RGB16F, gl.RGBA16F},
+	}
+	if srgb {
+		if img.Channels == 3 {
+			return gl.SRGB8
+		}
+		return gl.SRGB8_ALPHA8
+	}
+	return formats[img.Type][img.Channels-1]
+}
+
+func orDefault(value, fallback int32) int32 {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
@@ -165,36 +165,6 @@ func createBuffers() (uint32, uint32, uint32) {
 	return VBO, cubeVAO, lightVAO
 }
 
-func loadTexture(filePath string) uint32 {
-	var textureID uint32
-	gl.GenTextures(1, &textureID)
-	gl.BindTexture(gl.TEXTURE_2D, textureID)
-
-	data := texture.ImageLoad(filePath)
-
-	gl.BindTexture(gl.TEXTURE_2D, textureID)
-	gl.TexImage2D(
-		gl.TEXTURE_2D,
-		0,
-		gl.RGBA,
-		int32(data.Rect.Size().X),
-		int32(data.Rect.Size().Y),
-		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(data.Pix))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
-
-	// Set texture parameters for wrapping
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER,
-		gl.LINEAR_MIPMAP_LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-	return textureID
-}
-
 func main() {
 	window := initGLFW()
 	defer glfw.Terminate()
@@ -223,9 +193,12 @@ func main() {
 		mgl32.Vec3{-1.3, 1.0, -1.5},
 	}
 
-	diffuseMap := loadTexture("../../../resources/textures/container2.png")
-	specularMap := loadTexture(
-		"../../../resources/textures/container2_specular.png")
+	diffuseMap := texture.MakeTexture(
+		"../../../resources/textures/container2.png",
+		texture.Options{}).ID
+	specularMap := texture.MakeTexture(
+		"../../../resources/textures/container2_specular.png",
+		texture.Options{}).ID
 
 	lightingShader.Use()
 	lightingShader.SetInt("material.diffuse", 0)
@@ -124,7 +124,7 @@ func main() {
 		// Render the model
 		model := mgl32.Translate3D(0.0, -1.75, 0)
 		model = model.Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
-		ourShader.SetMat4("model", model)
+		ourModel.Transform = model
 		ourModel.Draw(ourShader)
 		//log.Println(ourModel)
 
@@ -159,11 +159,11 @@ func main() {
 		// Render the planet
 		model := mgl32.Translate3D(0.0, -3.0, 0)
 		model = model.Mul4(mgl32.Scale3D(4.0, 4.0, 4.0))
-		ourShader.SetMat4("model", model)
+		planet.Transform = model
 		planet.Draw(ourShader)
 
 		for i := 0; i < amount; i++ {
-			ourShader.SetMat4("model", modelMatrices[i])
+			rock.Transform = modelMatrices[i]
 			rock.Draw(ourShader)
 		}
 
@@ -1,7 +1,8 @@
 #version 410 core
 layout (location = 0) in vec3 aPos;
 layout (location = 2) in vec2 aTexCoords;
-layout (location = 3) in mat4 aInstanceMatrix;
+// Locations 3 to 6 are the mesh's tangents, bitangents and bones
+layout (location = 7) in mat4 aInstanceMatrix;
 
 out vec2 TexCoords;
 
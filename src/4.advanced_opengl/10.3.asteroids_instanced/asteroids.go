@@ -15,6 +15,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/mesh"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
@@ -136,38 +137,19 @@ func main() {
 
 	// Config instanced array
 	var buffer uint32
-	sizeOfMat4 := int32(16 * 4)
+	sizeOfMat4 := 16 * 4
 	gl.GenBuffers(1, &buffer)
 	gl.BindBuffer(gl.ARRAY_BUFFER, buffer)
-	gl.BufferData(gl.ARRAY_BUFFER, amount*int(sizeOfMat4),
+	gl.BufferData(gl.ARRAY_BUFFER, amount*sizeOfMat4,
 		unsafe.Pointer(&modelMatrices[0]), gl.STATIC_DRAW)
 
-	// Set transformation matrices as an instance vertex attrib
-	// Note we are using a hack by adding new
-	// vertexAttribPointers to the model meshes
+	// Set transformation matrices as an instance vertex attrib (4 times
+	// vec4) after the locations the model meshes already use
 	for i := 0; i < len(rock.Meshes); i++ {
-		VAO := rock.Meshes[i].VAO
-		gl.BindVertexArray(VAO)
-		// Set attrib points for matrix (4 times vec4)
-		gl.EnableVertexAttribArray(3)
-		gl.VertexAttribPointer(3, 4, gl.FLOAT, false, sizeOfMat4,
-			gl.PtrOffset(0))
-		gl.EnableVertexAttribArray(4)
-		gl.VertexAttribPointer(4, 4, gl.FLOAT, false, sizeOfMat4,
-			gl.PtrOffset(int(sizeOfMat4/4)))
-		gl.EnableVertexAttribArray(5)
-		gl.VertexAttribPointer(5, 4, gl.FLOAT, false, sizeOfMat4,
-			gl.PtrOffset(int(2*sizeOfMat4/4)))
-		gl.EnableVertexAttribArray(6)
-		gl.VertexAttribPointer(6, 4, gl.FLOAT, false, sizeOfMat4,
-			gl.PtrOffset(int(3*sizeOfMat4/4)))
-
-		gl.VertexAttribDivisor(3, 1)
-		gl.VertexAttribDivisor(4, 1)
-		gl.VertexAttribDivisor(5, 1)
-		gl.VertexAttribDivisor(6, 1)
-
-		gl.BindVertexArray(0)
+		location := rock.Meshes[i].Format.NextLocation()
+		instanceFormat := mesh.MakeVertexFormat(
+			mesh.Mat4Attributes("aInstanceMatrix", location, 0, 1)...)
+		rock.Meshes[i].AddStream(buffer, instanceFormat, 0)
 	}
 
 	// Draw in polygon mode
@@ -201,7 +183,7 @@ func main() {
 		// Render the planet
 		model := mgl32.Translate3D(0.0, -3.0, 0)
 		model = model.Mul4(mgl32.Scale3D(4.0, 4.0, 4.0))
-		planetShader.SetMat4("model", model)
+		planet.Transform = model
 		planet.Draw(planetShader)
 
 		// Draw meteorites
@@ -12,6 +12,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
 
@@ -189,51 +190,22 @@ func main() {
 	defer gl.DeleteVertexArrays(1, &quadVAO)
 	defer gl.DeleteVertexArrays(1, &quadVBO)
 
-	// Configure framebuffer
-	var framebuffer uint32
-	gl.GenFramebuffers(1, &framebuffer)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, framebuffer)
-	// Create a multisampled color attachment texture
-	var textureColorBufferMulti uint32
-	gl.GenTextures(1, &textureColorBufferMulti)
-	gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, textureColorBufferMulti)
-	gl.TexImage2DMultisample(gl.TEXTURE_2D_MULTISAMPLE, 4, gl.RGB,
-		windowWidth, windowHeight, true)
-	gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, 0)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.TEXTURE_2D_MULTISAMPLE, textureColorBufferMulti, 0)
-	// Create a renderbuffer object for depth and stencil atachment (multi)
-	var rbo uint32
-	gl.GenRenderbuffers(1, &rbo)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, rbo)
-	gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, 4,
-		gl.DEPTH24_STENCIL8, windowWidth, windowHeight)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT,
-		gl.RENDERBUFFER, rbo)
-	// Ensure framebuffer is complete
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Framebuffer is not complete")
-	}
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	// Configure framebuffer, a multisampled color texture and renderbuffer
+	// for depth and stencil
+	multisampled := framebuffer.Make(framebuffer.Description{
+		Width: windowWidth, Height: windowHeight, Samples: 4,
+		Colors: []framebuffer.Attachment{{InternalFormat: gl.RGB}},
+		Depth: &framebuffer.Attachment{InternalFormat: gl.DEPTH24_STENCIL8,
+			Renderbuffer: true},
+	})
+	defer multisampled.Delete()
 
 	// Configure second post-processing framebuffer
-	var intermediateFBO uint32
-	gl.GenFramebuffers(1, &intermediateFBO)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, intermediateFBO)
-	// Create a color attachment texture
-	var screenTexture uint32
-	gl.GenTextures(1, &screenTexture)
-	gl.BindTexture(gl.TEXTURE_2D, screenTexture)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, windowWidth, windowHeight,
-		0, gl.RGB, gl.UNSIGNED_BYTE, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.TEXTURE_2D, screenTexture, 0)
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Intermediate framebuffer is not complete")
-	}
+	intermediate := framebuffer.Make(framebuffer.Description{
+		Width: windowWidth, Height: windowHeight,
+		Colors: []framebuffer.Attachment{{InternalFormat: gl.RGB}},
+	})
+	defer intermediate.Delete()
 
 	screenShader.Use()
 	screenShader.SetInt("screenTexture", 0)
@@ -253,7 +225,7 @@ func main() {
 		gl.Clear(gl.DEPTH_BUFFER_BIT)
 
 		// Draw scene as normal in multisampled buffers
-		gl.BindFramebuffer(gl.FRAMEBUFFER, framebuffer)
+		multisampled.Bind()
 		gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 		gl.Clear(gl.COLOR_BUFFER_BIT)
 		gl.Clear(gl.DEPTH_BUFFER_BIT)
@@ -273,11 +245,9 @@ func main() {
 		gl.DrawArrays(gl.TRIANGLES, 0, 36)
 
 		// Now blit multisampled buffer to normal colorbuffer
-		// of intermediate FBO. Image stored in screenTexture
-		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, framebuffer)
-		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, intermediateFBO)
-		gl.BlitFramebuffer(0, 0, windowWidth, windowHeight, 0, 0,
-			windowWidth, windowHeight, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+		// of intermediate FBO. Image stored in its texture
+		framebuffer.Blit(multisampled, intermediate, gl.COLOR_BUFFER_BIT,
+			gl.NEAREST)
 
 		// Now render quad with scene's visuals as its texture image
 		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
@@ -289,7 +259,7 @@ func main() {
 		screenShader.Use()
 		gl.BindVertexArray(quadVAO)
 		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, screenTexture)
+		gl.BindTexture(gl.TEXTURE_2D, intermediate.Colors[0])
 		gl.DrawArrays(gl.TRIANGLES, 0, 6)
 
 		window.SwapBuffers()
@@ -12,6 +12,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
@@ -227,32 +228,15 @@ func main() {
 	screenShader.Use()
 	screenShader.SetInt("screenTexture", 0)
 
-	// Frambuffer config
-	var framebuffer uint32
-	gl.GenFramebuffers(1, &framebuffer)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, framebuffer)
-	// Create a color attachment texture
-	var textureColorbuffer uint32
-	gl.GenTextures(1, &textureColorbuffer)
-	gl.BindTexture(gl.TEXTURE_2D, textureColorbuffer)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, windowWidth, windowHeight,
-		0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(nil))
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.TEXTURE_2D, textureColorbuffer, 0)
-	// Create a renderbuffer object for depth and stencil atachment
-	var rbo uint32
-	gl.GenRenderbuffers(1, &rbo)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, rbo)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8,
-		windowWidth, windowHeight)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT,
-		gl.RENDERBUFFER, rbo)
-	// Ensure framebuffer is complete
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
-	}
+	// Frambuffer config, a color texture and a renderbuffer for depth and
+	// stencil
+	fbo := framebuffer.Make(framebuffer.Description{
+		Width: windowWidth, Height: windowHeight,
+		Colors: []framebuffer.Attachment{{InternalFormat: gl.RGBA}},
+		Depth: &framebuffer.Attachment{InternalFormat: gl.DEPTH24_STENCIL8,
+			Renderbuffer: true},
+	})
+	defer fbo.Delete()
 
 	// Wireframe
 	//gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
@@ -268,7 +252,7 @@ func main() {
 		glfw.PollEvents()
 
 		// Binf frame buffer
-		gl.BindFramebuffer(gl.FRAMEBUFFER, framebuffer)
+		fbo.Bind()
 		gl.Enable(gl.DEPTH_TEST)
 
 		// Clear framebuffer contents
@@ -308,7 +292,7 @@ func main() {
 
 		screenShader.Use()
 		gl.BindVertexArray(quadVAO)
-		gl.BindTexture(gl.TEXTURE_2D, textureColorbuffer)
+		gl.BindTexture(gl.TEXTURE_2D, fbo.Colors[0])
 		gl.DrawArrays(gl.TRIANGLES, 0, 6)
 
 		window.SwapBuffers()
@@ -12,9 +12,10 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/cubemap"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
-	loadTexture "github.com/nicholasblaskey/go-learn-opengl/includes/texture"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/texture"
 )
 
 // Settings
@@ -232,7 +233,8 @@ func main() {
 		dir + "front.jpg",
 		dir + "back.jpg",
 	}
-	cubemapTexture := loadCubemap(faces)
+	cubemapTexture := cubemap.MakeFaces(faces,
+		texture.Options{NoMipmaps: true}).ID
 
 	// shader config
 	ourShader.Use()
@@ -353,26 +355,3 @@ func scroll_callback(w *glfw.Window, xOffset float64, yOffset float64) {
 func framebuffer_size_callback(w *glfw.Window, width int, height int) {
 	gl.Viewport(0, 0, int32(width), int32(height))
 }
-
-func loadCubemap(faces []string) uint32 {
-	var textureID uint32
-	gl.GenTextures(1, &textureID)
-	gl.BindTexture(gl.TEXTURE_CUBE_MAP, textureID)
-
-	for i := uint32(0); i < uint32(len(faces)); i++ {
-		data := loadTexture.ImageLoad(faces[i])
-		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i, 0, gl.RGBA,
-			int32(data.Rect.Size().X), int32(data.Rect.Size().Y),
-			0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(data.Pix))
-	}
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S,
-		gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T,
-		gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R,
-		gl.CLAMP_TO_EDGE)
-
-	return textureID
-}
@@ -125,7 +125,7 @@ func main() {
 		// Render the model
 		model := mgl32.Translate3D(0.0, -1.75, 0)
 		model = model.Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
-		ourShader.SetMat4("model", model)
+		ourModel.Transform = model
 
 		// Draw model as normal
 		ourModel.Draw(ourShader)
@@ -133,7 +133,6 @@ func main() {
 		normalShader.Use()
 		normalShader.SetMat4("projection", projection)
 		normalShader.SetMat4("view", view)
-		normalShader.SetMat4("model", model)
 
 		ourModel.Draw(normalShader)
 
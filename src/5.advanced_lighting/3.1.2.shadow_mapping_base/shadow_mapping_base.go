@@ -12,6 +12,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
@@ -135,24 +136,14 @@ func main() {
 
 	// Create and config fbo
 	SHADOW_WIDTH, SHADOW_HEIGHT := int32(1024), int32(1024)
-	var depthMap, depthMapFBO uint32
-	gl.GenFramebuffers(1, &depthMapFBO)
-	// Create depth texture
-	gl.GenTextures(1, &depthMap)
-	gl.BindTexture(gl.TEXTURE_2D, depthMap)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT, SHADOW_WIDTH,
-		SHADOW_HEIGHT, 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	// Attach the depth texture as our FBO's depth buffer
-	gl.BindFramebuffer(gl.FRAMEBUFFER, depthMapFBO)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT,
-		gl.TEXTURE_2D, depthMap, 0)
-	gl.DrawBuffer(gl.NONE)
-	gl.ReadBuffer(gl.NONE)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	// A depth texture and no colors
+	depthMapFBO := framebuffer.Make(framebuffer.Description{
+		Width: SHADOW_WIDTH, Height: SHADOW_HEIGHT,
+		Depth: &framebuffer.Attachment{InternalFormat: gl.DEPTH_COMPONENT,
+			Filter: gl.NEAREST, Wrap: gl.REPEAT},
+	})
+	defer depthMapFBO.Delete()
+	depthMap := depthMapFBO.Depth
 
 	// shader config
 	ourShader.Use()
@@ -187,8 +178,7 @@ func main() {
 		simpleDepthShader.Use()
 		simpleDepthShader.SetMat4("lightSpaceMatrix", lightSpaceMatrix)
 
-		gl.Viewport(0, 0, SHADOW_WIDTH, SHADOW_HEIGHT)
-		gl.BindFramebuffer(gl.FRAMEBUFFER, depthMapFBO)
+		depthMapFBO.Bind()
 		gl.Clear(gl.DEPTH_BUFFER_BIT)
 		gl.ActiveTexture(gl.TEXTURE0)
 		gl.BindTexture(gl.TEXTURE_2D, woodTexture)
@@ -14,6 +14,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
@@ -136,26 +137,14 @@ func main() {
 
 	// Create and config fbo
 	SHADOW_WIDTH, SHADOW_HEIGHT := int32(1024), int32(1024)
-	var depthCubemap, depthMapFBO uint32
-	gl.GenFramebuffers(1, &depthMapFBO)
-	// Create depth texture
-	gl.GenTextures(1, &depthCubemap)
-	gl.BindTexture(gl.TEXTURE_CUBE_MAP, depthCubemap)
-	for i := uint32(0); i < 6; i++ {
-		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i, 0, gl.DEPTH_COMPONENT,
-			SHADOW_WIDTH, SHADOW_HEIGHT, 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
-	}
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
-	// Attach the depth texture as our FBO's depth buffer
-	gl.BindFramebuffer(gl.FRAMEBUFFER, depthMapFBO)
-	gl.FramebufferTexture(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, depthCubemap, 0)
-	gl.DrawBuffer(gl.NONE)
-	gl.ReadBuffer(gl.NONE)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	// A depth cubemap attached whole, the geometry shader picks the face
+	depthMapFBO := framebuffer.Make(framebuffer.Description{
+		Width: SHADOW_WIDTH, Height: SHADOW_HEIGHT, Cubemap: true,
+		Depth: &framebuffer.Attachment{InternalFormat: gl.DEPTH_COMPONENT,
+			Filter: gl.NEAREST},
+	})
+	defer depthMapFBO.Delete()
+	depthCubemap := depthMapFBO.Depth
 
 	// shader config
 	ourShader.Use()
@@ -202,8 +191,7 @@ func main() {
 		}
 
 		// 1. Render scene to depth cube map
-		gl.Viewport(0, 0, SHADOW_WIDTH, SHADOW_HEIGHT)
-		gl.BindFramebuffer(gl.FRAMEBUFFER, depthMapFBO)
+		depthMapFBO.Bind()
 		gl.Clear(gl.DEPTH_BUFFER_BIT)
 		simpleDepthShader.Use()
 		for i := 0; i < 6; i++ {
@@ -13,6 +13,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
@@ -97,29 +98,15 @@ func main() {
 	dir := "../../../resources/textures"
 	woodTexture := loadModel.TextureFromFile("wood.png", dir, false)
 
-	// Configure floating point framebuffer
-	var hdrFBO, colorBuffer uint32
-	gl.GenFramebuffers(1, &hdrFBO)
-	// Create depth texture
-	gl.GenTextures(1, &colorBuffer)
-	gl.BindTexture(gl.TEXTURE_2D, colorBuffer)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, windowWidth, windowHeight,
-		0, gl.RGBA, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	// Create depth buffer (renderbuffer)
-	var rboDepth uint32
-	gl.GenRenderbuffers(1, &rboDepth)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, rboDepth)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, windowWidth, windowHeight)
-	// Attach buffers
-	gl.BindFramebuffer(gl.FRAMEBUFFER, hdrFBO)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, colorBuffer, 0)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, rboDepth)
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Framebuffer not complete")
-	}
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	// Configure floating point framebuffer with a depth renderbuffer
+	hdrFBO := framebuffer.Make(framebuffer.Description{
+		Width: windowWidth, Height: windowHeight,
+		Colors: []framebuffer.Attachment{{InternalFormat: gl.RGBA16F}},
+		Depth: &framebuffer.Attachment{InternalFormat: gl.DEPTH_COMPONENT,
+			Renderbuffer: true},
+	})
+	defer hdrFBO.Delete()
+	colorBuffer := hdrFBO.Colors[0]
 
 	// Lighting info
 	lightPositions := []mgl32.Vec3{
@@ -156,7 +143,7 @@ func main() {
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 		// 1. Render the scene into the floating point framebuffer
-		gl.BindFramebuffer(gl.FRAMEBUFFER, hdrFBO)
+		hdrFBO.Bind()
 		{
 			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 			ourShader.Use()
@@ -13,6 +13,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
@@ -100,60 +101,28 @@ func main() {
 	woodTexture := loadModel.TextureFromFile("wood.png", dir, false)
 	containerTexture := loadModel.TextureFromFile("container2.png", dir, false)
 
-	// Configure floating point framebuffer
-	var hdrFBO uint32
-	gl.GenFramebuffers(1, &hdrFBO)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, hdrFBO)
-	// Create depth texture
-	colorBuffers := make([]uint32, 2)
-	gl.GenTextures(2, &colorBuffers[0])
-	for i, colorBuffer := range colorBuffers {
-		gl.BindTexture(gl.TEXTURE_2D, colorBuffer)
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, windowWidth, windowHeight,
-			0, gl.RGBA, gl.FLOAT, nil)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-		// Attach texture to frame buffer
-		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0+uint32(i),
-			gl.TEXTURE_2D, colorBuffer, 0)
-	}
-
-	// Create and attach depth buffer (renderbuffer)
-	var rboDepth uint32
-	gl.GenRenderbuffers(1, &rboDepth)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, rboDepth)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, windowWidth, windowHeight)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, rboDepth)
-	// tell OpenGL which color attachments we will be using for rendering
-	attachments := []uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
-	gl.DrawBuffers(2, &attachments[0])
-	// Chck if frame buffer is complete
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Framebuffer not complete")
-	}
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	// Configure floating point framebuffer, the scene goes in the first
+	// color and only its bright parts in the second
+	hdrFBO := framebuffer.Make(framebuffer.Description{
+		Width: windowWidth, Height: windowHeight,
+		Colors: []framebuffer.Attachment{{InternalFormat: gl.RGBA16F},
+			{InternalFormat: gl.RGBA16F}},
+		Depth: &framebuffer.Attachment{InternalFormat: gl.DEPTH_COMPONENT,
+			Renderbuffer: true},
+	})
+	defer hdrFBO.Delete()
+	colorBuffers := hdrFBO.Colors
 
 	// Ping-pong-framebuffer for blurring
-	pingpongFBO := make([]uint32, 2)
+	pingpongFBO := make([]*framebuffer.Framebuffer, 2)
 	pingpongColorbuffers := make([]uint32, 2)
-	gl.GenFramebuffers(2, &pingpongFBO[0])
-	gl.GenTextures(2, &pingpongColorbuffers[0])
-	for i := 0; i < len(pingpongFBO); i++ {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, pingpongFBO[i])
-		gl.BindTexture(gl.TEXTURE_2D, pingpongColorbuffers[i])
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F,
-			windowWidth, windowHeight, 0, gl.RGBA, gl.FLOAT, nil)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-			gl.TEXTURE_2D, pingpongColorbuffers[i], 0)
-		if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-			panic("Ping pong frame buffer failed to complete")
-		}
+	for i := range pingpongFBO {
+		pingpongFBO[i] = framebuffer.Make(framebuffer.Description{
+			Width: windowWidth, Height: windowHeight,
+			Colors: []framebuffer.Attachment{{InternalFormat: gl.RGBA16F}},
+		})
+		defer pingpongFBO[i].Delete()
+		pingpongColorbuffers[i] = pingpongFBO[i].Colors[0]
 	}
 
 	// Lighting info
@@ -194,7 +163,7 @@ func main() {
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 		// 1. Render the scene into the floating point framebuffer
-		gl.BindFramebuffer(gl.FRAMEBUFFER, hdrFBO)
+		hdrFBO.Bind()
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 		ourShader.Use()
 		projection := mgl32.Perspective(mgl32.DegToRad(ourCamera.Zoom),
@@ -277,9 +246,9 @@ func main() {
 		shaderBlur.Use()
 		for i := 0; i < amount; i++ {
 			if horizontal {
-				gl.BindFramebuffer(gl.FRAMEBUFFER, pingpongFBO[1])
+				pingpongFBO[1].Bind()
 			} else {
-				gl.BindFramebuffer(gl.FRAMEBUFFER, pingpongFBO[0])
+				pingpongFBO[0].Bind()
 			}
 			shaderBlur.SetBool("horizontal", horizontal)
 
@@ -14,6 +14,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
@@ -115,54 +116,21 @@ func main() {
 		mgl32.Vec3{3.0, -0.5, 3.0},
 	}
 
-	// Configure g-buffer framebuffer
-	var gBuffer uint32
-	gl.GenFramebuffers(1, &gBuffer)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, gBuffer)
-	var gPosition, gNormal, gAlbedoSpec uint32
-	// Position color buffer
-	gl.GenTextures(1, &gPosition)
-	gl.BindTexture(gl.TEXTURE_2D, gPosition)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, windowWidth,
-		windowHeight, 0, gl.RGBA, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.TEXTURE_2D, gPosition, 0)
-	// Normal color buffer
-	gl.GenTextures(1, &gNormal)
-	gl.BindTexture(gl.TEXTURE_2D, gNormal)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, windowWidth,
-		windowHeight, 0, gl.RGBA, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT1,
-		gl.TEXTURE_2D, gNormal, 0)
-	// Color + specular color buffer
-	gl.GenTextures(1, &gAlbedoSpec)
-	gl.BindTexture(gl.TEXTURE_2D, gAlbedoSpec)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, windowWidth,
-		windowHeight, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT2,
-		gl.TEXTURE_2D, gAlbedoSpec, 0)
-	// Tell OpenGL which color attachments we will use (of this framebuffer) for rendering
-	attachments := []uint32{gl.COLOR_ATTACHMENT0,
-		gl.COLOR_ATTACHMENT1, gl.COLOR_ATTACHMENT2}
-	gl.DrawBuffers(3, &attachments[0])
-
-	// Create and attach depth buffer (renderbuffer)
-	var rboDepth uint32
-	gl.GenRenderbuffers(1, &rboDepth)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, rboDepth)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, windowWidth, windowHeight)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, rboDepth)
-	// Check if frame buffer is complete
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Framebuffer not complete")
-	}
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	// Configure g-buffer framebuffer with position, normal and color +
+	// specular color buffers
+	gBuffer := framebuffer.Make(framebuffer.Description{
+		Width: windowWidth, Height: windowHeight,
+		Colors: []framebuffer.Attachment{
+			{InternalFormat: gl.RGBA16F, Filter: gl.NEAREST},
+			{InternalFormat: gl.RGBA16F, Filter: gl.NEAREST},
+			{InternalFormat: gl.RGBA, Filter: gl.NEAREST},
+		},
+		Depth: &framebuffer.Attachment{InternalFormat: gl.DEPTH_COMPONENT,
+			Renderbuffer: true},
+	})
+	defer gBuffer.Delete()
+	gPosition, gNormal, gAlbedoSpec := gBuffer.Colors[0], gBuffer.Colors[1],
+		gBuffer.Colors[2]
 
 	// Lighting info
 	numLights := 32
@@ -201,7 +169,7 @@ func main() {
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 		// 1. Geometry pass: render scene's geometry / color data into gbuffer
-		gl.BindFramebuffer(gl.FRAMEBUFFER, gBuffer)
+		gBuffer.Bind()
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 		projection := mgl32.Perspective(mgl32.DegToRad(ourCamera.Zoom),
 			float32(windowWidth)/windowHeight, 0.1, 100.0)
@@ -212,7 +180,7 @@ func main() {
 		for _, pos := range objectPositions {
 			model := mgl32.Translate3D(pos[0], pos[1], pos[2]).Mul4(
 				mgl32.Scale3D(0.5, 0.5, 0.5))
-			shaderGeometryPass.SetMat4("model", model)
+			backpack.Transform = model
 			backpack.Draw(shaderGeometryPass)
 		}
 		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
@@ -244,11 +212,7 @@ func main() {
 		renderQuad()
 
 		// 2.5. Copy content of geometry's depth buffer to default framebuffer's depth buffer
-		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, gBuffer)
-		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0) // Write to default framebuffer
-		gl.BlitFramebuffer(0, 0, windowWidth, windowHeight, 0, 0,
-			windowWidth, windowWidth, gl.DEPTH_BUFFER_BIT, gl.NEAREST)
-		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		framebuffer.Blit(gBuffer, nil, gl.DEPTH_BUFFER_BIT, gl.NEAREST)
 
 		// 3. Render lights on top of scene
 		shaderLightBox.Use()
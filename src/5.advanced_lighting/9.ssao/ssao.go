@@ -14,6 +14,7 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 )
@@ -106,88 +107,35 @@ func main() {
 	backpack := loadModel.NewModel(
 		"../../../resources/objects/backpack/backpack.obj", false)
 
-	// Configure g-buffer framebuffer
-	var gBuffer uint32
-	gl.GenFramebuffers(1, &gBuffer)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, gBuffer)
-	var gPosition, gNormal, gAlbedo uint32
-	// Position color buffer
-	gl.GenTextures(1, &gPosition)
-	gl.BindTexture(gl.TEXTURE_2D, gPosition)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, windowWidth,
-		windowHeight, 0, gl.RGBA, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.TEXTURE_2D, gPosition, 0)
-	// Normal color buffer
-	gl.GenTextures(1, &gNormal)
-	gl.BindTexture(gl.TEXTURE_2D, gNormal)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, windowWidth,
-		windowHeight, 0, gl.RGBA, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT1,
-		gl.TEXTURE_2D, gNormal, 0)
-	// Color + specular color buffer
-	gl.GenTextures(1, &gAlbedo)
-	gl.BindTexture(gl.TEXTURE_2D, gAlbedo)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, windowWidth,
-		windowHeight, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT2,
-		gl.TEXTURE_2D, gAlbedo, 0)
-	// Tell OpenGL which color attachments we will use (of this framebuffer) for rendering
-	attachments := []uint32{gl.COLOR_ATTACHMENT0,
-		gl.COLOR_ATTACHMENT1, gl.COLOR_ATTACHMENT2}
-	gl.DrawBuffers(3, &attachments[0])
-
-	// Create and attach depth buffer (renderbuffer)
-	var rboDepth uint32
-	gl.GenRenderbuffers(1, &rboDepth)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, rboDepth)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, windowWidth, windowHeight)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, rboDepth)
-	// Check if frame buffer is complete
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Framebuffer not complete")
-	}
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
-
-	// Also create a framebuffer to hold SSAO processing stage
-	var ssaoFBO, ssaoBlurFBO uint32
-	gl.GenFramebuffers(1, &ssaoFBO)
-	gl.GenFramebuffers(1, &ssaoBlurFBO)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, ssaoFBO)
-	var ssaoColorBuffer, ssaoColorBufferBlur uint32
-	// SSAO color buffer
-	gl.GenTextures(1, &ssaoColorBuffer)
-	gl.BindTexture(gl.TEXTURE_2D, ssaoColorBuffer)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RED, windowWidth,
-		windowHeight, 0, gl.RED, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.TEXTURE_2D, ssaoColorBuffer, 0)
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("SSAO Framebuffer not complete")
-	}
-	// Blur stage too
-	gl.BindFramebuffer(gl.FRAMEBUFFER, ssaoBlurFBO)
-	gl.GenTextures(1, &ssaoColorBufferBlur)
-	gl.BindTexture(gl.TEXTURE_2D, ssaoColorBufferBlur)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RED, windowWidth,
-		windowHeight, 0, gl.RED, gl.FLOAT, nil)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.TEXTURE_2D, ssaoColorBufferBlur, 0)
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("SSAO Blur Framebuffer not complete")
+	// Configure g-buffer framebuffer with position, normal and color +
+	// specular color buffers
+	gBuffer := framebuffer.Make(framebuffer.Description{
+		Width: windowWidth, Height: windowHeight,
+		Colors: []framebuffer.Attachment{
+			{InternalFormat: gl.RGBA16F, Filter: gl.NEAREST},
+			{InternalFormat: gl.RGBA16F, Filter: gl.NEAREST},
+			{InternalFormat: gl.RGBA, Filter: gl.NEAREST},
+		},
+		Depth: &framebuffer.Attachment{InternalFormat: gl.DEPTH_COMPONENT,
+			Renderbuffer: true},
+	})
+	defer gBuffer.Delete()
+	gPosition, gNormal, gAlbedo := gBuffer.Colors[0], gBuffer.Colors[1],
+		gBuffer.Colors[2]
+
+	// Also create a framebuffer to hold SSAO processing stage and one for
+	// the blur stage too
+	ssaoDesc := framebuffer.Description{
+		Width: windowWidth, Height: windowHeight,
+		Colors: []framebuffer.Attachment{
+			{InternalFormat: gl.RED, Filter: gl.NEAREST}},
 	}
+	ssaoFBO := framebuffer.Make(ssaoDesc)
+	defer ssaoFBO.Delete()
+	ssaoBlurFBO := framebuffer.Make(ssaoDesc)
+	defer ssaoBlurFBO.Delete()
+	ssaoColorBuffer := ssaoFBO.Colors[0]
+	ssaoColorBufferBlur := ssaoBlurFBO.Colors[0]
 
 	// Generate sample kernel
 	ssaoKernel := []mgl32.Vec3{}
@@ -249,7 +197,7 @@ func main() {
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 		// 1. Geometry pass: render scene's geometry / color data into gbuffer
-		gl.BindFramebuffer(gl.FRAMEBUFFER, gBuffer)
+		gBuffer.Bind()
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 		projection := mgl32.Perspective(mgl32.DegToRad(ourCamera.Zoom),
 			float32(windowWidth)/windowHeight, 0.1, 100.0)
@@ -272,12 +220,12 @@ func main() {
 			mgl32.HomogRotate3D(
 				mgl32.DegToRad(-90.0), mgl32.Vec3{1.0, 0.0, 0.0}).Mul4(
 				mgl32.Scale3D(1.0, 1.0, 1.0)))
-		shaderGeometryPass.SetMat4("model", model)
+		backpack.Transform = model
 		backpack.Draw(shaderGeometryPass)
 		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
 
 		// 2. Generate SSAO texture
-		gl.BindFramebuffer(gl.FRAMEBUFFER, ssaoFBO)
+		ssaoFBO.Bind()
 		gl.Clear(gl.COLOR_BUFFER_BIT)
 		shaderSSAO.Use()
 		// Send kernel and rotation
@@ -295,7 +243,7 @@ func main() {
 		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
 
 		// 3. Blur SSAO texture to remove noise
-		gl.BindFramebuffer(gl.FRAMEBUFFER, ssaoBlurFBO)
+		ssaoBlurFBO.Bind()
 		gl.Clear(gl.COLOR_BUFFER_BIT)
 		shaderSSAOBlur.Use()
 		gl.ActiveTexture(gl.TEXTURE0)
@@ -14,11 +14,11 @@ import (
 	"github.com/go-gl/glfw/v3.1/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 
-	"github.com/nicholasblaskey/stbi"
-
 	"github.com/nicholasblaskey/go-learn-opengl/includes/camera"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/cubemap"
 	loadModel "github.com/nicholasblaskey/go-learn-opengl/includes/model"
 	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/texture"
 )
 
 // Settings
@@ -102,8 +102,6 @@ func main() {
 
 	// Build and compile shaders
 	pbrShader := shader.MakeShaders("2.2.2.pbr.vs", "2.2.2.pbr.fs")
-	equaiRectToCubeMapShader := shader.MakeShaders(
-		"2.2.2.cubemap.vs", "2.2.2.equirectangular_to_cubemap.fs")
 	irradianceShader := shader.MakeShaders("2.2.2.cubemap.vs",
 		"2.2.2.irradiance_convolution.fs")
 	prefilterShader := shader.MakeShaders("2.2.2.cubemap.vs", "2.2.2.prefilter.fs")
@@ -179,6 +177,17 @@ func main() {
 		mgl32.Vec3{300.0, 300.0, 300.0},
 	}
 
+	// Pbr: load the HDR environment map and convert it to a cubemap,
+	// with mipmaps to combat the visible dots artifact
+	envCubemap := cubemap.MakeEquirectangular(
+		"../../../resources/textures/hdr/newport_loft.hdr", 512,
+		texture.Options{}).ID
+
+	// Pbr: set up projection and view matrices for capturing data onto the 6
+	// cubemap face directions
+	captureProjection := cubemap.CaptureProjection()
+	captureViews := cubemap.CaptureViews()
+
 	// Pbr: set up the framebuffer
 	var captureFBO, captureRBO uint32
 	gl.GenFramebuffers(1, &captureFBO)
@@ -189,74 +198,6 @@ func main() {
 	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, 512, 512)
 	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, captureRBO)
 
-	// Pbr load the HDR environment map
-	path := "../../../resources/textures/hdr/newport_loft.hdr"
-	data, width, height, _, cleanup, err := stbi.Loadf(path, true, 0)
-	if err != nil {
-		panic(err)
-	}
-	defer cleanup()
-
-	var hdrTexture uint32
-	gl.GenTextures(1, &hdrTexture)
-	gl.BindTexture(gl.TEXTURE_2D, hdrTexture)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB16F, int32(width), int32(height), 0,
-		gl.RGB, gl.FLOAT, data)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-	// Pbr: set up the cubemap to render to and attach to framebuffer
-	var envCubemap uint32
-	gl.GenTextures(1, &envCubemap)
-	gl.BindTexture(gl.TEXTURE_CUBE_MAP, envCubemap)
-	for i := 0; i < 6; i++ {
-		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), 0, gl.RGB16F,
-			512, 512, 0, gl.RGB, gl.FLOAT, nil)
-	}
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER,
-		gl.LINEAR_MIPMAP_LINEAR)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-	// Pbr: set up projection and view matrices for capturing data onto the 6
-	// cubemap face directions
-	captureProjection := mgl32.Perspective(mgl32.DegToRad(90.0), 1.0, 0.1, 10.0)
-	captureViews := []mgl32.Mat4{
-		mgl32.LookAt(0.0, 0.0, 0.0, +1.0, +0.0, +0.0, +0.0, -1.0, +0.0),
-		mgl32.LookAt(0.0, 0.0, 0.0, -1.0, +0.0, +0.0, +0.0, -1.0, +0.0),
-		mgl32.LookAt(0.0, 0.0, 0.0, +0.0, +1.0, +0.0, +0.0, +0.0, +1.0),
-		mgl32.LookAt(0.0, 0.0, 0.0, +0.0, -1.0, +0.0, +0.0, +0.0, -1.0),
-		mgl32.LookAt(0.0, 0.0, 0.0, +0.0, +0.0, +1.0, +0.0, -1.0, +0.0),
-		mgl32.LookAt(0.0, 0.0, 0.0, +0.0, +0.0, -1.0, +0.0, -1.0, +0.0),
-	}
-
-	// Pbr: convert HDR equirectangular environment map to cubemap equivalent
-	equaiRectToCubeMapShader.Use()
-	equaiRectToCubeMapShader.SetInt("equirectangularMap", 0)
-	equaiRectToCubeMapShader.SetMat4("projection", captureProjection)
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, hdrTexture)
-
-	gl.Viewport(0, 0, 512, 512)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, captureFBO)
-	for i, captureView := range captureViews {
-		equaiRectToCubeMapShader.SetMat4("view", captureView)
-		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-			gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), envCubemap, 0)
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-
-		renderCube()
-	}
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
-
-	// Then let OpenGL generate mipmaps from the first mip face (combatting visible dots artifact)
-	gl.BindTexture(gl.TEXTURE_CUBE_MAP, envCubemap)
-	gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
-
 	// Pbr: create an irradiance cubemap, and re-scale capture FBO to irradiance scale.
 	var irradianceMap uint32
 	gl.GenTextures(1, &irradianceMap)
@@ -85,10 +85,12 @@ func (g *Game) Init() {
 	// Configure shaders
 	projection := mgl32.Ortho(0.0, float32(g.Width), float32(g.Height), 0.0,
 		-1.0, 1.0)
-	resourceManager.Shaders["sprite"].SetInteger("image", 0, true)
-	resourceManager.Shaders["sprite"].SetMatrix4("projection", projection, false)
-	resourceManager.Shaders["particle"].SetInteger("sprite", 0, true)
-	resourceManager.Shaders["particle"].SetMatrix4("projection", projection, false)
+	resourceManager.Shaders["sprite"].Use()
+	resourceManager.Shaders["sprite"].SetInt("image", 0)
+	resourceManager.Shaders["sprite"].SetMat4("projection", projection)
+	resourceManager.Shaders["particle"].Use()
+	resourceManager.Shaders["particle"].SetInt("sprite", 0)
+	resourceManager.Shaders["particle"].SetMat4("projection", projection)
 
 	// Load textures
 	resourceManager.LoadTexture(textureDir+"background.jpg", "background")
@@ -7,8 +7,8 @@ import (
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 
+	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/gameObject"
-	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/shader"
 	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/texture"
 )
 
@@ -22,13 +22,13 @@ type Particle struct {
 type Generator struct {
 	Particles        []*Particle
 	Amount           uint32
-	Shader           *shader.Shader
+	Shader           shader.Shader
 	Texture          *texture.Texture
 	VAO              uint32
 	lastUsedParticle uint32
 }
 
-func NewGenerator(s *shader.Shader, t *texture.Texture, amount uint32) *Generator {
+func NewGenerator(s shader.Shader, t *texture.Texture, amount uint32) *Generator {
 	g := &Generator{Shader: s, Texture: t, Amount: amount}
 	g.init()
 
@@ -62,8 +62,8 @@ func (g *Generator) Draw() {
 
 	g.Shader.Use()
 	for _, p := range g.Particles {
-		g.Shader.SetVector2f("offset", p.Position, false)
-		g.Shader.SetVector4f("color", p.Color, false)
+		g.Shader.SetVec2("offset", p.Position)
+		g.Shader.SetVec4("color", p.Color)
 		g.Texture.Bind()
 		gl.BindVertexArray(g.VAO)
 		gl.DrawArrays(gl.TRIANGLES, 0, 6)
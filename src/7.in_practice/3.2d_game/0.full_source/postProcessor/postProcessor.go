@@ -6,58 +6,50 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	// Gross import path todo fix later
-	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/shader"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/framebuffer"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/texture"
 )
 
 type PostProcessor struct {
-	Shader  *shader.Shader
+	Shader  shader.Shader
 	Texture *texture.Texture
 	Width   int32
 	Height  int32
 	Chaos   bool
 	Shake   bool
 	Confuse bool
-	MSFBO   uint32
-	FBO     uint32
-	RBO     uint32
+	MSFBO   *framebuffer.Framebuffer
+	FBO     *framebuffer.Framebuffer
 	VAO     uint32
 }
 
-func New(s *shader.Shader, width, height int32) *PostProcessor {
+func New(s shader.Shader, width, height int32) *PostProcessor {
 	p := &PostProcessor{Shader: s, Width: width, Height: height}
-	// Initialize renderbuffer / framebuffer object
-	gl.GenFramebuffers(1, &p.MSFBO)
-	gl.GenFramebuffers(1, &p.FBO)
-	gl.GenRenderbuffers(1, &p.RBO)
-
-	// Initialize renderbuffer storage with a multisampled color buffer
-	gl.BindFramebuffer(gl.FRAMEBUFFER, p.MSFBO)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, p.RBO)
-	// Allocate storage for RBO object
-	gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, 4, gl.RGB, width, height)
-	// Attach MS render buffer object to framebuffer
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.RENDERBUFFER, p.RBO)
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Postprocessor failed to initialize MSFBO")
-	}
+	// Initialize the framebuffer with a multisampled color renderbuffer
+	p.MSFBO = framebuffer.Make(framebuffer.Description{
+		Width: width, Height: height, Samples: 4,
+		Colors: []framebuffer.Attachment{
+			{InternalFormat: gl.RGB, Renderbuffer: true}},
+	})
 
 	// Also initialize the FBO / texture to blit multisample color-buffer to
 	// be used for shader operations (for postprocessing effects)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, p.FBO)
-	p.Texture = texture.New()
-	p.Texture.Generate(width, height, nil)
-	// Attach texture to framebuffer as its color attachment
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, p.Texture.ID, 0)
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
-		panic("Postprocessor failed to initialize FBO")
-	}
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	p.FBO = framebuffer.Make(framebuffer.Description{
+		Width: width, Height: height,
+		Colors: []framebuffer.Attachment{
+			{InternalFormat: gl.RGBA, Wrap: gl.REPEAT}},
+	})
+	p.Texture = &texture.Texture{ID: p.FBO.Colors[0],
+		Width: width, Height: height,
+		InternalFormat: gl.RGBA, ImageFormat: gl.RGBA,
+		WrapS: gl.REPEAT, WrapT: gl.REPEAT,
+		FilterMin: gl.LINEAR, FilterMax: gl.LINEAR}
 
 	// Initialize render data and uniforms
 	p.initRenderData()
-	p.Shader.SetInteger("scene", 0, true)
+	p.Shader.Use()
+	p.Shader.SetInt("scene", 0)
 	offset := float32(1.0 / 300.0)
 	offsets := []mgl32.Vec2{
 		mgl32.Vec2{-offset, offset},  // top-left
@@ -70,30 +62,27 @@ func New(s *shader.Shader, width, height int32) *PostProcessor {
 		mgl32.Vec2{0.0, -offset},     // bottom-center
 		mgl32.Vec2{offset, -offset},  // bottom right
 	}
-	gl.Uniform2fv(gl.GetUniformLocation(p.Shader.ID,
-		gl.Str("offsets\x00")), 9, &offsets[0][0])
+	p.Shader.SetVec2Array("offsets", offsets)
 
 	edgeKernel := []int32{
 		-1, -1, -1,
 		-1, +8, -1,
 		-1, -1, -1,
 	}
-	gl.Uniform1iv(gl.GetUniformLocation(p.Shader.ID,
-		gl.Str("edge_kernel\x00")), 9, &edgeKernel[0])
+	p.Shader.SetIntArray("edge_kernel", edgeKernel)
 
 	blurKernel := []float32{
 		1.0 / 16.0, 2.0 / 16.0, 1.0 / 16.0,
 		2.0 / 16.0, 4.0 / 16.0, 2.0 / 16.0,
 		1.0 / 16.0, 2.0 / 16.0, 1.0 / 16.0,
 	}
-	gl.Uniform1fv(gl.GetUniformLocation(p.Shader.ID,
-		gl.Str("blur_kernel\x00")), 9, &blurKernel[0])
+	p.Shader.SetFloatArray("blur_kernel", blurKernel)
 
 	return p
 }
 
 func (p *PostProcessor) BeginRender() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, p.MSFBO)
+	p.MSFBO.Bind()
 	gl.ClearColor(0.0, 0.0, 0.0, 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT)
 }
@@ -101,11 +90,7 @@ func (p *PostProcessor) BeginRender() {
 func (p *PostProcessor) EndRender() {
 	// Now resolve multisampled color-buffer into intermidate FBO to
 	// store its texture
-	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, p.MSFBO)
-	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, p.FBO)
-	gl.BlitFramebuffer(0, 0, p.Width, p.Height, 0, 0, p.Width, p.Height,
-		gl.COLOR_BUFFER_BIT, gl.NEAREST)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	framebuffer.Blit(p.MSFBO, p.FBO, gl.COLOR_BUFFER_BIT, gl.NEAREST)
 }
 
 func boolToInt(b bool) int32 {
@@ -117,10 +102,11 @@ func boolToInt(b bool) int32 {
 
 func (p *PostProcessor) Render(time float32) {
 	// Set unfiorms
-	p.Shader.SetFloat("time", time, true)
-	p.Shader.SetInteger("confuse", boolToInt(p.Confuse), false)
-	p.Shader.SetInteger("chaos", boolToInt(p.Chaos), false)
-	p.Shader.SetInteger("shake", boolToInt(p.Shake), false)
+	p.Shader.Use()
+	p.Shader.SetFloat("time", time)
+	p.Shader.SetInt("confuse", boolToInt(p.Confuse))
+	p.Shader.SetInt("chaos", boolToInt(p.Chaos))
+	p.Shader.SetInt("shake", boolToInt(p.Shake))
 
 	// Render texture quad
 	gl.ActiveTexture(gl.TEXTURE0)
@@ -1,8 +1,6 @@
 package resourceManager
 
 import (
-	"io/ioutil"
-
 	"github.com/go-gl/gl/v4.1-core/gl"
 
 	"image"
@@ -11,22 +9,22 @@ import (
 	_ "image/png"
 	"os"
 
-	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/shader"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/texture"
 )
 
 var (
 	Textures map[string]*texture.Texture = make(map[string]*texture.Texture)
-	Shaders  map[string]*shader.Shader   = make(map[string]*shader.Shader)
+	Shaders  map[string]shader.Shader    = make(map[string]shader.Shader)
 )
 
-func LoadShader(vShaderFile, fShaderFile, name string) *shader.Shader {
-	Shaders[name] = loadShaderFromFile(vShaderFile, fShaderFile, "")
+func LoadShader(vShaderFile, fShaderFile, name string) shader.Shader {
+	Shaders[name] = shader.MakeShaders(vShaderFile, fShaderFile)
 	return Shaders[name]
 }
 
-func LoadShaderGeom(vShaderFile, fShaderFile, gShaderFile, name string) *shader.Shader {
-	Shaders[name] = loadShaderFromFile(vShaderFile, fShaderFile, gShaderFile)
+func LoadShaderGeom(vShaderFile, fShaderFile, gShaderFile, name string) shader.Shader {
+	Shaders[name] = shader.MakeGeomShaders(vShaderFile, fShaderFile, gShaderFile)
 	return Shaders[name]
 }
 
@@ -44,31 +42,6 @@ func Clear() {
 	}
 }
 
-func loadShaderFromFile(vShaderFile, fShaderFile, gShaderFile string) *shader.Shader {
-	vertexCodeBytes, err := ioutil.ReadFile(vShaderFile)
-	if err != nil {
-		panic(err)
-	}
-	vertexCode := string(vertexCodeBytes)
-
-	fragmentCodeBytes, err := ioutil.ReadFile(fShaderFile)
-	if err != nil {
-		panic(err)
-	}
-	fragmentCode := string(fragmentCodeBytes)
-
-	if gShaderFile != "" {
-		geoCodeBytes, err := ioutil.ReadFile(gShaderFile)
-		if err != nil {
-			panic(err)
-		}
-		geoCode := string(geoCodeBytes)
-
-		return shader.MakeGeomShaders(vertexCode, fragmentCode, geoCode)
-	}
-	return shader.MakeShaders(vertexCode, fragmentCode)
-}
-
 func loadTextureFromFile(file string) *texture.Texture {
 	f, err := os.Open(file)
 	if err != nil {
@@ -5,16 +5,16 @@ import (
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 
-	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/shader"
+	"github.com/nicholasblaskey/go-learn-opengl/includes/shader"
 	"github.com/nicholasblaskey/go-learn-opengl/src/7.in_practice/3.2d_game/0.full_source/texture"
 )
 
 type SpriteRenderer struct {
-	SpriteShader *shader.Shader
+	SpriteShader shader.Shader
 	VAO          uint32
 }
 
-func New(s *shader.Shader) *SpriteRenderer {
+func New(s shader.Shader) *SpriteRenderer {
 	sr := &SpriteRenderer{SpriteShader: s}
 	sr.initRenderData()
 	return sr
@@ -41,8 +41,9 @@ func (sr *SpriteRenderer) DrawSprite(texture *texture.Texture,
 	// Scale last
 	model = model.Mul4(mgl32.Scale3D(size[0], size[1], 1))
 
-	sr.SpriteShader.SetMatrix4("model", model, true)
-	sr.SpriteShader.SetVector3f("spriteColor", color, false)
+	sr.SpriteShader.Use()
+	sr.SpriteShader.SetMat4("model", model)
+	sr.SpriteShader.SetVec3("spriteColor", color)
 
 	gl.ActiveTexture(gl.TEXTURE0)
 	texture.Bind()